@@ -0,0 +1,345 @@
+package patternmatcher
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NodeKind is the kind of a Node of a pattern.
+type NodeKind int
+
+const (
+	// LiteralNode is a run of characters matching themselves.
+	LiteralNode NodeKind = iota
+	// StarNode is "*", matching any sequence of characters within a path
+	// element.
+	StarNode
+	// AnyNode is "?", matching a single character.
+	AnyNode
+	// GlobstarNode is "**", matching any sequence of characters, including
+	// separators.
+	GlobstarNode
+	// ClassNode is a character class, such as "[a-z]".
+	ClassNode
+)
+
+// String returns the name of the kind, such as "LiteralNode".
+func (k NodeKind) String() string {
+	switch k {
+	case LiteralNode:
+		return "LiteralNode"
+	case StarNode:
+		return "StarNode"
+	case AnyNode:
+		return "AnyNode"
+	case GlobstarNode:
+		return "GlobstarNode"
+	case ClassNode:
+		return "ClassNode"
+	}
+	return "NodeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// AST is the parsed structure of a pattern, as returned by ParseAST.
+// Positions are byte offsets in Pattern, so that tools can highlight the
+// parts of the text they refer to.
+type AST struct {
+	// Pattern is the text that was parsed.
+	Pattern string
+	// Exclusion reports that the pattern starts with "!".
+	Exclusion bool
+	// Anchored reports that the pattern starts with a separator. Patterns
+	// are always relative to the root, so it doesn't change what they
+	// match.
+	Anchored bool
+	// DirOnly reports that the pattern ends with a separator, so that it
+	// only matches directories when they are known.
+	DirOnly  bool
+	Segments []*Segment
+
+	// style is the path style the pattern was parsed with.
+	style PathStyle
+}
+
+// Segment is a path element of a pattern, between separators.
+type Segment struct {
+	Pos, End int
+	Nodes    []*Node
+}
+
+// Node is a part of a Segment.
+type Node struct {
+	Kind     NodeKind
+	Pos, End int
+	// Value is the text matched by a LiteralNode, with escapes removed.
+	Value string
+	// Negated reports that a ClassNode matches the characters outside of
+	// its ranges, as "[!a-z]" and "[^a-z]" do.
+	Negated bool
+	// Ranges are the ranges of characters of a ClassNode. A single
+	// character is a range whose bounds are equal.
+	Ranges []ClassRange
+}
+
+// ClassRange is a range of characters of a class, bounds included.
+type ClassRange struct {
+	Lo, Hi rune
+}
+
+// ParseAST parses the text of a pattern, as given to NewPatterns, without
+// cleaning it, so that external tools can analyze and highlight patterns
+// with the grammar of this package. Separators are "/" and the separator
+// of the PathStyle set by WithPathStyle, the host's by default, and
+// backslashes escape the next character unless they are separators. Other
+// options are ignored. Malformed classes return a *PatternError wrapping
+// filepath.ErrBadPattern.
+func ParseAST(pattern string, opts ...Option) (*AST, error) {
+	style := newOptions(opts).style
+	ast := &AST{Pattern: pattern, style: style}
+	p := astParser{text: pattern, style: style}
+	if p.peek() == '!' {
+		ast.Exclusion = true
+		p.pos++
+	}
+	if style.isSepRune(rune(p.peek())) {
+		ast.Anchored = true
+	}
+	var seg *Segment
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		if style.isSep(c) {
+			if seg != nil {
+				seg.End = p.pos
+				ast.Segments = append(ast.Segments, seg)
+				seg = nil
+			}
+			p.pos++
+			ast.DirOnly = len(ast.Segments) != 0 && p.pos == len(p.text)
+			continue
+		}
+		if seg == nil {
+			seg = &Segment{Pos: p.pos}
+		}
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		if n := len(seg.Nodes); n != 0 && node.Kind == LiteralNode && seg.Nodes[n-1].Kind == LiteralNode {
+			seg.Nodes[n-1].End = node.End
+			seg.Nodes[n-1].Value += node.Value
+		} else {
+			seg.Nodes = append(seg.Nodes, node)
+		}
+	}
+	if seg != nil {
+		seg.End = p.pos
+		ast.Segments = append(ast.Segments, seg)
+	}
+	return ast, nil
+}
+
+type astParser struct {
+	text  string
+	pos   int
+	style PathStyle
+}
+
+// peek returns the next byte, or 0 at the end of the text.
+func (p *astParser) peek() byte {
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+// next returns the next rune, with the backslash escaping it removed.
+func (p *astParser) next() rune {
+	if p.text[p.pos] == '\\' && p.style.escapes() && p.pos+1 < len(p.text) {
+		p.pos++
+	}
+	r, size := utf8.DecodeRuneInString(p.text[p.pos:])
+	p.pos += size
+	return r
+}
+
+// node parses the node starting at the current position, which isn't a
+// separator.
+func (p *astParser) node() (*Node, error) {
+	start := p.pos
+	switch p.text[p.pos] {
+	case '*':
+		p.pos++
+		if p.peek() != '*' {
+			return &Node{Kind: StarNode, Pos: start, End: p.pos}, nil
+		}
+		for p.peek() == '*' {
+			p.pos++
+		}
+		return &Node{Kind: GlobstarNode, Pos: start, End: p.pos}, nil
+	case '?':
+		p.pos++
+		return &Node{Kind: AnyNode, Pos: start, End: p.pos}, nil
+	case '[':
+		return p.class()
+	}
+	r := p.next()
+	return &Node{Kind: LiteralNode, Pos: start, End: p.pos, Value: string(r)}, nil
+}
+
+// class parses the character class starting at the current position.
+func (p *astParser) class() (*Node, error) {
+	node := &Node{Kind: ClassNode, Pos: p.pos}
+	bad := &PatternError{Pattern: p.text, Err: filepath.ErrBadPattern}
+	p.pos++
+	if c := p.peek(); c == '!' || c == '^' {
+		node.Negated = true
+		p.pos++
+	}
+	for {
+		if p.pos == len(p.text) || p.style.isSep(p.text[p.pos]) {
+			return nil, bad
+		}
+		if p.text[p.pos] == ']' {
+			p.pos++
+			break
+		}
+		lo := p.next()
+		hi := lo
+		if p.peek() == '-' {
+			p.pos++
+			if p.pos == len(p.text) || p.text[p.pos] == ']' {
+				return nil, bad
+			}
+			hi = p.next()
+			if hi < lo {
+				return nil, bad
+			}
+		}
+		node.Ranges = append(node.Ranges, ClassRange{Lo: lo, Hi: hi})
+	}
+	if len(node.Ranges) == 0 {
+		return nil, bad
+	}
+	node.End = p.pos
+	return node, nil
+}
+
+// Inspect calls fn for each segment of a, with a nil node, and then for
+// each of its nodes, in order. If fn returns false for a segment, its nodes
+// are skipped.
+func (a *AST) Inspect(fn func(seg *Segment, node *Node) bool) {
+	for _, seg := range a.Segments {
+		if !fn(seg, nil) {
+			continue
+		}
+		for _, node := range seg.Nodes {
+			fn(seg, node)
+		}
+	}
+}
+
+// Rewrite returns a copy of a in which each node is replaced with those fn
+// returns for it: returning the node unchanged keeps it, and returning nil
+// removes it. Segments left without nodes are removed. The nodes fn
+// receives are copies, which it may modify and return. Positions aren't
+// updated, and only refer to Pattern for the nodes fn kept.
+//
+// To transform the segments themselves, such as to prefix every pattern
+// with a directory, modify the Segments of the copy.
+func (a *AST) Rewrite(fn func(node *Node) []*Node) *AST {
+	rewritten := *a
+	rewritten.Segments = nil
+	for _, seg := range a.Segments {
+		var nodes []*Node
+		for _, node := range seg.Nodes {
+			n := *node
+			n.Ranges = append([]ClassRange(nil), node.Ranges...)
+			nodes = append(nodes, fn(&n)...)
+		}
+		if len(nodes) != 0 {
+			rewritten.Segments = append(rewritten.Segments, &Segment{Pos: seg.Pos, End: seg.End, Nodes: nodes})
+		}
+	}
+	return &rewritten
+}
+
+// LiteralSegment returns a segment matching the path element elem, for
+// adding it to an AST.
+func LiteralSegment(elem string) *Segment {
+	return &Segment{Nodes: []*Node{{Kind: LiteralNode, Value: elem}}}
+}
+
+// String returns the text of the pattern a stands for, which can be
+// compiled with NewPatterns. Separators are slashes, and the characters of
+// literals which would have a special meaning are quoted in classes.
+func (a *AST) String() string {
+	var sb strings.Builder
+	if a.Exclusion {
+		sb.WriteByte('!')
+	}
+	if a.Anchored {
+		sb.WriteByte('/')
+	}
+	for i, seg := range a.Segments {
+		if i > 0 {
+			sb.WriteByte('/')
+		}
+		for _, node := range seg.Nodes {
+			node.writeTo(&sb, a.style.escapes())
+		}
+	}
+	if a.DirOnly && len(a.Segments) != 0 {
+		sb.WriteByte('/')
+	}
+	return sb.String()
+}
+
+// writeTo writes the text of n to sb, escapes reporting whether
+// backslashes escape characters.
+func (n *Node) writeTo(sb *strings.Builder, escapes bool) {
+	switch n.Kind {
+	case LiteralNode:
+		for _, r := range n.Value {
+			switch {
+			case r == '*' || r == '?' || r == '[':
+				sb.WriteByte('[')
+				sb.WriteRune(r)
+				sb.WriteByte(']')
+			case r == '\\' && escapes:
+				sb.WriteString(`\\`)
+			default:
+				sb.WriteRune(r)
+			}
+		}
+	case StarNode:
+		sb.WriteByte('*')
+	case AnyNode:
+		sb.WriteByte('?')
+	case GlobstarNode:
+		sb.WriteString("**")
+	case ClassNode:
+		sb.WriteByte('[')
+		if n.Negated {
+			sb.WriteByte('^')
+		}
+		for _, rg := range n.Ranges {
+			writeClassRune(sb, rg.Lo, escapes)
+			if rg.Hi != rg.Lo {
+				sb.WriteByte('-')
+				writeClassRune(sb, rg.Hi, escapes)
+			}
+		}
+		sb.WriteByte(']')
+	}
+}
+
+// writeClassRune writes r as a bound of a class range, escaping the
+// characters special to classes if backslashes are escapes.
+func writeClassRune(sb *strings.Builder, r rune, escapes bool) {
+	if escapes && strings.ContainsRune(`]\-^!`, r) {
+		sb.WriteByte('\\')
+	}
+	sb.WriteRune(r)
+}
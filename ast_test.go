@@ -0,0 +1,109 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAST(t *testing.T) {
+	ast, err := ParseAST("!/src/**/*_[!a-c].go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ast.Exclusion || !ast.Anchored || !ast.DirOnly {
+		t.Errorf("unexpected flags %+v", ast)
+	}
+	want := []*Segment{
+		{Pos: 2, End: 5, Nodes: []*Node{{Kind: LiteralNode, Pos: 2, End: 5, Value: "src"}}},
+		{Pos: 6, End: 8, Nodes: []*Node{{Kind: GlobstarNode, Pos: 6, End: 8}}},
+		{Pos: 9, End: 20, Nodes: []*Node{
+			{Kind: StarNode, Pos: 9, End: 10},
+			{Kind: LiteralNode, Pos: 10, End: 11, Value: "_"},
+			{Kind: ClassNode, Pos: 11, End: 17, Negated: true, Ranges: []ClassRange{{'a', 'c'}}},
+			{Kind: LiteralNode, Pos: 17, End: 20, Value: ".go"},
+		}},
+	}
+	if !reflect.DeepEqual(ast.Segments, want) {
+		for i, seg := range ast.Segments {
+			for _, n := range seg.Nodes {
+				t.Logf("segment %d: %+v", i, n)
+			}
+		}
+		t.Errorf("unexpected segments")
+	}
+
+	ast, err = ParseAST("a?[xyz0-9]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes := ast.Segments[0].Nodes; len(nodes) != 3 || nodes[1].Kind != AnyNode || len(nodes[2].Ranges) != 4 {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	for _, p := range []string{"[a", "a[]", "[z-a]", "[a/b]"} {
+		if _, err := ParseAST(p); !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("%q: expected %v, got %v", p, filepath.ErrBadPattern, err)
+		}
+	}
+}
+
+func TestASTRewrite(t *testing.T) {
+	ast, err := ParseAST("!Build/**/*.[ch]/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ast.String(); s != "!Build/**/*.[ch]/" {
+		t.Errorf("expected the pattern back, got %q", s)
+	}
+
+	// Lower-case literals, and strip classes, which aren't supported by
+	// some other tool.
+	rewritten := ast.Rewrite(func(n *Node) []*Node {
+		switch n.Kind {
+		case LiteralNode:
+			n.Value = strings.ToLower(n.Value)
+		case ClassNode:
+			return []*Node{{Kind: AnyNode}}
+		}
+		return []*Node{n}
+	})
+	rewritten.Segments = append([]*Segment{LiteralSegment("src")}, rewritten.Segments...)
+	if s := rewritten.String(); s != "!src/build/**/*.?/" {
+		t.Errorf("unexpected rewritten pattern %q", s)
+	}
+	if s := ast.String(); s != "!Build/**/*.[ch]/" {
+		t.Errorf("expected the original to be unchanged, got %q", s)
+	}
+
+	var kinds []NodeKind
+	rewritten.Inspect(func(seg *Segment, n *Node) bool {
+		if n != nil {
+			kinds = append(kinds, n.Kind)
+		}
+		return true
+	})
+	if expected := []NodeKind{LiteralNode, LiteralNode, GlobstarNode, StarNode, LiteralNode, AnyNode}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected %v, got %v", expected, kinds)
+	}
+
+	// Literals with special characters are quoted, so that the result
+	// compiles to a pattern matching them.
+	quoted := ast.Rewrite(func(n *Node) []*Node {
+		if n.Kind == LiteralNode && n.Value == "Build" {
+			n.Value = "a*b?"
+		}
+		return []*Node{n}
+	})
+	pm, err := New([]string{quoted.String()[1:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]bool{"a*b?/x/y.c/": true, "aXbY/y.c": false} {
+		if matched, _ := pm.MatchesOrParentMatches(filepath.FromSlash(path)); matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+}
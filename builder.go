@@ -0,0 +1,91 @@
+package patternmatcher
+
+// PatternSetBuilder accumulates the patterns of a PatternMatcher which
+// change at runtime, such as those edited in an interactive tool. Each
+// pattern is compiled once, when it is added, and Build reuses the compiled
+// patterns rather than compiling the whole list again with New.
+//
+// A PatternSetBuilder isn't safe for concurrent use. The matchers it builds
+// are, and aren't affected by later changes to the builder.
+type PatternSetBuilder struct {
+	opts    *options
+	entries []builderEntry
+}
+
+// builderEntry is a line added to a PatternSetBuilder, and the patterns it
+// compiles to, which are several once braces are expanded, or none for a
+// blank line.
+type builderEntry struct {
+	line     string
+	patterns []*Pattern
+}
+
+// NewPatternSetBuilder returns an empty PatternSetBuilder, whose patterns
+// are compiled and matched with opts.
+func NewPatternSetBuilder(opts ...Option) *PatternSetBuilder {
+	return &PatternSetBuilder{opts: newOptions(opts)}
+}
+
+// AddPattern compiles line, as New does the lines of its list, and appends
+// it to the patterns of b, after which it takes precedence over those
+// already added. b isn't modified if line can't be compiled.
+func (b *PatternSetBuilder) AddPattern(line string) error {
+	patterns, err := newPatterns([]string{line}, b.opts)
+	if err != nil {
+		return err
+	}
+	for _, p := range patterns {
+		p.index = len(b.entries)
+	}
+	b.entries = append(b.entries, builderEntry{line: line, patterns: patterns})
+	return nil
+}
+
+// RemovePattern removes the last pattern added as line, and reports
+// whether there was one. The other patterns aren't compiled again.
+func (b *PatternSetBuilder) RemovePattern(line string) bool {
+	for i := len(b.entries) - 1; i >= 0; i-- {
+		if b.entries[i].line == line {
+			b.entries = append(b.entries[:i], b.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Patterns returns the lines added to b and not removed, in order.
+func (b *PatternSetBuilder) Patterns() []string {
+	lines := make([]string, len(b.entries))
+	for i, entry := range b.entries {
+		lines[i] = entry.line
+	}
+	return lines
+}
+
+// Build returns a PatternMatcher matching the current patterns of b, as New
+// would with the lines returned by Patterns.
+func (b *PatternSetBuilder) Build() (*PatternMatcher, error) {
+	var n int
+	for _, entry := range b.entries {
+		n += len(entry.patterns)
+	}
+	if max := b.opts.limits.MaxPatterns; max > 0 && n > max {
+		return nil, &LimitError{Limit: "MaxPatterns", Max: max}
+	}
+	all := make([]*Pattern, 0, n)
+	for i := range b.entries {
+		entry := &b.entries[i]
+		for j, p := range entry.patterns {
+			if p.index != i {
+				// The position of the line changed since it was
+				// compiled. The pattern may be used by matchers built
+				// before, which are immutable.
+				p = p.Clone()
+				p.index = i
+				entry.patterns[j] = p
+			}
+			all = append(all, p)
+		}
+	}
+	return newPatternMatcher(all, b.opts, nil)
+}
@@ -0,0 +1,82 @@
+package patternmatcher
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPatternSetBuilder(t *testing.T) {
+	b := NewPatternSetBuilder(WithBraceExpansion())
+	for _, line := range []string{"*.{o,a}", "build", "!build/keep", "build"} {
+		if err := b.AddPattern(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.AddPattern("[a-"); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !b.RemovePattern("build") || !b.RemovePattern("*.{o,a}") {
+		t.Fatal("expected the patterns to be removed")
+	}
+	if b.RemovePattern("*.o") {
+		t.Error("expected a pattern which wasn't added not to be removed")
+	}
+	if lines := b.Patterns(); !reflect.DeepEqual(lines, []string{"build", "!build/keep"}) {
+		t.Errorf("unexpected patterns %q", lines)
+	}
+	second, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string][2]bool{
+		"a.o":          {true, false},
+		"lib.a":        {true, false},
+		"build/out":    {true, true},
+		"build/keep":   {true, false},
+		"src/main.go":  {false, false},
+		"build/keep/x": {true, false},
+	} {
+		for i, pm := range []*PatternMatcher{first, second} {
+			if matched, _ := pm.MatchesOrParentMatches(path); matched != expected[i] {
+				t.Errorf("%q: expected matcher %d to return %v", path, i, expected[i])
+			}
+		}
+	}
+
+	// The positions of the patterns are updated, without affecting the
+	// matchers built before.
+	var indexes []int
+	for _, p := range second.Patterns() {
+		indexes = append(indexes, p.Index())
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 1}) {
+		t.Errorf("unexpected indexes %v", indexes)
+	}
+	if index := first.Patterns()[2].Index(); index != 1 {
+		t.Errorf("expected the pattern of the first matcher to keep index 1, got %d", index)
+	}
+}
+
+func TestPatternSetBuilderLimits(t *testing.T) {
+	b := NewPatternSetBuilder(WithLimits(Limits{MaxPatterns: 2}))
+	for _, line := range []string{"a", "b", "c"} {
+		if err := b.AddPattern(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var limitErr *LimitError
+	if _, err := b.Build(); !errors.As(err, &limitErr) || limitErr.Limit != "MaxPatterns" {
+		t.Errorf("expected a MaxPatterns error, got %v", err)
+	}
+	b.RemovePattern("b")
+	if _, err := b.Build(); err != nil {
+		t.Error(err)
+	}
+}
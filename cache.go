@@ -0,0 +1,96 @@
+package patternmatcher
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CachedMatcher wraps a PatternMatcher, memoizing its decisions for the most
+// recently queried paths. It suits servers which repeatedly match the same
+// paths against a stable set of patterns.
+//
+// A CachedMatcher is safe for concurrent use by multiple goroutines.
+type CachedMatcher struct {
+	pm   *PatternMatcher
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List
+}
+
+var _ Matcher = (*CachedMatcher)(nil)
+
+type cacheEntry struct {
+	file    string
+	matched bool
+}
+
+// NewCachedMatcher returns a CachedMatcher remembering the decisions of pm
+// for up to size paths, evicting the least recently used ones first.
+func NewCachedMatcher(pm *PatternMatcher, size int) *CachedMatcher {
+	if size < 1 {
+		size = 1
+	}
+	return &CachedMatcher{
+		pm:      pm,
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// MatchesOrParentMatches is like PatternMatcher.MatchesOrParentMatches, but
+// returns the remembered decision for file if there is one. Errors aren't
+// remembered.
+//
+// Paths are remembered as given, so that "a/b" and "a//b" are distinct
+// entries.
+func (c *CachedMatcher) MatchesOrParentMatches(file string) (bool, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[file]; ok {
+		c.lru.MoveToFront(elem)
+		matched := elem.Value.(*cacheEntry).matched
+		c.mu.Unlock()
+		return matched, nil
+	}
+	c.mu.Unlock()
+
+	// Match without holding the lock, so that slow paths don't block the
+	// others. Concurrent misses on the same path may match it twice.
+	matched, err := c.pm.MatchesOrParentMatches(file)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[file]; ok {
+		c.lru.MoveToFront(elem)
+		return matched, nil
+	}
+	c.entries[file] = c.lru.PushFront(&cacheEntry{file: file, matched: matched})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).file)
+	}
+	return matched, nil
+}
+
+// Matches is the same as MatchesOrParentMatches, so that a CachedMatcher
+// can be used wherever a Matcher is.
+func (c *CachedMatcher) Matches(file string) (bool, error) {
+	return c.MatchesOrParentMatches(file)
+}
+
+// Len returns the number of paths whose decision is remembered.
+func (c *CachedMatcher) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Matcher returns the wrapped PatternMatcher.
+func (c *CachedMatcher) Matcher() *PatternMatcher {
+	return c.pm
+}
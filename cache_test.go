@@ -0,0 +1,77 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCachedMatcher(t *testing.T) {
+	pm, err := New([]string{"*.log", "!keep.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCachedMatcher(pm, 2)
+	for _, tt := range []struct {
+		path    string
+		matched bool
+		len     int
+	}{
+		{"a.log", true, 1},
+		{"keep.log", false, 2},
+		{"a.log", true, 2},
+		{"main.go", false, 2},
+		{"keep.log", false, 2},
+	} {
+		matched, err := c.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != tt.matched {
+			t.Errorf("%q: expected %v, got %v", tt.path, tt.matched, matched)
+		}
+		if c.Len() != tt.len {
+			t.Errorf("%q: expected %d entries, got %d", tt.path, tt.len, c.Len())
+		}
+	}
+
+	// "keep.log" was evicted by "main.go", being the least recently used,
+	// then pushed "a.log" out.
+	if _, ok := c.entries["a.log"]; ok {
+		t.Error("expected a.log to be evicted")
+	}
+
+	// As a Matcher, it shares the remembered decisions.
+	var m Matcher = c
+	if matched, err := m.Matches("main.go"); err != nil || matched {
+		t.Errorf("main.go: expected false, got %v, %v", matched, err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestCachedMatcherConcurrent(t *testing.T) {
+	pm, err := New([]string{"dir/**", "!dir/keep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCachedMatcher(pm, 16)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				path := fmt.Sprintf("dir/%d", i%32)
+				if matched, err := c.MatchesOrParentMatches(path); err != nil || !matched {
+					t.Errorf("%q: expected a match, got %v, %v", path, matched, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() != 16 {
+		t.Errorf("expected 16 entries, got %d", c.Len())
+	}
+}
@@ -0,0 +1,104 @@
+package patternmatcher
+
+import "strconv"
+
+// ChainStrategy is how a Chain combines the decisions of its matchers.
+type ChainStrategy int
+
+const (
+	// FirstDecides applies the decision of the first matcher with a
+	// pattern matching the path, so that earlier matchers take precedence.
+	FirstDecides ChainStrategy = iota
+	// LastDecides applies the decision of the last matcher with a pattern
+	// matching the path, so that later matchers override earlier ones, as
+	// user overrides do for repository rules.
+	LastDecides
+	// AllMustInclude matches a path if any matcher matches it, so that it
+	// is only included if all of them include it.
+	AllMustInclude
+)
+
+// String returns the name of the strategy, such as "FirstDecides".
+func (s ChainStrategy) String() string {
+	switch s {
+	case FirstDecides:
+		return "FirstDecides"
+	case LastDecides:
+		return "LastDecides"
+	case AllMustInclude:
+		return "AllMustInclude"
+	}
+	return "ChainStrategy(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Chain combines several PatternMatchers with a ChainStrategy, to express
+// layered policies, such as organization defaults, repository rules and
+// user overrides, without merging their patterns into a single list. With
+// FirstDecides and LastDecides, a path which no pattern of any matcher
+// matches isn't matched.
+//
+// A Chain is immutable, and safe for concurrent use.
+type Chain struct {
+	strategy ChainStrategy
+	matchers []*PatternMatcher
+}
+
+// NewChain returns a Chain evaluating matchers, in order, with strategy.
+func NewChain(strategy ChainStrategy, matchers ...*PatternMatcher) *Chain {
+	return &Chain{strategy: strategy, matchers: append([]*PatternMatcher(nil), matchers...)}
+}
+
+// MatchesOrParentMatches reports whether the chain matches file, or one of
+// its parent directories, with the semantics of
+// PatternMatcher.MatchesOrParentMatches for each matcher.
+func (c *Chain) MatchesOrParentMatches(file string) (bool, error) {
+	return c.matches(func(pm *PatternMatcher) (bool, *Pattern, error) {
+		if err := pm.opts.checkPath(file); err != nil {
+			return false, nil, err
+		}
+		e := &evaluation{needPattern: true}
+		matched, pattern := pm.matchesOrParentMatches(file, e)
+		return matched, pattern, e.pathError(file)
+	})
+}
+
+// Matches is the same as MatchesOrParentMatches, which makes c a Matcher.
+func (c *Chain) Matches(file string) (bool, error) {
+	return c.MatchesOrParentMatches(file)
+}
+
+// MatchesWithOpts is like MatchesOrParentMatches, but applies opts to the
+// call, as PatternMatcher.MatchesWithOpts does.
+func (c *Chain) MatchesWithOpts(file string, opts MatchOpts) (bool, error) {
+	return c.matches(func(pm *PatternMatcher) (bool, *Pattern, error) {
+		matched, pattern, _, err := pm.matchesVia(file, opts)
+		return matched, pattern, err
+	})
+}
+
+// matches combines the results of eval for the matchers of c, which return
+// whether a matcher matches the path, and the pattern deciding it.
+func (c *Chain) matches(eval func(pm *PatternMatcher) (bool, *Pattern, error)) (bool, error) {
+	decision := false
+	for _, pm := range c.matchers {
+		matched, pattern, err := eval(pm)
+		if err != nil {
+			return false, err
+		}
+		switch c.strategy {
+		case FirstDecides:
+			if pattern != nil {
+				return matched, nil
+			}
+		case LastDecides:
+			if pattern != nil {
+				decision = matched
+			}
+		case AllMustInclude:
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return decision, nil
+}
@@ -0,0 +1,39 @@
+package patternmatcher
+
+import "testing"
+
+func TestChain(t *testing.T) {
+	org := MustNew([]string{"*.log", "secrets"})
+	repo := MustNew([]string{"!debug.log", "dist"})
+	user := MustNew([]string{"!dist", "notes.txt"})
+
+	tests := []struct {
+		path     string
+		expected map[ChainStrategy]bool
+	}{
+		{"debug.log", map[ChainStrategy]bool{FirstDecides: true, LastDecides: false, AllMustInclude: true}},
+		{"dist/app.js", map[ChainStrategy]bool{FirstDecides: true, LastDecides: false, AllMustInclude: true}},
+		{"notes.txt", map[ChainStrategy]bool{FirstDecides: true, LastDecides: true, AllMustInclude: true}},
+		{"secrets", map[ChainStrategy]bool{FirstDecides: true, LastDecides: true, AllMustInclude: true}},
+		{"main.go", map[ChainStrategy]bool{FirstDecides: false, LastDecides: false, AllMustInclude: false}},
+	}
+	for strategy := FirstDecides; strategy <= AllMustInclude; strategy++ {
+		chain := NewChain(strategy, org, repo, user)
+		for _, tt := range tests {
+			matched, err := chain.MatchesOrParentMatches(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != tt.expected[strategy] {
+				t.Errorf("%v %q: expected %v, got %v", strategy, tt.path, tt.expected[strategy], matched)
+			}
+			withOpts, err := chain.MatchesWithOpts(tt.path, MatchOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if withOpts != matched {
+				t.Errorf("%v %q: expected MatchesWithOpts to agree", strategy, tt.path)
+			}
+		}
+	}
+}
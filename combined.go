@@ -0,0 +1,98 @@
+package patternmatcher
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// combinedRegexp matches a path against a whole list of patterns in a single
+// pass, and identifies the pattern deciding the result.
+//
+// Each pattern is compiled to an alternative matching the path or one of its
+// parent dirs. Alternatives are in reverse order, so that the leftmost-first
+// semantics of regexp select the last pattern matching, which is the one
+// deciding the result.
+type combinedRegexp struct {
+	re *regexp.Regexp
+	// groups holds the index of the capturing group of each pattern.
+	groups []int
+}
+
+func newCombinedRegexp(patterns []*Pattern, style PathStyle) (*combinedRegexp, error) {
+	escapedPathSeparator := regexp.QuoteMeta(string(style.sep()))
+	parentSuffix := "(?:" + escapedPathSeparator + "(?s:.*))?"
+
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		alternatives[len(patterns)-1-i] = "(?P<p" + strconv.Itoa(i) + ">(?:" + pattern.regexpSource() + ")" + parentSuffix + ")"
+	}
+	re, err := regexp.Compile("^(?:" + strings.Join(alternatives, "|") + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]int, len(patterns))
+	for i := range patterns {
+		groups[i] = re.SubexpIndex("p" + strconv.Itoa(i))
+	}
+	return &combinedRegexp{re: re, groups: groups}, nil
+}
+
+// find returns the index of the last pattern matching file or one of its
+// parent dirs, or -1 if none does. The "file" argument must be cleaned and
+// use the separator of the patterns.
+func (c *combinedRegexp) find(file string) int {
+	loc := c.re.FindStringSubmatchIndex(file)
+	if loc == nil {
+		return -1
+	}
+	for i, group := range c.groups {
+		if loc[2*group] >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// regexpSource returns an unanchored regular expression equivalent to the
+// pattern.
+func (p *Pattern) regexpSource() string {
+	switch p.MatchType {
+	case ExactMatch:
+		return regexp.QuoteMeta(p.CleanedPattern)
+	case PrefixMatch:
+		return regexp.QuoteMeta(p.CleanedPattern[:len(p.CleanedPattern)-2]) + "(?s:.*)"
+	case SuffixMatch:
+		suffix := p.CleanedPattern[2:]
+		if suffix != "" && suffix[0] == p.style.sep() {
+			// **/foo matches "foo"
+			return "(?:(?s:.*)" + regexp.QuoteMeta(suffix[:1]) + ")?" + regexp.QuoteMeta(suffix[1:])
+		}
+		return "(?s:.*)" + regexp.QuoteMeta(suffix)
+	case ExtensionMatch:
+		return "[^" + regexp.QuoteMeta(string(p.style.sep())) + "]*" + regexp.QuoteMeta(p.CleanedPattern[1:])
+	case RegexpMatch, ClassMatch:
+		if p.Regexp != nil {
+			src := p.Regexp.String()
+			return strings.TrimSuffix(strings.TrimPrefix(src, "^"), "$")
+		}
+	}
+	// Never matches.
+	return `[^\x00-\x{10FFFF}]`
+}
+
+// singlePassMatches is the equivalent of matchesOrParentMatches using the
+// combined regexp. It also returns the index of the deciding pattern, or -1
+// if no pattern matched.
+func (pm *PatternMatcher) singlePassMatches(file string) (bool, int) {
+	file = pm.opts.style.clean(file)
+	if file == "." && pm.opts.root == RootNotMatched {
+		return false, -1
+	}
+	i := pm.combined.find(pm.opts.style.fromSlash(file))
+	if i == -1 {
+		return pm.opts.defaultMatch, -1
+	}
+	return !pm.patterns[i].Exclusion, i
+}
@@ -0,0 +1,85 @@
+package patternmatcher
+
+import (
+	"testing"
+)
+
+func TestSinglePassMatching(t *testing.T) {
+	tests := []multiPatternTestCase{
+		{[]string{"**"}, "file", true},
+		{[]string{"**/"}, "dir/file", true},
+		{[]string{"dir/**"}, "dir/file", true},
+		{[]string{"dir/**"}, "dir", false},
+		{[]string{"**/dir"}, "dir", true},
+		{[]string{"**/dir"}, "a/dir/file", true},
+		{[]string{"**/dir"}, "adir", false},
+		{[]string{"**file"}, "dir/file", true},
+		{[]string{"**/dir2/*"}, "dir/dir2/file", true},
+		{[]string{"a[b-d]e"}, "ace/file", true},
+		{[]string{"docs"}, "docs/README.md", true},
+		{[]string{"docs"}, "docs.md", false},
+		{[]string{"a(b)c/def"}, "a(b)c/def/ghi", true},
+		{[]string{"**", "!util/docker/web"}, "util/docker/web/foo", false},
+		{[]string{"**", "!util/docker/web", "util/docker/web/foo"}, "util/docker/web/foo", true},
+		{[]string{"docs", "!docs/README.md"}, "docs/README.md", false},
+		{[]string{"!docs/README.md", "docs"}, "docs/README.md", true},
+		{[]string{"*.go", "!main.go"}, "main.go", false},
+		{[]string{"*.go", "!main.go"}, "util.go", true},
+		{[]string{}, "file", false},
+	}
+	for _, tt := range matchTests {
+		if tt.err == nil {
+			tests = append(tests, multiPatternTestCase{[]string{tt.pattern}, tt.s, tt.match})
+		}
+	}
+
+	for _, tt := range tests {
+		patterns, err := NewPatterns(tt.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := MatchesOrParentMatches(patterns, tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pm, err := New(tt.patterns, WithSinglePassMatching())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pm.combined == nil {
+			t.Fatal("expected combined regexp to be compiled")
+		}
+		got, err := pm.MatchesOrParentMatches(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("patterns %q, path %q: expected %v, got %v", tt.patterns, tt.text, want, got)
+		}
+	}
+}
+
+func TestSinglePassDecidingPattern(t *testing.T) {
+	pm, err := New([]string{"**/*.go", "!vendor", "vendor/**/*.go", "docs"}, WithSinglePassMatching())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path    string
+		matched bool
+		index   int
+	}{
+		{"main.go", true, 0},
+		{"vendor/lib/lib.go", true, 2},
+		{"vendor/lib/README.md", false, 1},
+		{"docs/index.md", true, 3},
+		{"README.md", false, -1},
+	}
+	for _, tt := range tests {
+		matched, index := pm.singlePassMatches(tt.path)
+		if matched != tt.matched || index != tt.index {
+			t.Errorf("path %q: expected (%v, %d), got (%v, %d)", tt.path, tt.matched, tt.index, matched, index)
+		}
+	}
+}
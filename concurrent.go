@@ -0,0 +1,47 @@
+package patternmatcher
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MatchesBatch matches each of paths with MatchesOrParentMatches, from up
+// to workers goroutines sharing pm, and returns the results in the order
+// of paths. A workers value below 1 uses runtime.GOMAXPROCS(0) goroutines.
+// It fails with the error of the first of paths pm rejects, the other
+// paths being matched anyway.
+func (pm *PatternMatcher) MatchesBatch(paths []string, workers int) ([]bool, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	results := make([]bool, len(paths))
+	errs := make([]error, len(paths))
+	var (
+		next int64 = -1
+		wg   sync.WaitGroup
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(paths) {
+					return
+				}
+				results[i], errs[i] = pm.MatchesOrParentMatches(paths[i])
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,95 @@
+package patternmatcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// concurrentPaths returns paths exercising the patterns of the concurrency
+// tests.
+func concurrentPaths() []string {
+	var paths []string
+	for i := 0; i < 200; i++ {
+		paths = append(paths,
+			fmt.Sprintf("src/pkg%d/main.go", i),
+			fmt.Sprintf("src/pkg%d/main_test.go", i),
+			fmt.Sprintf("Build/out%d.o", i),
+			fmt.Sprintf("vendor/mod%d/keep.go", i),
+		)
+	}
+	return paths
+}
+
+func TestConcurrentMatching(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSinglePassMatching()}, {WithBraceExpansion()}} {
+		pm, err := New([]string{"build/", "**/*_test.go", "vendor", "!vendor/*/keep.{go,c}", "*.o"}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := concurrentPaths()
+
+		// Results computed sequentially, before the caches are built.
+		expected := make([]bool, len(paths))
+		expectedFold := make([]bool, len(paths))
+		for i, p := range paths {
+			expected[i], _ = pm.MatchesOrParentMatches(p)
+			expectedFold[i], _ = pm.Clone().MatchesWithOpts(p, MatchOpts{FoldCase: true})
+		}
+
+		shared := pm.Clone()
+		cached := NewCachedMatcher(shared, 16)
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i, p := range paths {
+					if matched, _ := shared.MatchesOrParentMatches(p); matched != expected[i] {
+						t.Errorf("%q: expected %v", p, expected[i])
+					}
+					if matched, _ := shared.MatchesWithOpts(p, MatchOpts{FoldCase: true}); matched != expectedFold[i] {
+						t.Errorf("%q: expected %v with FoldCase", p, expectedFold[i])
+					}
+					if matched, _ := cached.MatchesOrParentMatches(p); matched != expected[i] {
+						t.Errorf("%q: expected the cached matcher to return %v", p, expected[i])
+					}
+					if _, err := shared.Explain(p, MatchOpts{}); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestMatchesBatch(t *testing.T) {
+	pm := MustNew([]string{"**/*_test.go", "vendor", "!vendor/*/keep.go"}, WithStrictPaths())
+	paths := concurrentPaths()
+	for _, workers := range []int{0, 1, 3, 1000} {
+		results, err := pm.MatchesBatch(paths, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range paths {
+			if expected, _ := pm.MatchesOrParentMatches(p); results[i] != expected {
+				t.Errorf("%d workers: %q: expected %v", workers, p, expected)
+			}
+		}
+	}
+
+	if results, err := pm.MatchesBatch(nil, 4); err != nil || len(results) != 0 {
+		t.Errorf("expected no results, got %v, %v", results, err)
+	}
+
+	var pathErr *PathError
+	_, err := pm.MatchesBatch([]string{"a", "../b", "/c"}, 2)
+	if !errors.As(err, &pathErr) || pathErr.Path != "../b" {
+		t.Errorf("expected the error of ../b, got %v", err)
+	}
+	if _, err := pm.MatchesBatch([]string{"a"}, 1); err != nil {
+		t.Error(err)
+	}
+}
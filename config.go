@@ -0,0 +1,83 @@
+package patternmatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Config is a structured list of patterns, such as read from a JSON or YAML
+// file, where each pattern has its own options.
+type Config struct {
+	Patterns []ConfigPattern `json:"patterns" yaml:"patterns"`
+}
+
+// ConfigPattern is a pattern of a Config along with its options.
+type ConfigPattern struct {
+	// Pattern is the text of the pattern, which is an exclusion if it
+	// starts with "!".
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// CaseInsensitive makes the pattern match regardless of case.
+	CaseInsensitive bool `json:"caseInsensitive,omitempty" yaml:"caseInsensitive,omitempty"`
+	// DirOnly makes the pattern only match directories and their contents,
+	// as if it ended with a path separator.
+	DirOnly bool `json:"dirOnly,omitempty" yaml:"dirOnly,omitempty"`
+	// Anchored set to false makes the pattern match at any depth rather
+	// than from the root, as if it started with "**/".
+	Anchored *bool `json:"anchored,omitempty" yaml:"anchored,omitempty"`
+	// Labels are reported by PatternMatcher.MatchesWithLabels when the
+	// pattern decides a result.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// UnmarshalFunc decodes data into v, such as json.Unmarshal or the Unmarshal
+// function of a YAML package.
+type UnmarshalFunc func(data []byte, v interface{}) error
+
+// ParseConfig decodes a Config from data with unmarshal, or with
+// json.Unmarshal if unmarshal is nil.
+func ParseConfig(data []byte, unmarshal UnmarshalFunc) (*Config, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	cfg := &Config{}
+	if err := unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewFromConfig creates a PatternMatcher from the patterns of cfg, which are
+// evaluated in order and compiled with opts in addition to their own
+// options.
+func NewFromConfig(cfg *Config, opts ...Option) (*PatternMatcher, error) {
+	o := newOptions(opts)
+	lines := make([]string, len(cfg.Patterns))
+	for i, cp := range cfg.Patterns {
+		line := strings.TrimSpace(cp.Pattern)
+		if line == "" {
+			return nil, fmt.Errorf("pattern %d: empty pattern", i)
+		}
+		if cp.Anchored != nil && !*cp.Anchored {
+			if line[0] == '!' {
+				line = "!**/" + line[1:]
+			} else {
+				line = "**/" + line
+			}
+		}
+		lines[i] = line
+	}
+	patterns, err := newPatterns(lines, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range patterns {
+		cp := cfg.Patterns[p.index]
+		p.DirOnly = p.DirOnly || cp.DirOnly
+		p.labels = cp.Labels
+		if cp.CaseInsensitive {
+			p.setFoldCase()
+		}
+	}
+	return newPatternMatcher(patterns, o, nil)
+}
@@ -0,0 +1,103 @@
+package patternmatcher
+
+import "testing"
+
+func TestNewFromConfig(t *testing.T) {
+	const data = `{
+		"patterns": [
+			{"pattern": "*.LOG", "caseInsensitive": true, "labels": {"reason": "logs"}},
+			{"pattern": "node_modules", "anchored": false},
+			{"pattern": "build", "dirOnly": true},
+			{"pattern": "!keep.log"}
+		]
+	}`
+	cfg, err := ParseConfig([]byte(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, singlePass := range []bool{false, true} {
+		var opts []Option
+		if singlePass {
+			opts = append(opts, WithSinglePassMatching())
+		}
+		pm, err := NewFromConfig(cfg, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			path string
+			pass bool
+		}{
+			{"a.log", true},
+			{"a.Log", true},
+			{"keep.log", false},
+			{"sub/a.log", false},
+			{"node_modules/x.js", true},
+			{"web/app/node_modules/x.js", true},
+			{"build/out", true},
+			{"main.go", false},
+		}
+		for _, tt := range tests {
+			match, err := pm.MatchesOrParentMatches(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.pass {
+				t.Errorf("single pass %v, path %q: expected %v, got %v", singlePass, tt.path, tt.pass, match)
+			}
+		}
+
+		_, labels, err := pm.MatchesWithLabels("A.LOG")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if labels["reason"] != "logs" {
+			t.Errorf("expected the labels of the deciding pattern, got %v", labels)
+		}
+	}
+
+	pm, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesWithOpts("build", MatchOpts{}); match {
+		t.Error("expected the dir-only pattern not to match a file")
+	}
+	if match, _ := pm.MatchesWithOpts("build", MatchOpts{IsDir: true}); !match {
+		t.Error("expected the dir-only pattern to match a directory")
+	}
+}
+
+func TestNewFromConfigErrors(t *testing.T) {
+	if _, err := ParseConfig([]byte("{"), nil); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+	for _, cfg := range []*Config{
+		{Patterns: []ConfigPattern{{Pattern: " "}}},
+		{Patterns: []ConfigPattern{{Pattern: "["}}},
+	} {
+		if _, err := NewFromConfig(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg.Patterns)
+		}
+	}
+}
+
+func TestNewFromConfigBraceExpansion(t *testing.T) {
+	cfg := &Config{Patterns: []ConfigPattern{
+		{Pattern: "*.{js,ts}", Labels: map[string]string{"lang": "web"}},
+		{Pattern: "*.go", Labels: map[string]string{"lang": "go"}},
+	}}
+	pm, err := NewFromConfig(cfg, WithBraceExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, lang := range map[string]string{"a.ts": "web", "a.go": "go"} {
+		_, labels, err := pm.MatchesWithLabels(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if labels["lang"] != lang {
+			t.Errorf("path %q: expected lang %q, got %v", path, lang, labels)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package patternmatcher
+
+import (
+	"errors"
+	"strings"
+)
+
+// DirCursor holds the results of matching a directory, to match the entries
+// of the directory without matching its parents again. It is obtained once
+// per directory with EnterDir, and reused for every entry of the
+// directory, which makes it cheap for wide directories.
+//
+// A DirCursor is immutable, and is safe for concurrent use by multiple
+// goroutines.
+type DirCursor struct {
+	pm        *PatternMatcher
+	dir       string
+	matched   bool
+	matchInfo []bool
+}
+
+// EnterDir returns the cursor of the directory name in the directory of
+// parent, or in the root if parent is nil. The name must be a single path
+// element.
+func (pm *PatternMatcher) EnterDir(parent *DirCursor, name string) (*DirCursor, error) {
+	path, err := pm.cursorPath(parent, name)
+	if err != nil {
+		return nil, err
+	}
+	var parentInfo []bool
+	if parent != nil {
+		parentInfo = parent.matchInfo
+	}
+	matched, matchInfo, err := pm.matchesWithParentInfo(path, true, parentInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &DirCursor{pm: pm, dir: path, matched: matched, matchInfo: matchInfo}, nil
+}
+
+// Path returns the path of the directory, using the separator of the
+// style of the matcher.
+func (c *DirCursor) Path() string {
+	return c.dir
+}
+
+// Matched reports whether the directory itself matches the patterns.
+func (c *DirCursor) Matched() bool {
+	return c.matched
+}
+
+// Matches reports whether the entry name of the directory matches the
+// patterns. The name must be a single path element.
+func (c *DirCursor) Matches(name string) (bool, error) {
+	path, err := c.pm.cursorPath(c, name)
+	if err != nil {
+		return false, err
+	}
+	matched, _, err := c.pm.matchesWithParentInfo(path, false, c.matchInfo)
+	return matched, err
+}
+
+// cursorPath returns the path of the entry name of the directory of
+// parent, or of the root if parent is nil.
+func (pm *PatternMatcher) cursorPath(parent *DirCursor, name string) (string, error) {
+	if parent != nil && parent.pm != pm {
+		return "", errors.New("cursor of another PatternMatcher")
+	}
+	if name == "" || name == "." || name == ".." || strings.IndexFunc(name, pm.opts.style.isSepRune) != -1 {
+		return "", &PathError{Path: name, Err: ErrNotPathElement}
+	}
+	if err := pm.opts.checkPath(name); err != nil {
+		return "", err
+	}
+	if parent == nil {
+		return name, nil
+	}
+	return parent.dir + string(pm.opts.style.sep()) + name, nil
+}
@@ -0,0 +1,98 @@
+package patternmatcher
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"testing"
+)
+
+func TestDirCursor(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/build/", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var walk func(dir string, cursor *DirCursor)
+	walk = func(dir string, cursor *DirCursor) {
+		entries, err := fs.ReadDir(walkFS, path.Join("ctx", dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			rel := path.Join(dir, entry.Name())
+			want, err := pm.MatchesOrParentMatches(rel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var matched bool
+			if entry.IsDir() {
+				child, err := pm.EnterDir(cursor, entry.Name())
+				if err != nil {
+					t.Fatal(err)
+				}
+				matched = child.Matched()
+				walk(rel, child)
+			} else if cursor == nil {
+				matched, err = pm.MatchesOrParentMatches(rel)
+			} else {
+				matched, err = cursor.Matches(entry.Name())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != want {
+				t.Errorf("%q: expected %v, got %v", rel, want, matched)
+			}
+		}
+	}
+	walk(".", nil)
+
+	cursor, err := pm.EnterDir(nil, "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "..", "pkg/lib.go"} {
+		if _, err := cursor.Matches(name); !errors.Is(err, ErrNotPathElement) {
+			t.Errorf("%q: expected ErrNotPathElement, got %v", name, err)
+		}
+	}
+	other, err := New([]string{"src"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.EnterDir(cursor, "pkg"); err == nil {
+		t.Error("expected an error entering the cursor of another matcher")
+	}
+}
+
+func TestDirCursorOptions(t *testing.T) {
+	for name, pm := range optionMatchers(t) {
+		var walk func(dir string, cursor *DirCursor)
+		walk = func(dir string, cursor *DirCursor) {
+			entries, err := fs.ReadDir(walkFS, path.Join("ctx", dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				rel := path.Join(dir, entry.Name())
+				var matched bool
+				if entry.IsDir() {
+					child, err := pm.EnterDir(cursor, entry.Name())
+					if err != nil {
+						t.Fatal(err)
+					}
+					matched = child.Matched()
+					walk(rel, child)
+				} else if cursor == nil {
+					continue
+				} else if matched, err = cursor.Matches(entry.Name()); err != nil {
+					t.Fatal(err)
+				}
+				if want, _ := pm.MatchesOrParentMatches(rel); matched != want {
+					t.Errorf("%s: %q: expected %v, got %v", name, rel, want, matched)
+				}
+			}
+		}
+		walk(".", nil)
+	}
+}
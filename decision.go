@@ -0,0 +1,94 @@
+package patternmatcher
+
+import (
+	"strconv"
+)
+
+// MatchDecision tells how the decision for a path was reached, as returned
+// by Decide. Being matched means being excluded, as for the patterns of an
+// ignore file.
+type MatchDecision int
+
+const (
+	// NoRuleMatched reports that no pattern matches the path or one of its
+	// parents. The path is included, unless WithDefaultMatch(true) is set.
+	NoRuleMatched MatchDecision = iota
+	// Included reports that the path is included whatever the patterns,
+	// as the root is with RootNotMatched.
+	Included
+	// ExcludedByRule reports that a pattern matches the path itself.
+	ExcludedByRule
+	// ExcludedViaParent reports that a pattern matches a parent directory
+	// of the path, such as "build" for "build/out".
+	ExcludedViaParent
+	// IncludedByNegation reports that an exclusion pattern, starting with
+	// "!", matches the path or one of its parents.
+	IncludedByNegation
+)
+
+// String returns the name of the decision, such as "ExcludedByRule".
+func (d MatchDecision) String() string {
+	switch d {
+	case NoRuleMatched:
+		return "NoRuleMatched"
+	case Included:
+		return "Included"
+	case ExcludedByRule:
+		return "ExcludedByRule"
+	case ExcludedViaParent:
+		return "ExcludedViaParent"
+	case IncludedByNegation:
+		return "IncludedByNegation"
+	}
+	return "MatchDecision(" + strconv.Itoa(int(d)) + ")"
+}
+
+// MatchResult is the outcome of matching a path, as returned by
+// MatchesWithResult.
+type MatchResult struct {
+	// Matched is the final decision, as returned by MatchesWithOpts.
+	Matched bool
+	// Pattern is the pattern deciding the result, or nil if no pattern
+	// matches the path or one of its parents. Its Source and Index locate
+	// the rule, so that tools can report which one excluded a path, as git
+	// check-ignore does.
+	Pattern *Pattern
+	// Decision tells how the result was reached.
+	Decision MatchDecision
+}
+
+// MatchesWithResult is like MatchesWithOpts, but also returns the pattern
+// deciding the result and how the result was reached.
+func (pm *PatternMatcher) MatchesWithResult(file string, opts MatchOpts) (MatchResult, error) {
+	matched, pattern, viaParent, err := pm.matchesVia(file, opts)
+	if err != nil {
+		return MatchResult{}, err
+	}
+	result := MatchResult{Matched: matched, Pattern: pattern}
+	switch {
+	case pattern == nil:
+		if pm.opts.style.clean(file) == "." && pm.opts.root == RootNotMatched {
+			result.Decision = Included
+		}
+	case pattern.Exclusion:
+		result.Decision = IncludedByNegation
+	case viaParent:
+		result.Decision = ExcludedViaParent
+	default:
+		result.Decision = ExcludedByRule
+	}
+	return result, nil
+}
+
+// Decide is like MatchesWithOpts, but returns how the decision was reached
+// rather than only whether file is matched, for callers such as archivers
+// which treat a path excluded through its parent directory differently
+// from one excluded by its own rule. The pattern deciding it, if any, is
+// returned as well.
+func (pm *PatternMatcher) Decide(file string, opts MatchOpts) (MatchDecision, *Pattern, error) {
+	result, err := pm.MatchesWithResult(file, opts)
+	if err != nil {
+		return NoRuleMatched, nil, err
+	}
+	return result.Decision, result.Pattern, nil
+}
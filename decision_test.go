@@ -0,0 +1,81 @@
+package patternmatcher
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		expected MatchDecision
+		pattern  string
+	}{
+		{".", Included, ""},
+		{"main.go", NoRuleMatched, ""},
+		{"node_modules", ExcludedByRule, "node_modules"},
+		{"node_modules/a/index.js", ExcludedViaParent, "node_modules"},
+		{"node_modules/keep.js", IncludedByNegation, "!node_modules/keep.js"},
+		{"src/lib_test.go", ExcludedByRule, "**/*_test.go"},
+	}
+	for _, tt := range tests {
+		decision, pattern, err := pm.Decide(tt.path, MatchOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.path, tt.expected, decision)
+		}
+		got := ""
+		if pattern != nil {
+			got = pattern.String()
+		}
+		if got != filepath.FromSlash(tt.pattern) {
+			t.Errorf("%q: expected pattern %q, got %q", tt.path, tt.pattern, got)
+		}
+	}
+	if s := MatchDecision(42).String(); s != "MatchDecision(42)" {
+		t.Errorf("unexpected name %q", s)
+	}
+}
+
+func TestMatchesWithResult(t *testing.T) {
+	pm, err := NewFromSources([]Source{{
+		Name:     ".dockerignore",
+		Patterns: []string{"node_modules", "!node_modules/keep.js", "*.log"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		matched  bool
+		index    int
+		decision MatchDecision
+	}{
+		{"main.go", false, -1, NoRuleMatched},
+		{"node_modules/a.js", true, 0, ExcludedViaParent},
+		{"node_modules/keep.js", false, 1, IncludedByNegation},
+		{"debug.log", true, 2, ExcludedByRule},
+	}
+	for _, tt := range tests {
+		result, err := pm.MatchesWithResult(tt.path, MatchOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		index := -1
+		if result.Pattern != nil {
+			index = result.Pattern.Index()
+			if src := result.Pattern.Source(); src != ".dockerignore" {
+				t.Errorf("%q: unexpected source %q", tt.path, src)
+			}
+		}
+		if result.Matched != tt.matched || index != tt.index || result.Decision != tt.decision {
+			t.Errorf("%q: expected %v, %d, %v, got %v, %d, %v", tt.path, tt.matched, tt.index, tt.decision, result.Matched, index, result.Decision)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"path"
+)
+
+// DiffTree walks the file tree rooted at root in fsys, and returns the files
+// which become included and those which become excluded when replacing the
+// patterns of oldPM with those of newPM, such as two versions of an ignore
+// file. A file is included when WalkDir would pass it to fn, that is when it
+// isn't matched and isn't in a directory pruned by the walk. Paths are
+// relative to root, and listed in lexical order.
+func DiffTree(fsys fs.FS, root string, oldPM, newPM *PatternMatcher) (included, excluded []string, err error) {
+	oldPruned, newPruned := map[string]bool{}, map[string]bool{}
+	var infos dirInfos
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(root, p)
+		opts := MatchOpts{IsDir: d.IsDir()}
+		if oldPM.predicates || newPM.predicates {
+			if opts.Info, err = d.Info(); err != nil {
+				return err
+			}
+		}
+		parentInfos := infos.visit(rel, opts.Info)
+		oldMatched, err := oldPM.excludes(fsys, p, rel, d, opts, parentInfos, oldPruned[path.Dir(rel)])
+		if err != nil {
+			return err
+		}
+		newMatched, err := newPM.excludes(fsys, p, rel, d, opts, parentInfos, newPruned[path.Dir(rel)])
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if oldMatched && newMatched {
+				return fs.SkipDir
+			}
+			oldPruned[rel], newPruned[rel] = oldMatched, newMatched
+			return nil
+		}
+		switch {
+		case oldMatched && !newMatched:
+			included = append(included, rel)
+		case !oldMatched && newMatched:
+			excluded = append(excluded, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return included, excluded, nil
+}
+
+// excludes reports whether the path p of fsys, rel relative to the walked
+// root, is left out of the walks of pm: for a file, whether it isn't passed
+// to fn, and for a directory, whether the walk doesn't descend into it.
+// opts and parentInfos describe p and its parent dirs, and parentPruned
+// reports whether the walk of pm pruned the parent of p.
+func (pm *PatternMatcher) excludes(fsys fs.FS, p, rel string, d fs.DirEntry, opts MatchOpts, parentInfos []fs.FileInfo, parentPruned bool) (bool, error) {
+	if parentPruned {
+		return true, nil
+	}
+	if d.IsDir() && rel != "." && pm.opts.prunesDir(fsys, p, d.Name()) {
+		return true, nil
+	}
+	matched, err := pm.matchesWalked(rel, opts, parentInfos)
+	if err != nil || !matched || !d.IsDir() {
+		return matched, err
+	}
+	return !pm.mayReincludeBeneath(rel), nil
+}
@@ -0,0 +1,99 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiffTree(t *testing.T) {
+	oldPM, err := New([]string{"node_modules", "build", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPM, err := New([]string{"node_modules", "!node_modules/keep.js", "**/*_test.go", "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	included, excluded, err := DiffTree(walkFS, "ctx", oldPM, newPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"build/out", "node_modules/keep.js"}; !reflect.DeepEqual(included, want) {
+		t.Errorf("expected included %q, got %q", want, included)
+	}
+	if want := []string{"docs/build"}; !reflect.DeepEqual(excluded, want) {
+		t.Errorf("expected excluded %q, got %q", want, excluded)
+	}
+}
+
+func TestDiffTreeSkipVCSDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":   {},
+		"src/main.go": {},
+	}
+	oldPM, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPM, err := New(nil, WithSkipVCSDirs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	included, excluded, err := DiffTree(fsys, ".", oldPM, newPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 0 || !reflect.DeepEqual(excluded, []string{".git/HEAD"}) {
+		t.Errorf("unexpected diff: included %q, excluded %q", included, excluded)
+	}
+}
+
+func TestDiffTreeMatchesWalkDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"build/out":     {},
+		"build.txt":     {},
+		"big.log":       {Data: make([]byte, 200)},
+		"small.log":     {Data: make([]byte, 10)},
+		"src/build":     {},
+		"lib/build/a.o": {},
+	}
+	oldPM, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPM, err := NewFromRules([]Rule{
+		{Pattern: "**/build/"},
+		{Pattern: "*.log", Predicates: []Predicate{LargerThan(100)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var walked []string
+	err = newPM.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			walked = append(walked, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	included, excluded, err := DiffTree(fsys, ".", oldPM, newPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 0 {
+		t.Errorf("expected nothing included, got %q", included)
+	}
+	if want := []string{"big.log", "build/out", "lib/build/a.o"}; !reflect.DeepEqual(excluded, want) {
+		t.Errorf("expected excluded %q, got %q", want, excluded)
+	}
+	if want := []string{"build.txt", "small.log", "src/build"}; !reflect.DeepEqual(walked, want) {
+		t.Errorf("expected WalkDir to pass %q, got %q", want, walked)
+	}
+}
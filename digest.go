@@ -0,0 +1,45 @@
+package patternmatcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+)
+
+// Digest walks the file tree rooted at root in fsys like WalkDir, and returns
+// a digest of the relative paths of the regular files pm doesn't match, and
+// of their contents if contents is true. The digest is deterministic, which
+// makes it suitable as a cache key for the set of files, such as the inputs
+// of a build. It has the form "sha256:" followed by the hex-encoded hash.
+func (pm *PatternMatcher) Digest(fsys fs.FS, root string, contents bool) (string, error) {
+	h := sha256.New()
+	err := pm.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		// Paths can't contain NUL, which makes it a safe delimiter.
+		io.WriteString(h, relPath(root, p)+"\x00")
+		if !contents {
+			return nil
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fh := sha256.New()
+		if _, err := io.Copy(fh, f); err != nil {
+			return err
+		}
+		h.Write(fh.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,60 @@
+package patternmatcher
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDigest(t *testing.T) {
+	pm, err := New([]string{"*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := func(fsys fstest.MapFS, contents bool) string {
+		t.Helper()
+		d, err := pm.Digest(fsys, ".", contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(d, "sha256:") || len(d) != len("sha256:")+64 {
+			t.Fatalf("malformed digest %q", d)
+		}
+		return d
+	}
+	base := fstest.MapFS{
+		"a.go":     {Data: []byte("a")},
+		"sub/b.go": {Data: []byte("b")},
+		"x.log":    {Data: []byte("1")},
+	}
+	changedLog := fstest.MapFS{
+		"a.go":     {Data: []byte("a")},
+		"sub/b.go": {Data: []byte("b")},
+		"x.log":    {Data: []byte("2")},
+		"y.log":    {Data: []byte("3")},
+	}
+	changedContent := fstest.MapFS{
+		"a.go":     {Data: []byte("A")},
+		"sub/b.go": {Data: []byte("b")},
+	}
+	renamed := fstest.MapFS{
+		"a.go":     {Data: []byte("a")},
+		"sub/c.go": {Data: []byte("b")},
+	}
+
+	for _, contents := range []bool{false, true} {
+		d := digest(base, contents)
+		if d != digest(base, contents) {
+			t.Error("expected the digest to be deterministic")
+		}
+		if d != digest(changedLog, contents) {
+			t.Error("expected matched files not to change the digest")
+		}
+		if d == digest(renamed, contents) {
+			t.Error("expected a renamed file to change the digest")
+		}
+		if changed := digest(changedContent, contents) != d; changed != contents {
+			t.Errorf("contents %v: expected a change of content to change the digest: %v, got %v", contents, contents, changed)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package patternmatcher
+
+import "io/fs"
+
+// MatchesDirEntry is like MatchesOrParentMatches, but honors the patterns
+// ending with a path separator, such as "build/", using the type of d, as
+// passed to the callback of fs.WalkDir, without calling Stat: only
+// directories match them, not symbolic links to directories. The
+// predicates of rules are evaluated with d.Info.
+//
+// The "file" argument should be a slash-delimited path.
+func MatchesDirEntry(patterns []*Pattern, file string, d fs.DirEntry) (bool, error) {
+	if err := checkPatterns(patterns); err != nil {
+		return false, err
+	}
+	pm := PatternMatcher{patterns: patterns, opts: defaultOptions, predicates: hasPredicates(patterns)}
+	return pm.MatchesDirEntry(file, d)
+}
+
+// MatchesDirEntry is like the MatchesDirEntry function, with the patterns
+// and options of pm.
+func (pm *PatternMatcher) MatchesDirEntry(file string, d fs.DirEntry) (bool, error) {
+	opts := MatchOpts{IsDir: d.IsDir()}
+	if pm.predicates {
+		info, err := d.Info()
+		if err != nil {
+			return false, err
+		}
+		opts.Info = info
+	}
+	return pm.MatchesWithOpts(file, opts)
+}
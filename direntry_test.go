@@ -0,0 +1,55 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchesDirEntry(t *testing.T) {
+	fsys := fstest.MapFS{
+		"build/out.o": {},
+		"src/build":   {Data: []byte("a file")},
+		"link":        {Mode: fs.ModeSymlink},
+		"big.log":     {Data: make([]byte, 200)},
+		"small.log":   {Data: make([]byte, 10)},
+	}
+	entries := make(map[string]fs.DirEntry)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		entries[p] = d
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := MustCompilePatterns("build/", "src/build/", "link/")
+	for path, expected := range map[string]bool{
+		"build":       true,
+		"build/out.o": true,
+		"src/build":   false,
+		"link":        false,
+	} {
+		matched, err := MatchesDirEntry(patterns, path, entries[path])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+
+	pm, err := NewFromRules([]Rule{{Pattern: "*.log", Predicates: []Predicate{LargerThan(100)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]bool{"big.log": true, "small.log": false} {
+		matched, err := pm.MatchesDirEntry(path, entries[path])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+}
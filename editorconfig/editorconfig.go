@@ -0,0 +1,109 @@
+// Package editorconfig matches paths against the section globs of
+// EditorConfig files, following the rules of
+// https://spec.editorconfig.org/#glob-expressions.
+package editorconfig
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/moby/patternmatcher/internal/glob"
+)
+
+// Glob is a compiled EditorConfig section glob.
+type Glob struct {
+	glob     string
+	compiled *glob.Glob
+}
+
+// Compile compiles an EditorConfig section glob, such as "*.{js,ts}" or
+// "lib/**.c".
+func Compile(pattern string) (*Glob, error) {
+	g := &Glob{glob: pattern}
+	if strings.Contains(stripClasses(pattern), "/") {
+		// Globs containing a separator are relative to the directory of
+		// the EditorConfig file.
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		pattern = "**/" + pattern
+	}
+	compiled, err := glob.Compile(pattern, glob.NumericRanges)
+	if err != nil {
+		return nil, err
+	}
+	g.compiled = compiled
+	return g, nil
+}
+
+// String returns the glob as it was written.
+func (g *Glob) String() string {
+	return g.glob
+}
+
+// Match reports whether path, a slash-separated path relative to the
+// directory of the EditorConfig file, matches the glob.
+func (g *Glob) Match(path string) bool {
+	return g.compiled.Match(path)
+}
+
+// stripClasses removes the bracket expressions of pattern, so that
+// separators they contain aren't taken into account.
+func stripClasses(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '[' {
+			if end := glob.ClassEnd(pattern, i); end != -1 {
+				i = end
+				continue
+			}
+		}
+		sb.WriteByte(pattern[i])
+	}
+	return sb.String()
+}
+
+// Sections holds the section globs of an EditorConfig file, in order.
+type Sections []*Glob
+
+// ReadSections reads an EditorConfig file and compiles the globs of its
+// section headers. Properties and comments are ignored.
+func ReadSections(r io.Reader) (Sections, error) {
+	var sections Sections
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if first {
+			line = bytes.TrimPrefix(line, []byte{0xEF, 0xBB, 0xBF})
+			first = false
+		}
+		trimmed := strings.TrimSpace(string(line))
+		if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+		g, err := Compile(trimmed[1 : len(trimmed)-1])
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+// Matching returns the headers of the sections applying to path, a
+// slash-separated path relative to the directory of the EditorConfig file,
+// in the order of the file.
+func (s Sections) Matching(path string) []string {
+	var headers []string
+	for _, g := range s {
+		if g.Match(path) {
+			headers = append(headers, g.String())
+		}
+	}
+	return headers
+}
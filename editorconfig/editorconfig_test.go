@@ -0,0 +1,96 @@
+package editorconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		pass bool
+	}{
+		{"*", "a.js", true},
+		{"*", "lib/a.js", true},
+		{"*.js", "lib/sub/a.js", true},
+		{"*.js", "a.ts", false},
+		{"*.{js,ts}", "a.ts", true},
+		{"*.{js,ts}", "lib/a.js", true},
+		{"*.{js,ts}", "a.go", false},
+		{"{package.json,.travis.yml}", ".travis.yml", true},
+		{"{package.json,.travis.yml}", "src/package.json", true},
+		{"{single}", "single", false},
+		{"{single}", "{single}", true},
+		{"a{b,{c,d}}e", "ade", true},
+		{"a{b,{c,d}}e", "abde", false},
+		{"lib/**.c", "lib/a.c", true},
+		{"lib/**.c", "lib/x/y/a.c", true},
+		{"lib/**.c", "src/lib/a.c", false},
+		{"/lib/*.c", "lib/a.c", true},
+		{"lib/*.c", "lib/x/a.c", false},
+		{"**/a.c", "a.c", true},
+		{"**/a.c", "x/y/a.c", true},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", false},
+		{"[abc].txt", "b.txt", true},
+		{"[abc].txt", "d.txt", false},
+		{"[!0-9].txt", "a.txt", true},
+		{"[!0-9].txt", "5.txt", false},
+		{"[a/b]", "[a/b]", true},
+		{"[a", "[a", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{"file{1..3}.txt", "file2.txt", true},
+		{"file{1..3}.txt", "file4.txt", false},
+		{"file{-2..2}", "file-1", true},
+		{"file{3..1}", "file1", true},
+		{"{a,file{1..3}}.txt", "a.txt", true},
+		{"*{10..20}", "12", true},
+		{"*{10..20}", "x12", true},
+		{"*{10..20}", "x9", false},
+	}
+	for _, test := range tests {
+		g, err := Compile(test.glob)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", test.glob, err)
+		}
+		if got := g.Match(test.path); got != test.pass {
+			t.Errorf("%q.Match(%q) = %v, want %v", test.glob, test.path, got, test.pass)
+		}
+	}
+}
+
+func TestSectionsMatching(t *testing.T) {
+	const file = "\xEF\xBB\xBFroot = true\n" +
+		"\n" +
+		"[*]\n" +
+		"indent_style = space\n" +
+		"; comment\n" +
+		"[*.{js,ts}]\n" +
+		"indent_size = 2\n" +
+		"# [*.go]\n" +
+		"[lib/**.js]\n" +
+		"[Makefile]\n"
+	sections, err := ReadSections(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 4 {
+		t.Fatalf("got %d sections, want 4", len(sections))
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"*"}},
+		{"lib/x/a.js", []string{"*", "*.{js,ts}", "lib/**.js"}},
+		{"src/Makefile", []string{"*", "Makefile"}},
+	}
+	for _, test := range tests {
+		if got := sections.Matching(test.path); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Matching(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
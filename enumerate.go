@@ -0,0 +1,251 @@
+package patternmatcher
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnbounded is returned by Enumerate for a pattern matching an unbounded
+// set of paths, whose wildcards aren't bounded by EnumerateOpts.
+var ErrUnbounded = errors.New("matches an unbounded set of paths")
+
+// defaultMaxEnumerated is the number of paths Enumerate returns at most
+// when EnumerateOpts.MaxResults isn't set.
+const defaultMaxEnumerated = 10000
+
+// EnumerateOpts bound the paths enumerated by Enumerate for patterns with
+// wildcards.
+type EnumerateOpts struct {
+	// Alphabet holds the characters "?", "*" and negated classes stand
+	// for. Separators are ignored.
+	Alphabet string
+	// MaxLength is the maximum number of characters "*" stands for, and
+	// of the elements "**" stands for.
+	MaxLength int
+	// MaxDepth is the maximum number of path elements "**" stands for.
+	MaxDepth int
+	// MaxResults is the maximum number of paths, beyond which Enumerate
+	// fails with an error wrapping ErrLimitExceeded. Zero means 10000.
+	MaxResults int
+	// Style is the PathStyle the pattern is compiled with, as set by
+	// WithPathStyle. The paths returned are slash-separated whatever the
+	// style.
+	Style PathStyle
+}
+
+// Enumerate returns the slash-separated paths pattern matches, sorted, for
+// exhaustive tests and generated documentation. The paths beneath those
+// paths, which the pattern matches as their parent, aren't listed. An
+// exclusion pattern enumerates the paths it re-includes.
+//
+// Patterns made of literals and classes match a finite set of paths.
+// Wildcards need opts to be bounded: "?" stands for a character of the
+// alphabet, "*" for up to MaxLength of them, and "**" for up to MaxDepth
+// elements, or for characters of a single element when it is in the middle
+// of one, as in "a**". Patterns with unbounded wildcards fail with
+// ErrUnbounded.
+func Enumerate(pattern string, opts EnumerateOpts) ([]string, error) {
+	limit := opts.MaxResults
+	if limit <= 0 {
+		limit = defaultMaxEnumerated
+	}
+	st := opts.Style
+	compiled, err := NewPatterns([]string{pattern}, WithPathStyle(st))
+	if err != nil || len(compiled) == 0 {
+		return nil, err
+	}
+	ast, err := ParseAST(strings.TrimSpace(pattern), WithPathStyle(st))
+	if err != nil {
+		return nil, err
+	}
+	en := &enumerator{opts: opts, limit: limit}
+	for _, r := range opts.Alphabet {
+		if !st.isSepRune(r) && !strings.ContainsRune(string(en.alphabet), r) {
+			en.alphabet = append(en.alphabet, r)
+		}
+	}
+
+	paths := []string{""}
+	for _, seg := range ast.Segments {
+		var elems [][]string
+		if len(seg.Nodes) == 1 && seg.Nodes[0].Kind == GlobstarNode {
+			elems, err = en.globstar()
+		} else {
+			var words []string
+			words, err = en.segment(seg)
+			elems = make([][]string, len(words))
+			for i, w := range words {
+				elems[i] = []string{w}
+			}
+		}
+		if err != nil {
+			return nil, &PatternError{Pattern: pattern, Err: err}
+		}
+		var next []string
+		for _, p := range paths {
+			for _, e := range elems {
+				if len(next) == 2*limit {
+					// Some of the candidates may not match.
+					return nil, &PatternError{Pattern: pattern, Err: en.tooMany()}
+				}
+				next = append(next, strings.Join(append([]string{p}, e...), "/"))
+			}
+		}
+		paths = next
+	}
+
+	var matched []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		p = strings.TrimPrefix(p, "/")
+		if p == "" || strings.Contains(p, "//") || strings.HasSuffix(p, "/") || seen[p] || !compiled[0].Match(st.fromSlash(p)) {
+			continue
+		}
+		seen[p] = true
+		matched = append(matched, p)
+	}
+	if len(matched) > limit {
+		return nil, &PatternError{Pattern: pattern, Err: en.tooMany()}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+type enumerator struct {
+	opts     EnumerateOpts
+	alphabet []rune
+	limit    int
+}
+
+func (en *enumerator) tooMany() error {
+	return fmt.Errorf("%w: more than %d paths", ErrLimitExceeded, en.limit)
+}
+
+// words returns the strings of up to maxLen characters of the alphabet, the
+// empty one included.
+func (en *enumerator) words(maxLen int) ([]string, error) {
+	if len(en.alphabet) == 0 || maxLen <= 0 {
+		return nil, ErrUnbounded
+	}
+	words := []string{""}
+	prev := []string{""}
+	for n := 1; n <= maxLen; n++ {
+		var cur []string
+		for _, w := range prev {
+			for _, r := range en.alphabet {
+				if len(words)+len(cur) == 2*en.limit {
+					return nil, en.tooMany()
+				}
+				cur = append(cur, w+string(r))
+			}
+		}
+		words = append(words, cur...)
+		prev = cur
+	}
+	return words, nil
+}
+
+// globstar returns the sequences of non-empty elements "**" stands for.
+func (en *enumerator) globstar() ([][]string, error) {
+	if en.opts.MaxDepth <= 0 {
+		return nil, ErrUnbounded
+	}
+	words, err := en.words(en.opts.MaxLength)
+	if err != nil {
+		return nil, err
+	}
+	words = words[1:]
+	seqs := [][]string{{}}
+	prev := [][]string{{}}
+	for depth := 1; depth <= en.opts.MaxDepth; depth++ {
+		var cur [][]string
+		for _, seq := range prev {
+			for _, w := range words {
+				if len(seqs)+len(cur) == 2*en.limit {
+					return nil, en.tooMany()
+				}
+				cur = append(cur, append(append([]string(nil), seq...), w))
+			}
+		}
+		seqs = append(seqs, cur...)
+		prev = cur
+	}
+	return seqs, nil
+}
+
+// segment returns the strings the nodes of seg stand for.
+func (en *enumerator) segment(seg *Segment) ([]string, error) {
+	words := []string{""}
+	for _, node := range seg.Nodes {
+		var alts []string
+		switch node.Kind {
+		case LiteralNode:
+			alts = []string{node.Value}
+		case AnyNode:
+			if len(en.alphabet) == 0 {
+				return nil, ErrUnbounded
+			}
+			for _, r := range en.alphabet {
+				alts = append(alts, string(r))
+			}
+		case StarNode, GlobstarNode:
+			var err error
+			if alts, err = en.words(en.opts.MaxLength); err != nil {
+				return nil, err
+			}
+		case ClassNode:
+			var err error
+			if alts, err = en.class(node); err != nil {
+				return nil, err
+			}
+		}
+		if len(words)*len(alts) > 2*en.limit {
+			return nil, en.tooMany()
+		}
+		next := make([]string, 0, len(words)*len(alts))
+		for _, w := range words {
+			for _, a := range alts {
+				next = append(next, w+a)
+			}
+		}
+		words = next
+	}
+	return words, nil
+}
+
+// class returns the characters of the class node stands for.
+func (en *enumerator) class(node *Node) ([]string, error) {
+	inClass := func(r rune) bool {
+		for _, rg := range node.Ranges {
+			if rg.Lo <= r && r <= rg.Hi {
+				return true
+			}
+		}
+		return false
+	}
+	var chars []string
+	if node.Negated {
+		if len(en.alphabet) == 0 {
+			return nil, ErrUnbounded
+		}
+		for _, r := range en.alphabet {
+			if !inClass(r) {
+				chars = append(chars, string(r))
+			}
+		}
+		return chars, nil
+	}
+	for _, rg := range node.Ranges {
+		for r := rg.Lo; r <= rg.Hi; r++ {
+			if len(chars) == 2*en.limit {
+				return nil, en.tooMany()
+			}
+			if !en.opts.Style.isSepRune(r) {
+				chars = append(chars, string(r))
+			}
+		}
+	}
+	return chars, nil
+}
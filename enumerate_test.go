@@ -0,0 +1,47 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnumerate(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		opts     EnumerateOpts
+		expected []string
+	}{
+		{"docs/README.md", EnumerateOpts{}, []string{"docs/README.md"}},
+		{"!log.[0-2]", EnumerateOpts{}, []string{"log.0", "log.1", "log.2"}},
+		{"[ab]/[!a]", EnumerateOpts{Alphabet: "abc"}, []string{"a/b", "a/c", "b/b", "b/c"}},
+		{"x?", EnumerateOpts{Alphabet: "ab"}, []string{"xa", "xb"}},
+		{"*.go", EnumerateOpts{Alphabet: "a", MaxLength: 2}, []string{".go", "a.go", "aa.go"}},
+		{"src/**/x", EnumerateOpts{Alphabet: "ab", MaxLength: 1, MaxDepth: 2}, []string{
+			"src/a/a/x", "src/a/b/x", "src/a/x", "src/b/a/x", "src/b/b/x", "src/b/x", "src/x",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Enumerate(tt.pattern, tt.opts)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.pattern, err)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: expected %q, got %q", tt.pattern, tt.expected, got)
+		}
+		pm := MustNew([]string{tt.pattern})
+		for _, p := range got {
+			if matched, _ := pm.MatchesOrParentMatches(filepath.FromSlash(p)); matched == (tt.pattern[0] == '!') {
+				t.Errorf("%q: expected %q to be decided by the pattern", tt.pattern, p)
+			}
+		}
+	}
+
+	if _, err := Enumerate("*.go", EnumerateOpts{}); !errors.Is(err, ErrUnbounded) {
+		t.Errorf("expected %v, got %v", ErrUnbounded, err)
+	}
+	if _, err := Enumerate("[a-z][a-z][a-z]", EnumerateOpts{MaxResults: 1000}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected %v, got %v", ErrLimitExceeded, err)
+	}
+}
@@ -0,0 +1,97 @@
+package patternmatcher
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrPatternEscapesRoot is returned when a pattern refers to a location
+// above the root and the ParentRefReject policy is in effect.
+var ErrPatternEscapesRoot = errors.New("pattern refers to a path outside the root")
+
+// ErrAbsolutePath is returned when a matcher created with WithStrictPaths
+// is asked to match an absolute path.
+var ErrAbsolutePath = errors.New("path is absolute")
+
+// ErrPathEscapesRoot is returned when a matcher created with
+// WithStrictPaths is asked to match a path containing ".." elements.
+var ErrPathEscapesRoot = errors.New("path contains a parent directory reference")
+
+// ErrControlCharacter is returned when a pattern or path contains a control
+// character and the ControlCharReject policy is in effect.
+var ErrControlCharacter = errors.New("contains a control character")
+
+// ErrPatternNotCompiled is returned when matching against a Pattern which
+// wasn't created by NewPattern or NewPatterns, such as a zero-value Pattern,
+// and lacks what is needed to evaluate its MatchType.
+var ErrPatternNotCompiled = errors.New("pattern isn't compiled")
+
+// ErrLimitExceeded is wrapped by the errors returned when compiling patterns
+// exceeds one of the limits set with WithLimits.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// ErrBudgetExceeded is returned when matching a path requires more work than
+// the budget set with WithEvaluationBudget.
+var ErrBudgetExceeded = errors.New("evaluation budget exceeded")
+
+// ErrOSDependent is returned by NewStrictPatterns for a pattern using a
+// construct whose meaning depends on the OS.
+var ErrOSDependent = errors.New("meaning depends on the OS")
+
+// ErrTrailingBackslash is returned by NewStrictPatterns for a pattern ending
+// with a backslash.
+var ErrTrailingBackslash = errors.New("ends with a backslash")
+
+// ErrEmptyClass is returned by NewStrictPatterns for a pattern with an empty
+// character class, such as "[]".
+var ErrEmptyClass = errors.New("contains an empty character class")
+
+// ErrNotPathElement is returned by the DirCursor methods for a name which
+// isn't a single path element.
+var ErrNotPathElement = errors.New("not a single path element")
+
+// ErrCannotRebase is returned by PatternMatcher.Rebase,
+// PatternMatcher.Prefix and NewPatternsFromFiles for a directory or a
+// pattern they can't handle.
+var ErrCannotRebase = errors.New("cannot rebase")
+
+// ErrPatternCleaned is wrapped by the errors reported for patterns altered
+// by cleaning, such as "./foo" or "a//b", under the CleanWarn and
+// CleanReject policies.
+var ErrPatternCleaned = errors.New("altered by cleaning")
+
+// PatternError records an error and the pattern that caused it.
+type PatternError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	return "invalid pattern " + strconv.Quote(e.Pattern) + ": " + e.Err.Error()
+}
+
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// ErrNotAbsolute is returned by NewMultiRoot for a root which isn't an
+// absolute path, and by the MultiRoot methods for such a path.
+var ErrNotAbsolute = errors.New("path isn't absolute")
+
+// ErrNoRoot is returned by the MultiRoot methods for a path outside of all
+// of its roots.
+var ErrNoRoot = errors.New("path isn't beneath a root")
+
+// PathError records an error and the path that caused it.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return "invalid path " + strconv.Quote(e.Path) + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,82 @@
+package patternmatcher
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// Examples holds a sample of the paths matched by a pattern, as returned by
+// ExampleMatches.
+type Examples struct {
+	// Paths holds the slash-separated paths matched by the pattern, in
+	// lexical order, relative to the root. A matched directory stands for
+	// its contents, which aren't listed. Paths is empty if the pattern
+	// matches nothing.
+	Paths []string
+	// More reports that the pattern matches more paths than those of Paths.
+	More bool
+}
+
+// errEnoughExamples stops the walk of ExampleMatches.
+var errEnoughExamples = errors.New("enough examples")
+
+// ExampleMatches returns up to n paths of the file tree rooted at root in
+// fsys matched by pattern, so that editors can give feedback while an
+// ignore rule is being typed. The examples of an exclusion pattern are the
+// paths it re-includes, and an empty pattern matches nothing. Directories
+// which can't contain a matched path aren't walked.
+//
+// A partial pattern which doesn't compile yet, such as "src/[a-", returns
+// the error of New.
+func ExampleMatches(fsys fs.FS, root, pattern string, n int, opts ...Option) (*Examples, error) {
+	examples := &Examples{}
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "!")
+	if pattern == "" || n <= 0 {
+		return examples, nil
+	}
+	pm, err := New([]string{pattern}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(root, p)
+		if d.IsDir() && rel != "." && pm.opts.prunesDir(fsys, p, d.Name()) {
+			return fs.SkipDir
+		}
+		matched, err := pm.MatchesWithOpts(rel, MatchOpts{IsDir: d.IsDir()})
+		if err != nil {
+			return err
+		}
+		if matched {
+			if len(examples.Paths) == n {
+				examples.More = true
+				return errEnoughExamples
+			}
+			examples.Paths = append(examples.Paths, rel)
+		}
+		if d.IsDir() && (matched || rel != "." && !pm.mayMatchBeneath(rel)) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil && err != errEnoughExamples {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// mayMatchBeneath reports whether a pattern of pm may match a path beneath
+// the slash-separated directory dir.
+func (pm *PatternMatcher) mayMatchBeneath(dir string) bool {
+	dirElems := strings.Split(pm.opts.style.fromSlash(dir), string(pm.opts.style.sep()))
+	for _, p := range pm.patterns {
+		if p.mayMatchBeneath(dirElems) {
+			return true
+		}
+	}
+	return false
+}
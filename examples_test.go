@@ -0,0 +1,36 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExampleMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		n        int
+		expected []string
+		more     bool
+	}{
+		{"**/*.go", 10, []string{"src/main.go", "src/pkg/lib.go", "src/pkg/lib_test.go"}, false},
+		{"**/*.go", 2, []string{"src/main.go", "src/pkg/lib.go"}, true},
+		{"node_modules", 10, []string{"node_modules"}, false},
+		{"!node_modules/keep.js", 10, []string{"node_modules/keep.js"}, false},
+		{"**/build", 10, []string{"build", "docs/build"}, false},
+		{"src/*.j", 10, nil, false},
+		{"", 10, nil, false},
+	}
+	for _, tt := range tests {
+		examples, err := ExampleMatches(walkFS, "ctx", tt.pattern, tt.n)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.pattern, err)
+		}
+		if !reflect.DeepEqual(examples.Paths, tt.expected) || examples.More != tt.more {
+			t.Errorf("%q: expected %q (more: %v), got %q (more: %v)", tt.pattern, tt.expected, tt.more, examples.Paths, examples.More)
+		}
+	}
+
+	if _, err := ExampleMatches(walkFS, "ctx", "src/[a-", 10); err == nil {
+		t.Error("expected an error for an incomplete class")
+	}
+}
@@ -0,0 +1,86 @@
+package excludes
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/moby/patternmatcher/internal/glob"
+)
+
+// ReadBorg reads an exclude file given to borg's --exclude-from. Each line
+// is a pattern, whose style is selected by a prefix:
+//
+//   - "fm:", the default, is an fnmatch pattern where "*" also matches
+//     path separators.
+//   - "sh:" is a shell pattern where "*" matches within a path element and
+//     "**/" any number of directories.
+//   - "re:" is a regular expression, which may match anywhere in the path.
+//   - "pp:" is a path prefix.
+//   - "pf:" is a full path.
+//
+// Except for "re:" and "pf:", a pattern matching a directory also excludes
+// its contents. Leading path separators are ignored, as borg stores paths
+// without them.
+func ReadBorg(r io.Reader) (*Matcher, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	m := &Matcher{}
+	for _, line := range lines {
+		match, err := compileBorg(line)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", line, err)
+		}
+		m.rules = append(m.rules, &Rule{Pattern: line, match: match})
+	}
+	return m, nil
+}
+
+func compileBorg(line string) (func(string) bool, error) {
+	style, pattern := "fm", line
+	if len(line) > 3 && line[2] == ':' {
+		style, pattern = line[:2], line[3:]
+	}
+	switch style {
+	case "fm", "sh":
+		flags := glob.LiteralBraces | glob.MatchContents
+		if style == "fm" {
+			flags |= glob.CrossSeparators
+		}
+		g, err := glob.Compile(cleanBorg(pattern), flags)
+		if err != nil {
+			return nil, err
+		}
+		return g.Match, nil
+	case "re":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case "pp":
+		prefix := cleanBorg(pattern)
+		return func(p string) bool {
+			return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+		}, nil
+	case "pf":
+		full := cleanBorg(pattern)
+		return func(p string) bool {
+			return p == full
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown pattern style %q", style)
+}
+
+// cleanBorg normalizes a path pattern the way borg does.
+func cleanBorg(pattern string) string {
+	pattern = strings.TrimLeft(pattern, "/")
+	if pattern == "" {
+		return ""
+	}
+	return path.Clean(pattern)
+}
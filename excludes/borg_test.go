@@ -0,0 +1,66 @@
+package excludes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadBorg(t *testing.T) {
+	const file = "# comment\n" +
+		"/home/*/junk\n" +
+		"sh:home/**/.cache\n" +
+		"re:\\.bak$\n" +
+		"pp:/var/tmp\n" +
+		"pf:/etc/secret\n"
+	m, err := ReadBorg(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{"/home/me/junk", true},
+		{"/home/me/junk/a", true},
+		{"/home/me/x/junk", true},
+		{"/home/me/.cache/a", true},
+		{"/home/.cache", true},
+		{"/home/me/x/.cache/a", true},
+		{"/srv/a.bak", true},
+		{"/srv/a.bak/b", false},
+		{"/var/tmp", true},
+		{"/var/tmp/a", true},
+		{"/var/tmpfile", false},
+		{"/etc/secret", true},
+		{"/etc/secret/a", false},
+		{"/etc/passwd", false},
+	}
+	for _, test := range tests {
+		if got := m.Excluded(test.path); got != test.excluded {
+			t.Errorf("Excluded(%q) = %v, want %v", test.path, got, test.excluded)
+		}
+	}
+}
+
+func TestReadBorgErrors(t *testing.T) {
+	for _, line := range []string{"xx:foo", "re:("} {
+		if _, err := ReadBorg(strings.NewReader(line)); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	borg, err := ReadBorg(strings.NewReader("pp:/data\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restic, err := ReadRestic(strings.NewReader("!/data/keep\n"), ResticOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	borg.Add(restic)
+	if !borg.Excluded("/data/x") || borg.Excluded("/data/keep/y") {
+		t.Error("expected the re-inclusion of the later rules to take precedence")
+	}
+}
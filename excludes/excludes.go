@@ -0,0 +1,77 @@
+// Package excludes reads the exclude files of backup tools, such as restic
+// and borg, into a Matcher, so that a tool can evaluate each format with the
+// same engine.
+package excludes
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Rule is a pattern of an exclude file.
+type Rule struct {
+	// Pattern is the pattern as written in the file, including its
+	// prefixes.
+	Pattern string
+	// Include is set for patterns re-including the paths they match.
+	Include bool
+
+	match func(path string) bool
+}
+
+// Matcher matches paths against the rules of exclude files. The last
+// matching rule decides whether a path is excluded.
+type Matcher struct {
+	rules []*Rule
+}
+
+// Rules returns the rules of the matcher, in order.
+func (m *Matcher) Rules() []*Rule {
+	return m.rules
+}
+
+// Add appends the rules of other to m, so that they take precedence over the
+// rules of m. This allows evaluating several exclude files, possibly of
+// different formats, at once.
+func (m *Matcher) Add(other *Matcher) {
+	m.rules = append(m.rules, other.rules...)
+}
+
+// Excluded reports whether path is excluded. path is slash-separated, and
+// is considered absolute whether or not it starts with "/".
+func (m *Matcher) Excluded(path string) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		if m.rules[i].match(path) {
+			return !m.rules[i].Include
+		}
+	}
+	return false
+}
+
+// readLines returns the lines of an exclude file which aren't blank or
+// comments, with surrounding whitespace removed.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if first {
+			line = bytes.TrimPrefix(line, []byte{0xEF, 0xBB, 0xBF})
+			first = false
+		}
+		text := strings.TrimSpace(string(line))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		lines = append(lines, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,62 @@
+package excludes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/moby/patternmatcher/internal/glob"
+)
+
+// ResticOptions configure ReadRestic.
+type ResticOptions struct {
+	// FoldCase matches regardless of case, as for --iexclude-file.
+	FoldCase bool
+	// Getenv expands the environment variables of patterns. It defaults to
+	// os.Getenv.
+	Getenv func(string) string
+}
+
+// ReadRestic reads an exclude file given to restic's --exclude-file, or to
+// --iexclude-file if opts.FoldCase is set.
+//
+// Patterns starting with "/" match from the root, others match at any
+// level. "*" matches within a path element and "**" across elements. A
+// pattern matching a directory also excludes its contents, and patterns
+// starting with "!" re-include the paths they match.
+func ReadRestic(r io.Reader, opts ResticOptions) (*Matcher, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	getenv := opts.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	flags := glob.LiteralBraces | glob.MatchContents
+	if opts.FoldCase {
+		flags |= glob.FoldCase
+	}
+	m := &Matcher{}
+	for _, line := range lines {
+		rule := &Rule{Pattern: line}
+		pattern := os.Expand(line, getenv)
+		if strings.HasPrefix(pattern, "!") {
+			rule.Include = true
+			pattern = pattern[1:]
+		}
+		if strings.HasPrefix(pattern, "/") {
+			pattern = strings.TrimLeft(pattern, "/")
+		} else {
+			pattern = "**/" + pattern
+		}
+		g, err := glob.Compile(strings.TrimSuffix(pattern, "/"), flags)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", line, err)
+		}
+		rule.match = g.Match
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
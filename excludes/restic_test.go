@@ -0,0 +1,59 @@
+package excludes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadRestic(t *testing.T) {
+	const file = "# comment\n" +
+		"*.tmp\n" +
+		"/home/*/.cache\n" +
+		"$HOME/Downloads\n" +
+		"**/node_modules\n" +
+		"!/home/me/.cache/keep\n" +
+		"build/\n"
+	getenv := func(name string) string {
+		if name == "HOME" {
+			return "/home/me"
+		}
+		return ""
+	}
+	m, err := ReadRestic(strings.NewReader(file), ResticOptions{Getenv: getenv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Rules()) != 6 {
+		t.Fatalf("got %d rules, want 6", len(m.Rules()))
+	}
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{"/a.tmp", true},
+		{"/srv/x/a.tmp", true},
+		{"/srv/x/a.TMP", false},
+		{"/home/me/.cache/x", true},
+		{"/home/me/.cache/keep", false},
+		{"/srv/home/me/.cache", false},
+		{"/home/me/Downloads/a.iso", true},
+		{"/src/app/node_modules/x/y.js", true},
+		{"/src/build/out", true},
+		{"/src/main.go", false},
+	}
+	for _, test := range tests {
+		if got := m.Excluded(test.path); got != test.excluded {
+			t.Errorf("Excluded(%q) = %v, want %v", test.path, got, test.excluded)
+		}
+	}
+}
+
+func TestReadResticFoldCase(t *testing.T) {
+	m, err := ReadRestic(strings.NewReader("*.TMP\n"), ResticOptions{FoldCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Excluded("/x/a.tmp") {
+		t.Error("expected /x/a.tmp to be excluded")
+	}
+}
@@ -0,0 +1,63 @@
+package patternmatcher
+
+// Explanation is the trace of the evaluation of a path, as returned by
+// Explain.
+type Explanation struct {
+	// Steps lists the patterns considered, in the order they were.
+	Steps []ExplainStep
+	// Matched is the final result, as returned by MatchesWithOpts.
+	Matched bool
+}
+
+// ExplainStep is a pattern considered while matching a path.
+type ExplainStep struct {
+	Pattern *Pattern
+	// Path is the slash-separated path the pattern was considered for,
+	// which is a parent directory of the explained path when parent
+	// directories decide first, as with WithGitReinclusionRules.
+	Path string
+	// Skipped reports that the pattern wasn't evaluated, because it
+	// couldn't change the result: it is an inclusion pattern and the path
+	// is already matched, or an exclusion and the path isn't matched.
+	Skipped bool
+	// Matched reports that the pattern matches Path or one of its parent
+	// directories, and ViaParent that it only matches a parent directory.
+	Matched   bool
+	ViaParent bool
+	// Decision is the running result once the pattern was considered.
+	Decision bool
+}
+
+// Explain is like MatchesWithOpts, but returns the trace of the evaluation
+// of file: every pattern considered, whether it was skipped or matched, and
+// the running result, for debugging ignore files whose result is
+// surprising. Patterns are all considered, even those that an index of the
+// matcher would rule out without evaluating them.
+func (pm *PatternMatcher) Explain(file string, opts MatchOpts) (*Explanation, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return nil, err
+	}
+	ex := &Explanation{}
+	e := &evaluation{opts: &opts, trace: ex}
+	ex.Matched, _ = pm.matchesOrParentMatches(file, e)
+	if err := e.pathError(file); err != nil {
+		return nil, err
+	}
+	return ex, nil
+}
+
+// traceStep appends the step of pattern, considered for path, to the trace
+// of e.
+func (e *evaluation) traceStep(pattern *Pattern, path string, isDir, skipped, matched, decision bool) {
+	step := ExplainStep{
+		Pattern:  pattern,
+		Path:     e.pm.opts.style.toSlash(path),
+		Skipped:  skipped,
+		Matched:  matched,
+		Decision: decision,
+	}
+	if matched {
+		step.ViaParent = !e.match(pattern, path, isDir)
+	}
+	e.trace.Steps = append(e.trace.Steps, step)
+}
@@ -0,0 +1,63 @@
+package patternmatcher
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// stepSummary is the part of an ExplainStep compared by the tests.
+type stepSummary struct {
+	pattern                               string
+	path                                  string
+	skipped, matched, viaParent, decision bool
+}
+
+func summarize(steps []ExplainStep) []stepSummary {
+	var summaries []stepSummary
+	for _, s := range steps {
+		summaries = append(summaries, stepSummary{filepath.ToSlash(s.Pattern.String()), s.Path, s.Skipped, s.Matched, s.ViaParent, s.Decision})
+	}
+	return summaries
+}
+
+func TestExplain(t *testing.T) {
+	pm, err := New([]string{"docs", "*.md", "!docs/README.md", "!*.go", "docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := pm.Explain("docs/README.md", MatchOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []stepSummary{
+		{"docs", "docs/README.md", false, true, true, true},
+		{"*.md", "docs/README.md", true, false, false, true},
+		{"!docs/README.md", "docs/README.md", false, true, false, false},
+		{"!*.go", "docs/README.md", true, false, false, false},
+		{"docs/README.md", "docs/README.md", false, true, false, true},
+	}
+	if got := summarize(ex.Steps); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if !ex.Matched {
+		t.Error("expected a match")
+	}
+
+	// With gitignore rules, the parent directories decide first.
+	pm, err = New([]string{"docs", "!docs/README.md"}, WithGitReinclusionRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err = pm.Explain("docs/README.md", MatchOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []stepSummary{
+		{"docs", "docs", false, true, false, true},
+		{"!docs/README.md", "docs", false, false, false, true},
+	}
+	if got := summarize(ex.Steps); !reflect.DeepEqual(got, expected) || !ex.Matched {
+		t.Errorf("expected %+v, got %+v (matched: %v)", expected, got, ex.Matched)
+	}
+}
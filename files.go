@@ -0,0 +1,64 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// NewPatternsFromFiles reads the ignore files at the slash-separated paths
+// files, relative to the directory root, and returns their patterns, each
+// anchored to the directory containing its file: "*.log" in
+// "web/.dockerignore" becomes "web/*.log". The patterns are returned in
+// the order of files, so the patterns of a file override those of the
+// files before it, and files in subdirectories should come after those of
+// their parents, as with a repository-wide ignore file followed by those
+// of its subdirectories.
+//
+// Files are read with ignorefile.ReadAll, and each pattern reports its file
+// as Source. A file that can't be read makes NewPatternsFromFiles fail.
+func NewPatternsFromFiles(root string, files []string, opts ...Option) ([]*Pattern, error) {
+	o := newOptions(opts)
+	var patterns []*Pattern
+	for _, file := range files {
+		lines, err := readIgnoreFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		if dir := path.Dir(path.Clean(file)); dir != "." {
+			prefix, ok := escapeLiteral(o.style.fromSlash(dir), o.style)
+			if !ok || dir == ".." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+				return nil, &PathError{Path: file, Err: ErrCannotRebase}
+			}
+			for i, line := range lines {
+				if strings.HasPrefix(line, "!") {
+					lines[i] = "!" + prefix + "/" + line[1:]
+				} else {
+					lines[i] = prefix + "/" + line
+				}
+			}
+		}
+		ps, err := newPatterns(lines, o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, p := range ps {
+			p.source = file
+		}
+		patterns = append(patterns, ps...)
+	}
+	return patterns, nil
+}
+
+func readIgnoreFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ignorefile.ReadAll(f)
+}
@@ -0,0 +1,46 @@
+package patternmatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewPatternsFromFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".dockerignore":     "*.log\nweb/dist\n",
+		"web/.dockerignore": "# generated\n*.map\n!keep.log\n",
+	}
+	for name, contents := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	patterns, err := NewPatternsFromFiles(root, []string{".dockerignore", "web/.dockerignore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct{ pattern, source string }{
+		{"*.log", ".dockerignore"},
+		{"web/dist", ".dockerignore"},
+		{"web/*.map", "web/.dockerignore"},
+		{"!web/keep.log", "web/.dockerignore"},
+	}
+	if len(patterns) != len(expected) {
+		t.Fatalf("expected %d patterns, got %q", len(expected), patterns)
+	}
+	for i, e := range expected {
+		if patterns[i].String() != filepath.FromSlash(e.pattern) || patterns[i].Source() != e.source {
+			t.Errorf("pattern %d: expected %q from %s, got %q from %s", i, e.pattern, e.source, patterns[i], patterns[i].Source())
+		}
+	}
+
+	if _, err := NewPatternsFromFiles(root, []string{"missing/.dockerignore"}); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
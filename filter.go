@@ -0,0 +1,88 @@
+package patternmatcher
+
+import "context"
+
+// Filter returns the items of items whose path, as returned by pathOf, m
+// doesn't match, in their order, such as the entries of a manifest which
+// aren't excluded by an ignore file. Paths are matched with m.Matches, as
+// with MatchesOrParentMatches for a PatternMatcher. It fails with the error
+// of the first path m rejects.
+func Filter[T any](m Matcher, items []T, pathOf func(T) string) ([]T, error) {
+	var kept []T
+	for _, item := range items {
+		matched, err := m.Matches(pathOf(item))
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// FilterFunc returns a function reporting whether pm doesn't match a path,
+// that is whether the path should be kept, for the libraries walking,
+// copying or archiving files which accept a func(path string) bool filter.
+// Paths are matched as with MatchesOrParentMatches. The error of a path pm
+// rejects is passed to onError, if it isn't nil, and the path isn't kept.
+func (pm *PatternMatcher) FilterFunc(onError func(path string, err error)) func(path string) bool {
+	return func(path string) bool {
+		matched, err := pm.MatchesOrParentMatches(path)
+		if err != nil {
+			if onError != nil {
+				onError(path, err)
+			}
+			return false
+		}
+		return !matched
+	}
+}
+
+// FilterChan is a pipeline stage passing on the paths received from in
+// which m doesn't match, in their order. Sends on the returned channel
+// are unbuffered, so a slow consumer holds back the producer.
+//
+// The stage stops when in is closed, when m rejects a path, or when ctx is
+// done, and then closes the returned channels. The error channel receives
+// the error stopping the stage, if any, which is either that of m or that
+// of ctx. It is buffered, so it doesn't need to be read for the stage to
+// stop.
+func FilterChan(ctx context.Context, m Matcher, in <-chan string) (<-chan string, <-chan error) {
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(out)
+		for {
+			var (
+				path string
+				ok   bool
+			)
+			select {
+			case path, ok = <-in:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+			matched, err := m.Matches(path)
+			if err != nil {
+				errc <- err
+				return
+			}
+			if matched {
+				continue
+			}
+			select {
+			case out <- path:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return out, errc
+}
@@ -0,0 +1,133 @@
+package patternmatcher
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := []*tar.Header{
+		{Name: "main.go"},
+		{Name: "debug.log"},
+		{Name: "node_modules/a/index.js"},
+		{Name: "node_modules/keep.js"},
+	}
+	kept, err := Filter(pm, headers, func(h *tar.Header) string { return h.Name })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, h := range kept {
+		names = append(names, h.Name)
+	}
+	if expected := []string{"main.go", "node_modules/keep.js"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %q, got %q", expected, names)
+	}
+
+	strict, err := New([]string{"*.log"}, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Filter(strict, []string{"a", "../b"}, func(s string) string { return s }); err == nil {
+		t.Error("expected an error for a path escaping the root")
+	}
+}
+
+func TestFilterFunc(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "*.log"}, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []string
+	keep := pm.FilterFunc(func(path string, err error) {
+		if !errors.Is(err, ErrPathEscapesRoot) {
+			t.Errorf("%q: unexpected error %v", path, err)
+		}
+		failed = append(failed, path)
+	})
+	for path, expected := range map[string]bool{
+		"main.go":              true,
+		"debug.log":            false,
+		"node_modules/a.js":    false,
+		"node_modules/keep.js": true,
+		"../secret":            false,
+	} {
+		if kept := keep(path); kept != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, kept)
+		}
+	}
+	if !reflect.DeepEqual(failed, []string{"../secret"}) {
+		t.Errorf("unexpected errors for %q", failed)
+	}
+
+	// Errors are swallowed without a callback.
+	if pm.FilterFunc(nil)("../secret") {
+		t.Error("expected a rejected path not to be kept")
+	}
+}
+
+func TestFilterChan(t *testing.T) {
+	pm, err := New([]string{"*.log"}, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, p := range []string{"main.go", "debug.log", "src/lib.go"} {
+			in <- p
+		}
+	}()
+	out, errc := FilterChan(context.Background(), pm, in)
+	var paths []string
+	for p := range out {
+		paths = append(paths, p)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"main.go", "src/lib.go"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %q, got %q", expected, paths)
+	}
+
+	// An invalid path stops the stage.
+	in = make(chan string, 2)
+	in <- "../escape"
+	in <- "main.go"
+	out, errc = FilterChan(context.Background(), pm, in)
+	for p := range out {
+		t.Errorf("unexpected path %q", p)
+	}
+	if err := <-errc; err == nil {
+		t.Error("expected an error for a path escaping the root")
+	}
+
+	// Cancelling stops a stage blocked on its consumer.
+	ctx, cancel := context.WithCancel(context.Background())
+	in = make(chan string, 1)
+	in <- "main.go"
+	_, errc = FilterChan(ctx, pm, in)
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestFilterMatcher(t *testing.T) {
+	// Filter accepts any Matcher, such as a Chain.
+	chain := NewChain(AllMustInclude, MustNew([]string{"*.log"}), MustNew([]string{"build"}))
+	kept, err := Filter(Matcher(chain), []string{"a.log", "build/out", "main.go"}, func(p string) string { return p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(kept, []string{"main.go"}) {
+		t.Errorf("unexpected paths %q", kept)
+	}
+}
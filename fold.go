@@ -0,0 +1,99 @@
+package patternmatcher
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matchFold is like match, but matches path regardless of case. path and
+// folded, the literal of the pattern, must be folded with foldString, and
+// foldRegexp is the case-insensitive version of the regexp of the pattern.
+func (p *Pattern) matchFold(path, folded string, foldRegexp *regexp.Regexp) bool {
+	switch p.MatchType {
+	case ExactMatch:
+		return equalLiteral(path, folded)
+	case PrefixMatch:
+		return hasPrefixLiteral(path, folded[:len(folded)-2])
+	case SuffixMatch:
+		suffix := folded[2:]
+		if hasSuffixLiteral(path, suffix) {
+			return true
+		}
+		return suffix[0] == p.style.sep() && equalLiteral(path, suffix[1:])
+	case ExtensionMatch:
+		return hasSuffixLiteral(path, folded[1:]) && strings.IndexByte(path, p.style.sep()) == -1
+	case RegexpMatch, ClassMatch:
+		if foldRegexp == nil {
+			return false
+		}
+		return p.matchRegexp(foldRegexp, path)
+	}
+	return false
+}
+
+// setFoldCase makes p match regardless of case, by replacing its matching
+// method with a case-insensitive regexp.
+func (p *Pattern) setFoldCase() {
+	p.Regexp = regexp.MustCompile("^(?i:" + p.regexpSource() + ")$")
+	p.MatchType = RegexpMatch
+	p.segment = nil
+	p.foldCase = true
+}
+
+// foldRune returns the representative of the runes equal to r under simple
+// Unicode case-folding: the lower case letter for ASCII letters, and the
+// smallest rune of the folding orbit otherwise.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	if 'A' <= min && min <= 'Z' {
+		// Such as the Kelvin sign, folding to "k".
+		min += 'a' - 'A'
+	}
+	return min
+}
+
+// foldString returns s with its runes replaced by their representative
+// under simple Unicode case-folding, so that two strings equal regardless
+// of case have the same folded form. Separators are left unchanged, so the
+// folded form of a path has the folded forms of its parent directories as
+// prefixes. s is returned as is if it is already folded.
+func foldString(s string) string {
+	i := 0
+	for i < len(s) {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if foldRune(r) != r {
+			break
+		}
+		i += n
+	}
+	if i == len(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	sb.WriteString(s[:i])
+	for i < len(s) {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			// Keep invalid bytes as they are.
+			sb.WriteByte(s[i])
+		} else {
+			sb.WriteRune(foldRune(r))
+		}
+		i += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package patternmatcher
+
+import "testing"
+
+func TestFoldString(t *testing.T) {
+	for _, tt := range []struct {
+		s, t  string
+		equal bool
+	}{
+		{"Kelvin", "Kelvin", true},
+		{"kelvin/File", "KELVIN/file", true},
+		{"dir/README.MD", "dir/readme.md", true},
+		{"ſ", "S", true},
+		{"Straße", "STRASSE", false},
+		{"a\xffb", "A\xffB", true},
+		{"a\xffb", "a\xfeb", false},
+	} {
+		if got := foldString(tt.s) == foldString(tt.t); got != tt.equal {
+			t.Errorf("foldString(%q) == foldString(%q): expected %v, got %v", tt.s, tt.t, tt.equal, got)
+		}
+	}
+	if s := "already/folded.md"; foldString(s) != s {
+		t.Errorf("expected %q to be left unchanged, got %q", s, foldString(s))
+	}
+	if got := foldString("Dir/\xffFile"); got != "dir/\xfffile" {
+		t.Errorf("expected invalid bytes to be kept, got %q", got)
+	}
+}
+
+func BenchmarkFoldCase(b *testing.B) {
+	pm, err := New([]string{"node_modules", "**/*.log", "build/**", "docs/README.md"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	opts := MatchOpts{FoldCase: true}
+	for i := 0; i < b.N; i++ {
+		if _, err := pm.MatchesWithOpts("Src/Pkg/Internal/Server.go", opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package patternmatcher
+
+import "text/template"
+
+// FuncMap returns template functions bound to pm, for templated packaging
+// manifests and reports:
+//
+//	ignored PATH   reports whether pm matches PATH
+//	included PATH  reports whether pm doesn't match PATH
+//
+// Paths are matched as with MatchesOrParentMatches, and an invalid path
+// fails the execution of the template. The map can be converted to the
+// FuncMap of html/template.
+func (pm *PatternMatcher) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"ignored": pm.MatchesOrParentMatches,
+		"included": func(path string) (bool, error) {
+			matched, err := pm.MatchesOrParentMatches(path)
+			return !matched, err
+		},
+	}
+}
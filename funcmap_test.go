@@ -0,0 +1,35 @@
+package patternmatcher
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("manifest").Funcs(pm.FuncMap()).Parse(
+		`{{range .}}{{if included .}}{{.}} {{end}}{{if ignored .}}-{{.}} {{end}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, []string{"main.go", "node_modules/a.js", "node_modules/keep.js"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "main.go -node_modules/a.js node_modules/keep.js "; sb.String() != expected {
+		t.Errorf("expected %q, got %q", expected, sb.String())
+	}
+
+	strict, err := New([]string{"node_modules"}, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl = template.Must(template.New("invalid").Funcs(strict.FuncMap()).Parse(`{{ignored "/etc/passwd"}}`))
+	if err := tmpl.Execute(&sb, nil); err == nil {
+		t.Error("expected an error for an absolute path")
+	}
+}
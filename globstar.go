@@ -0,0 +1,57 @@
+package patternmatcher
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WithMaxGlobstarDepth limits the number of directory levels a "**" may
+// span to depth, so that "a/**/b" matches "a/b" and "a/x/b" but not
+// "a/x/y/b" with a depth of 1. Matchers over enormous trees can use it to
+// bound the reach of their patterns, which also lets ShouldWatch and the
+// walkers prune more directories. A depth of 0, the default, means no limit.
+// Depths below 0 or above 1000, the largest repeat count of package regexp,
+// make the constructors return an error.
+//
+// A trailing "**", as in "a/**", isn't limited: since the parent directories
+// of a path are matched as well, it matches everything beneath "a" anyway.
+func WithMaxGlobstarDepth(depth int) Option {
+	return func(o *options) {
+		o.globstarDepth = depth
+	}
+}
+
+// maxGlobstarDepth is the largest depth WithMaxGlobstarDepth accepts, as
+// "**" is compiled to a repetition of up to depth directory levels.
+const maxGlobstarDepth = 1000
+
+// capGlobstar limits the "**" of p which are followed by more of the
+// pattern to span at most depth directory levels.
+func (p *Pattern) capGlobstar(depth int) {
+	if depth <= 0 || !strings.Contains(p.CleanedPattern, "**") {
+		return
+	}
+	p.globstarDepth = depth
+
+	sep := regexp.QuoteMeta(string(p.style.sep()))
+	levels := "(?:[^" + sep + "]*" + sep + "){0," + strconv.Itoa(depth) + "}"
+	var src string
+	switch p.MatchType {
+	case SuffixMatch:
+		suffix := p.CleanedPattern[2:]
+		if suffix != "" && suffix[0] == p.style.sep() {
+			src = levels + regexp.QuoteMeta(suffix[1:])
+		} else {
+			// "**foo" may end in the middle of a name.
+			src = levels + "[^" + sep + "]*" + regexp.QuoteMeta(suffix)
+		}
+	case RegexpMatch, ClassMatch:
+		src = strings.ReplaceAll(p.regexpSource(), "(.*"+sep+")?", levels)
+	default:
+		return
+	}
+	p.Regexp = regexp.MustCompile("^" + src + "$")
+	p.MatchType = RegexpMatch
+	p.segment = nil
+}
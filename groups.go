@@ -0,0 +1,60 @@
+package patternmatcher
+
+import "sort"
+
+// Groups returns the names of the groups of the patterns of pm, in
+// alphabetical order. Patterns which don't belong to a group aren't
+// reported.
+func (pm *PatternMatcher) Groups() []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, p := range pm.all {
+		if p.group != "" && !seen[p.group] {
+			seen[p.group] = true
+			groups = append(groups, p.group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// GroupEnabled reports whether the patterns of the named group are
+// evaluated. Groups are enabled unless disabled with DisableGroups.
+func (pm *PatternMatcher) GroupEnabled(name string) bool {
+	return !pm.disabled[name]
+}
+
+// DisableGroups returns a PatternMatcher that ignores the patterns of the
+// named groups, such as the patterns excluding tests for an --include-tests
+// flag. pm isn't modified, and its compiled patterns are reused.
+func (pm *PatternMatcher) DisableGroups(names ...string) *PatternMatcher {
+	return pm.withGroups(names, false)
+}
+
+// EnableGroups returns a PatternMatcher that evaluates the patterns of the
+// named groups again, after they were disabled with DisableGroups. pm isn't
+// modified, and its compiled patterns are reused.
+func (pm *PatternMatcher) EnableGroups(names ...string) *PatternMatcher {
+	return pm.withGroups(names, true)
+}
+
+func (pm *PatternMatcher) withGroups(names []string, enabled bool) *PatternMatcher {
+	disabled := make(map[string]bool, len(pm.disabled)+len(names))
+	for name := range pm.disabled {
+		disabled[name] = true
+	}
+	for _, name := range names {
+		if enabled {
+			delete(disabled, name)
+		} else {
+			disabled[name] = true
+		}
+	}
+	derived, err := newPatternMatcher(pm.all, pm.baseOpts, disabled)
+	if err != nil {
+		// The combined regexp of a subset of the patterns of pm can't fail
+		// to compile if that of pm didn't.
+		panic(err)
+	}
+	return derived
+}
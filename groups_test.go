@@ -0,0 +1,57 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSinglePassMatching()}} {
+		pm, err := NewFromSources([]Source{
+			{Patterns: []string{"*.tmp"}},
+			{Group: "tests", Patterns: []string{"**/*_test.go", "**/testdata"}},
+			{Group: "docs", Patterns: []string{"docs", "*.md"}},
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if groups := pm.Groups(); !reflect.DeepEqual(groups, []string{"docs", "tests"}) {
+			t.Errorf("unexpected groups %q", groups)
+		}
+
+		withTests := pm.DisableGroups("tests")
+		if withTests.GroupEnabled("tests") || !pm.GroupEnabled("tests") {
+			t.Errorf("expected only the derived matcher to have tests disabled")
+		}
+		reenabled := withTests.EnableGroups("tests")
+
+		tests := []struct {
+			path                      string
+			all, withTests, reenabled bool
+		}{
+			{"a.tmp", true, true, true},
+			{"src/main_test.go", true, false, true},
+			{"src/testdata/x", true, false, true},
+			{"docs/index.html", true, true, true},
+			{"src/main.go", false, false, false},
+		}
+		for _, tt := range tests {
+			for _, m := range []struct {
+				pm   *PatternMatcher
+				pass bool
+			}{{pm, tt.all}, {withTests, tt.withTests}, {reenabled, tt.reenabled}} {
+				if match, _ := m.pm.MatchesOrParentMatches(tt.path); match != m.pass {
+					t.Errorf("path %q, disabled groups %v: expected %v, got %v", tt.path, m.pm.disabled, m.pass, match)
+				}
+			}
+		}
+
+		derived, err := withTests.With("src")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if derived.GroupEnabled("tests") {
+			t.Errorf("expected With to keep groups disabled")
+		}
+	}
+}
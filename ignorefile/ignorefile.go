@@ -3,15 +3,32 @@ package ignorefile
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"io"
 	"path/filepath"
 	"strings"
+	"unicode/utf16"
 )
 
+// ErrTruncatedUTF16 is returned for an ignore file detected as UTF-16 whose
+// length is an odd number of bytes.
+var ErrTruncatedUTF16 = errors.New("ignorefile: UTF-16 content with an odd number of bytes")
+
+// Decoder converts the contents of an ignore file in a legacy encoding, such
+// as Latin-1 or Shift JIS, to UTF-8. The decoders of
+// golang.org/x/text/encoding implement it.
+type Decoder interface {
+	Reader(r io.Reader) io.Reader
+}
+
 // ReadAll reads an ignore file from a reader and returns the list of file
 // patterns to ignore, applying the following rules:
 //
 //   - An UTF8 BOM header (if present) is stripped.
+//   - UTF-16 content, as saved by Windows editors, is decoded. It is
+//     detected by its BOM, or, without one, by the NUL byte of the first
+//     character.
 //   - Lines starting with "#" are considered comments and are skipped.
 //
 // For remaining lines:
@@ -22,9 +39,20 @@ import (
 //   - Leading forward-slashes ("/") are removed from ignore patterns,
 //     so "/some/path" and "some/path" are considered equivalent.
 func ReadAll(reader io.Reader) ([]string, error) {
+	return ReadAllWithDecoder(reader, nil)
+}
+
+// ReadAllWithDecoder is like ReadAll, but decodes the contents of the ignore
+// file with dec if it is neither UTF-16 nor starts with an UTF8 BOM. A nil
+// dec reads the contents as UTF-8.
+func ReadAllWithDecoder(reader io.Reader, dec Decoder) ([]string, error) {
 	if reader == nil {
 		return nil, nil
 	}
+	reader, err := decode(reader, dec)
+	if err != nil {
+		return nil, err
+	}
 
 	var excludes []string
 	currentLine := 0
@@ -71,3 +99,38 @@ func ReadAll(reader io.Reader) ([]string, error) {
 	}
 	return excludes, nil
 }
+
+// decode returns a reader of the contents of reader converted to UTF-8.
+func decode(reader io.Reader, dec Decoder) (io.Reader, error) {
+	br := bufio.NewReader(reader)
+	head, _ := br.Peek(3)
+	var order binary.ByteOrder
+	switch {
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xFE:
+		order = binary.LittleEndian
+	case len(head) >= 2 && head[0] == 0xFE && head[1] == 0xFF:
+		order = binary.BigEndian
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		order = binary.LittleEndian
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		order = binary.BigEndian
+	case dec != nil && !bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return dec.Reader(br), nil
+	default:
+		return br, nil
+	}
+
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%2 != 0 {
+		return nil, ErrTruncatedUTF16
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	// The BOM is decoded into the UTF8 BOM, which is stripped.
+	return strings.NewReader(string(utf16.Decode(units))), nil
+}
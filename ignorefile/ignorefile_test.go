@@ -1,8 +1,15 @@
 package ignorefile
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf16"
 )
 
 func TestReadAll(t *testing.T) {
@@ -52,3 +59,79 @@ lastfile
 		}
 	}
 }
+
+// utf16Bytes encodes s in UTF-16, with bom prepended.
+func utf16Bytes(s string, order binary.ByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func TestReadAllUTF16(t *testing.T) {
+	const content = "# comment\r\nnode_modules\r\n!café/keep\r\n*.log\r\n"
+	expected := []string{"node_modules", "!café/keep", "*.log"}
+	for _, tt := range []struct {
+		name  string
+		order binary.ByteOrder
+		bom   bool
+	}{
+		{"LE with BOM", binary.LittleEndian, true},
+		{"BE with BOM", binary.BigEndian, true},
+		{"LE without BOM", binary.LittleEndian, false},
+		{"BE without BOM", binary.BigEndian, false},
+	} {
+		actual, err := ReadAll(bytes.NewReader(utf16Bytes(content, tt.order, tt.bom)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, expected, actual)
+		}
+	}
+
+	truncated := utf16Bytes("a\n", binary.LittleEndian, true)
+	if _, err := ReadAll(bytes.NewReader(truncated[:len(truncated)-1])); !errors.Is(err, ErrTruncatedUTF16) {
+		t.Errorf("expected ErrTruncatedUTF16, got %v", err)
+	}
+}
+
+// latin1 decodes ISO 8859-1, whose bytes are the code points of their
+// characters.
+type latin1 struct{}
+
+func (latin1) Reader(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return iotest.ErrReader(err)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return strings.NewReader(string(runes))
+}
+
+func TestReadAllWithDecoder(t *testing.T) {
+	actual, err := ReadAllWithDecoder(strings.NewReader("caf\xe9\n!na\xefve\n"), latin1{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"café", "!naïve"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	// The BOM of UTF-8 and UTF-16 content takes precedence over the decoder.
+	actual, err = ReadAllWithDecoder(strings.NewReader("\xef\xbb\xbfcaf\xc3\xa9\n"), latin1{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"café"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,112 @@
+package patternmatcher
+
+import "strings"
+
+// segmentIndex groups patterns in a trie by the literal leading elements of
+// their path.
+//
+// Patterns are anchored to the root, so a pattern whose leading elements are
+// literals, such as "vendor/github.com/*", can only match paths starting
+// with those same elements, and its regexp only needs to be run for them.
+// Patterns whose first element contains wildcards can match any path and
+// belong to the root of the trie. With machine-generated allowlists of
+// literal paths, a lookup returns the few patterns sharing the directory of
+// a path, whatever the size of the list.
+type segmentIndex struct {
+	root indexNode
+	sep  byte
+}
+
+// indexNode is the node of the trie for a directory.
+type indexNode struct {
+	children map[string]*indexNode
+	// patterns holds the patterns whose literal elements lead to the node,
+	// in their original order, and positions their position in the list.
+	// The patterns that may match the paths reaching the node are those of
+	// the node and of its ancestors.
+	patterns  []*Pattern
+	positions []int
+}
+
+// newSegmentIndex returns an index of patterns for paths separated by sep,
+// or nil if indexing wouldn't reduce the number of patterns to evaluate.
+func newSegmentIndex(patterns []*Pattern, sep byte) *segmentIndex {
+	idx := &segmentIndex{sep: sep}
+	nodes := make([]*indexNode, len(patterns))
+	for i, pattern := range patterns {
+		node := &idx.root
+		for _, elem := range pattern.literalDirs() {
+			child, ok := node.children[elem]
+			if !ok {
+				if node.children == nil {
+					node.children = make(map[string]*indexNode)
+				}
+				child = &indexNode{}
+				node.children[elem] = child
+			}
+			node = child
+		}
+		nodes[i] = node
+	}
+	if len(idx.root.children) == 0 {
+		return nil
+	}
+
+	for i, pattern := range patterns {
+		nodes[i].patterns = append(nodes[i].patterns, pattern)
+		nodes[i].positions = append(nodes[i].positions, i)
+	}
+	return idx
+}
+
+// lookup returns the patterns that may match file, which must be cleaned and
+// use the separator of the index, in their original order. A nil index
+// returns all patterns.
+func (idx *segmentIndex) lookup(file string, all []*Pattern) []*Pattern {
+	if idx == nil {
+		return all
+	}
+	// The nodes along the path of file holding patterns, which are few.
+	var buf [8]*indexNode
+	found := buf[:0]
+	n := 0
+	for node := &idx.root; node != nil; {
+		if len(node.patterns) != 0 {
+			found = append(found, node)
+			n += len(node.patterns)
+		}
+		if node.children == nil || file == "" {
+			break
+		}
+		elem, rest := file, ""
+		if i := strings.IndexByte(file, idx.sep); i != -1 {
+			elem, rest = file[:i], file[i+1:]
+		}
+		node, file = node.children[elem], rest
+	}
+	switch len(found) {
+	case 0:
+		return nil
+	case 1:
+		return found[0].patterns
+	}
+
+	// Merge the patterns of the nodes back into their original order.
+	patterns := make([]*Pattern, 0, n)
+	var headBuf [8]int
+	heads := headBuf[:0]
+	for range found {
+		heads = append(heads, 0)
+	}
+	for len(patterns) < n {
+		next := -1
+		for j, node := range found {
+			if heads[j] < len(node.positions) && (next == -1 || node.positions[heads[j]] < found[next].positions[heads[next]]) {
+				next = j
+			}
+		}
+		patterns = append(patterns, found[next].patterns[heads[next]])
+		heads[next]++
+	}
+	return patterns
+}
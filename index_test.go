@@ -0,0 +1,104 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSegmentIndex(t *testing.T) {
+	patterns, err := NewPatterns([]string{"vendor", "!vendor/keep", "*.go", "docs/*.md", "**/testdata", "!docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newSegmentIndex(patterns, os.PathSeparator)
+	if idx == nil {
+		t.Fatal("expected patterns to be indexed")
+	}
+
+	tests := []struct {
+		path     string
+		expected []int
+	}{
+		{"vendor/x/y.go", []int{0, 2, 4}},
+		{"vendor/keep/y.go", []int{0, 1, 2, 4}},
+		{"docs/index.md", []int{2, 3, 4}},
+		{"docs/README.md", []int{2, 3, 4, 5}},
+		{"src/main.go", []int{2, 4}},
+		{"vendor", []int{0, 2, 4}},
+	}
+	for _, tt := range tests {
+		got := idx.lookup(filepath.FromSlash(tt.path), patterns)
+		if len(got) != len(tt.expected) {
+			t.Errorf("path %q: expected %d patterns, got %d", tt.path, len(tt.expected), len(got))
+			continue
+		}
+		for i, j := range tt.expected {
+			if got[i] != patterns[j] {
+				t.Errorf("path %q: expected pattern %d to be %q, got %q", tt.path, i, patterns[j], got[i])
+			}
+		}
+	}
+
+	// Each pattern is stored once, on the node of its literal elements.
+	var count func(n *indexNode) int
+	count = func(n *indexNode) int {
+		c := len(n.patterns)
+		for _, child := range n.children {
+			c += count(child)
+		}
+		return c
+	}
+	if c := count(&idx.root); c != len(patterns) {
+		t.Errorf("expected %d patterns in the index, got %d", len(patterns), c)
+	}
+
+	wildcards, err := NewPatterns([]string{"*.go", "**/testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx := newSegmentIndex(wildcards, os.PathSeparator); idx != nil {
+		t.Errorf("expected no index when no pattern starts with a literal")
+	}
+}
+
+func TestSegmentIndexMatches(t *testing.T) {
+	patterns := []string{"vendor", "!vendor/keep", "*.go", "docs/*.md", "**/testdata", "!docs/README.md", "**", "!src"}
+	paths := []string{"vendor/x/y.go", "vendor/keep/a", "docs/index.md", "docs/README.md", "src/main.go", "main.go", "a/testdata/b", "src"}
+	for i := range patterns {
+		compiled, err := NewPatterns(patterns[:i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pm, err := New(patterns[:i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			want, _ := MatchesOrParentMatches(compiled, path)
+			got, _ := pm.MatchesOrParentMatches(path)
+			if got != want {
+				t.Errorf("patterns %q, path %q: expected %v, got %v", patterns[:i+1], path, want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkSegmentIndex(b *testing.B) {
+	// An ignore file organized by subdirectory of a monorepo.
+	var patterns []string
+	for i := 0; i < 50; i++ {
+		patterns = append(patterns, fmt.Sprintf("services/svc%d/*.tmp", i), fmt.Sprintf("services/svc%d/build/**", i))
+	}
+	pm, err := New(patterns)
+	if err != nil {
+		b.Fatal(err)
+	}
+	file := filepath.FromSlash("services/svc7/src/handler.go")
+	for i := 0; i < b.N; i++ {
+		if _, err := pm.MatchesOrParentMatches(file); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package patternmatcher
+
+import "time"
+
+// Instrumentation receives measurements of the work of a PatternMatcher, such
+// as to record them as metrics. Its methods are called synchronously, from
+// the goroutines matching paths, and must be safe for concurrent use.
+type Instrumentation interface {
+	// PatternEvaluated is called after evaluating pattern against a path
+	// and its parent dirs, with the time it took.
+	PatternEvaluated(pattern *Pattern, elapsed time.Duration)
+	// WalkDone is called at the end of a walk, such as WalkDir.
+	WalkDone(stats WalkStats)
+}
+
+// WalkStats are the totals of a walk.
+type WalkStats struct {
+	// Visited is the number of paths evaluated.
+	Visited int
+	// Included is the number of paths which weren't matched.
+	Included int
+	// Elapsed is the duration of the walk.
+	Elapsed time.Duration
+}
+
+// WithInstrumentation reports the work of a PatternMatcher to instr.
+//
+// Patterns are measured when matching with MatchesOrParentMatches,
+// MatchesWithOpts and MatchesWithLabels. Measuring them requires evaluating
+// patterns one at a time, so WithSinglePassMatching is ignored.
+func WithInstrumentation(instr Instrumentation) Option {
+	return func(o *options) {
+		o.instrumentation = instr
+	}
+}
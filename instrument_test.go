@@ -0,0 +1,55 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingInstrumentation struct {
+	mu       sync.Mutex
+	patterns map[string]int
+	walks    []WalkStats
+}
+
+func (r *recordingInstrumentation) PatternEvaluated(pattern *Pattern, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns[pattern.String()]++
+}
+
+func (r *recordingInstrumentation) WalkDone(stats WalkStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.walks = append(r.walks, stats)
+}
+
+func TestInstrumentation(t *testing.T) {
+	instr := &recordingInstrumentation{patterns: make(map[string]int)}
+	pm, err := New([]string{"src/**/*.go", "!src/main.go"}, WithInstrumentation(instr), WithSinglePassMatching())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.combined != nil {
+		t.Error("expected instrumentation to disable single pass matching")
+	}
+	if _, err := pm.MatchesOrParentMatches("src/main.go"); err != nil {
+		t.Fatal(err)
+	}
+	if instr.patterns[filepath.FromSlash("src/**/*.go")] != 1 || instr.patterns[filepath.FromSlash("!src/main.go")] != 1 {
+		t.Errorf("unexpected pattern evaluations %v", instr.patterns)
+	}
+
+	err = pm.WalkDir(walkFS, "ctx", func(string, fs.DirEntry, error) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instr.walks) != 1 {
+		t.Fatalf("expected 1 walk, got %d", len(instr.walks))
+	}
+	if stats := instr.walks[0]; stats.Visited != 15 || stats.Included != 13 {
+		t.Errorf("unexpected walk stats %+v", stats)
+	}
+}
@@ -0,0 +1,415 @@
+// Package glob compiles the glob dialect shared by EditorConfig, Syncthing
+// and backup tools, where "*" matches within a path element, "**" matches
+// across elements and "{a,b}" matches either alternative. Flags select the
+// variations of each tool.
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Flags select the variations of the dialect.
+type Flags uint
+
+const (
+	// NumericRanges makes "{n..m}" match the integers from n to m.
+	NumericRanges Flags = 1 << iota
+	// FoldCase makes the glob match regardless of case.
+	FoldCase
+	// MatchContents makes the glob also match the contents of the
+	// directories it matches.
+	MatchContents
+	// LiteralBraces makes braces match themselves.
+	LiteralBraces
+	// CrossSeparators makes "*" and "?" also match path separators, as
+	// in fnmatch without FNM_PATHNAME.
+	CrossSeparators
+)
+
+// Glob is a compiled glob.
+type Glob struct {
+	re *regexp.Regexp
+}
+
+var (
+	numericRange     = regexp.MustCompile(`^([+-]?[0-9]+)\.\.([+-]?[0-9]+)$`)
+	numericRangeStep = regexp.MustCompile(`^([+-]?[0-9]+)\.\.([+-]?[0-9]+)(?:\.\.([+-]?[0-9]+))?$`)
+)
+
+// Compile compiles a glob matching whole slash-separated paths. A leading
+// "**/" also matches paths in the top directory.
+func Compile(glob string, flags Flags) (*Glob, error) {
+	t := translator{flags: flags}
+	expr := "^" + t.translate(glob)
+	if flags&MatchContents != 0 {
+		expr += "(?:/.*)?"
+	}
+	expr += "$"
+	if flags&FoldCase != 0 {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile("(?s)" + expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Glob{re: re}, nil
+}
+
+// Match reports whether the slash-separated path matches the glob.
+func (g *Glob) Match(path string) bool {
+	return g.re.MatchString(path)
+}
+
+type translator struct {
+	flags Flags
+}
+
+// translate converts glob to a regular expression.
+func (t *translator) translate(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			} else {
+				sb.WriteString(`\\`)
+			}
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				wholeElement := i == 1 || glob[i-2] == '/'
+				if wholeElement && strings.HasPrefix(glob[i+1:], "/") {
+					// "**/" also matches no directory at all.
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else if t.flags&CrossSeparators != 0 {
+				sb.WriteString(".*")
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			if t.flags&CrossSeparators != 0 {
+				sb.WriteString(".")
+			} else {
+				sb.WriteString("[^/]")
+			}
+		case '[':
+			end := ClassEnd(glob, i)
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : end]
+			i = end
+			if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+				sb.WriteString("[^" + escapeClass(class[1:]) + "]")
+			} else {
+				sb.WriteString("[" + escapeClass(class) + "]")
+			}
+		case '{':
+			end := -1
+			if t.flags&LiteralBraces == 0 {
+				end = matchingBrace(glob, i, true)
+			}
+			if end == -1 {
+				sb.WriteString(`\{`)
+				continue
+			}
+			inner := glob[i+1 : end]
+			i = end
+			if m := numericRange.FindStringSubmatch(inner); m != nil && t.flags&NumericRanges != 0 {
+				lo, err1 := strconv.Atoi(m[1])
+				hi, err2 := strconv.Atoi(m[2])
+				if err1 == nil && err2 == nil {
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					sb.WriteString(numberRange(lo, hi))
+					continue
+				}
+			}
+			alternatives := splitAlternatives(inner, true)
+			if len(alternatives) == 1 {
+				// A brace without alternatives is literal.
+				sb.WriteString(`\{` + t.translate(inner) + `\}`)
+				continue
+			}
+			sb.WriteString("(?:")
+			for j, alt := range alternatives {
+				if j > 0 {
+					sb.WriteString("|")
+				}
+				sb.WriteString(t.translate(alt))
+			}
+			sb.WriteString(")")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// numberRange returns a regular expression matching the integers from lo
+// to hi, written with an optional sign and leading zeros as strconv.Atoi
+// accepts them. The bounds are enforced by the expression itself, so that
+// the wildcards around the range can't take some of its digits.
+func numberRange(lo, hi int) string {
+	var alternatives []string
+	if hi >= 0 {
+		from := 0
+		if lo > 0 {
+			from = lo
+		}
+		alternatives = append(alternatives, `\+?0*`+naturalRange(strconv.Itoa(from), strconv.Itoa(hi)))
+	}
+	if lo < 0 {
+		to := -1
+		if hi < -1 {
+			to = hi
+		}
+		// The magnitudes of the negative numbers, from the smallest.
+		alternatives = append(alternatives, `-0*`+naturalRange(strconv.Itoa(to)[1:], strconv.Itoa(lo)[1:]))
+		if hi >= 0 {
+			alternatives = append(alternatives, `-0+`)
+		}
+	}
+	return alternation(alternatives)
+}
+
+// naturalRange returns a regular expression matching the decimal numbers
+// from lo to hi, written without leading zeros.
+func naturalRange(lo, hi string) string {
+	var alternatives []string
+	for n := len(lo); n <= len(hi); n++ {
+		from, to := lo, hi
+		if n > len(lo) {
+			from = "1" + strings.Repeat("0", n-1)
+		}
+		if n < len(hi) {
+			to = strings.Repeat("9", n)
+		}
+		alternatives = append(alternatives, digitsRange(from, to))
+	}
+	return alternation(alternatives)
+}
+
+// digitsRange returns a regular expression matching the strings of digits
+// from lo to hi, which have the same length.
+func digitsRange(lo, hi string) string {
+	switch {
+	case lo == hi:
+		return lo
+	case len(lo) == 1:
+		return "[" + lo + "-" + hi + "]"
+	case lo[0] == hi[0]:
+		return lo[:1] + digitsRange(lo[1:], hi[1:])
+	}
+	rest := len(lo) - 1
+	alternatives := []string{lo[:1] + digitsRange(lo[1:], strings.Repeat("9", rest))}
+	if hi[0]-lo[0] > 1 {
+		alternatives = append(alternatives, "["+string(lo[0]+1)+"-"+string(hi[0]-1)+"][0-9]{"+strconv.Itoa(rest)+"}")
+	}
+	alternatives = append(alternatives, hi[:1]+digitsRange(strings.Repeat("0", rest), hi[1:]))
+	return alternation(alternatives)
+}
+
+// alternation returns a regular expression matching any of alternatives.
+func alternation(alternatives []string) string {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return "(?:" + strings.Join(alternatives, "|") + ")"
+}
+
+// ClassEnd returns the index of the "]" closing the bracket expression
+// starting at start, or -1 if there is none. Brackets containing a
+// separator aren't bracket expressions.
+func ClassEnd(glob string, start int) int {
+	end := strings.IndexByte(glob[start+1:], ']')
+	if end == -1 || strings.Contains(glob[start+1:start+1+end], "/") {
+		return -1
+	}
+	return start + 1 + end
+}
+
+// escapeClass escapes the characters of a bracket expression which have a
+// special meaning in regexp classes.
+func escapeClass(class string) string {
+	var sb strings.Builder
+	for i := 0; i < len(class); i++ {
+		c := class[i]
+		switch {
+		case c == '\\' && i+1 < len(class):
+			i++
+			sb.WriteString(`\` + class[i:i+1])
+		case c == '\\' || c == '[' || c == ']' || c == '^':
+			sb.WriteString(`\` + string(c))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// matchingBrace returns the index of the brace closing the one at start, or
+// -1 if it isn't closed. If escapes is set, "\\" escapes the next character.
+func matchingBrace(glob string, start int, escapes bool) int {
+	depth := 0
+	for i := start; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the content of a brace on the commas which
+// aren't nested in another brace.
+func splitAlternatives(inner string, escapes bool) []string {
+	var alternatives []string
+	depth, start := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, inner[start:])
+}
+
+// Expand expands the braces of pattern into one pattern per alternative, so
+// that "a{b,c{d,e}}" becomes "ab", "acd" and "ace". Braces holding a numeric
+// range, such as "{1..3}" or "{01..31..2}", expand into the numbers of the
+// range, zero-padded if one of its bounds is. Braces without alternatives
+// are left as they are. If escapes is set, "\\" escapes the next character.
+// Expand returns false if there would be more than max patterns, unless max
+// is 0.
+func Expand(pattern string, escapes bool, max int) ([]string, bool) {
+	var expanded []string
+	var expand func(s string) bool
+	expand = func(s string) bool {
+		start, end, alternatives, ok := firstBrace(s, escapes, max)
+		if !ok {
+			return false
+		}
+		if start == -1 {
+			expanded = append(expanded, s)
+			return max <= 0 || len(expanded) <= max
+		}
+		for _, alt := range alternatives {
+			if !expand(s[:start] + alt + s[end+1:]) {
+				return false
+			}
+		}
+		return true
+	}
+	if !expand(pattern) {
+		return nil, false
+	}
+	return expanded, true
+}
+
+// firstBrace returns the bounds and the alternatives of the first brace of s
+// with several alternatives, or -1 if there is none. It returns false if the
+// brace is a range of more than max numbers.
+func firstBrace(s string, escapes bool, max int) (start, end int, alternatives []string, ok bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case '{':
+			end := matchingBrace(s, i, escapes)
+			if end == -1 {
+				continue
+			}
+			if m := numericRangeStep.FindStringSubmatch(s[i+1 : end]); m != nil {
+				numbers, ok := expandRange(m[1], m[2], m[3], max)
+				return i, end, numbers, ok
+			}
+			if alternatives := splitAlternatives(s[i+1:end], escapes); len(alternatives) > 1 {
+				return i, end, alternatives, true
+			}
+		}
+	}
+	return -1, -1, nil, true
+}
+
+// expandRange returns the numbers from first to last, by step if it isn't
+// empty. It returns false if there are more than max numbers, unless max is
+// 0.
+func expandRange(first, last, step string, max int) ([]string, bool) {
+	lo, err1 := strconv.Atoi(first)
+	hi, err2 := strconv.Atoi(last)
+	inc := 1
+	var err3 error
+	if step != "" {
+		inc, err3 = strconv.Atoi(step)
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, false
+	}
+	if inc < 0 {
+		inc = -inc
+	}
+	if inc == 0 {
+		inc = 1
+	}
+	count := (hi-lo)/inc + 1
+	if hi < lo {
+		count = (lo-hi)/inc + 1
+		inc = -inc
+	}
+	if count <= 0 || max > 0 && count > max {
+		// The count overflowed, or is over the limit.
+		return nil, false
+	}
+	width := 0
+	if padded(first) || padded(last) {
+		width = len(first)
+		if len(last) > width {
+			width = len(last)
+		}
+	}
+	numbers := make([]string, 0, count)
+	for i, n := 0, lo; i < count; i, n = i+1, n+inc {
+		numbers = append(numbers, fmt.Sprintf("%0*d", width, n))
+	}
+	return numbers, true
+}
+
+// padded reports whether the number n has leading zeros.
+func padded(n string) bool {
+	n = strings.TrimLeft(n, "+-")
+	return len(n) > 1 && n[0] == '0'
+}
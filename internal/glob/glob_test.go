@@ -0,0 +1,104 @@
+package glob
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		glob  string
+		flags Flags
+		path  string
+		pass  bool
+	}{
+		{"*.c", 0, "a.c", true},
+		{"*.c", 0, "x/a.c", false},
+		{"**/*.c", 0, "a.c", true},
+		{"**/*.c", 0, "x/y/a.c", true},
+		{"a**", 0, "ab/c", true},
+		{"{a,b{c,d}}", 0, "bd", true},
+		{"f{1..3}", 0, "f2", false},
+		{"f{1..3}", 0, "f{1..3}", true},
+		{"f{1..3}", NumericRanges, "f2", true},
+		{"f{1..3}", NumericRanges, "f4", false},
+		{"*{10..20}", NumericRanges, "12", true},
+		{"*{10..20}", NumericRanges, "x12", true},
+		{"*{10..20}", NumericRanges, "x21", false},
+		{"*{10..20}", NumericRanges, "x9", false},
+		{"*{-5..5}", NumericRanges, "x-3", true},
+		{"*{-5..5}", NumericRanges, "x-6", false},
+		{"[!a]", 0, "a", false},
+		{"[^a]", 0, "b", true},
+		{"A.TXT", FoldCase, "a.txt", true},
+		{"A.TXT", 0, "a.txt", false},
+		{"dir", MatchContents, "dir/a/b", true},
+		{"dir", 0, "dir/a/b", false},
+		{"{a,b}", LiteralBraces, "a", false},
+		{"{a,b}", LiteralBraces, "{a,b}", true},
+		{"a*c", CrossSeparators, "a/b/c", true},
+		{"a?c", CrossSeparators, "a/c", true},
+	}
+	for _, test := range tests {
+		g, err := Compile(test.glob, test.flags)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", test.glob, err)
+		}
+		if got := g.Match(test.path); got != test.pass {
+			t.Errorf("Compile(%q, %v).Match(%q) = %v, want %v", test.glob, test.flags, test.path, got, test.pass)
+		}
+	}
+}
+
+func TestNumberRange(t *testing.T) {
+	for _, bounds := range [][2]int{{0, 0}, {1, 3}, {7, 123}, {10, 20}, {-15, -3}, {-12, 107}, {95, 1005}} {
+		re := regexp.MustCompile("^" + numberRange(bounds[0], bounds[1]) + "$")
+		for n := -1100; n <= 1100; n++ {
+			want := bounds[0] <= n && n <= bounds[1]
+			for _, s := range []string{strconv.Itoa(n), fmt.Sprintf("%+04d", n)} {
+				if got := re.MatchString(s); got != want {
+					t.Errorf("{%d..%d}: %q: expected %v, got %v", bounds[0], bounds[1], s, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		pattern string
+		escapes bool
+		max     int
+		want    []string
+	}{
+		{"a", true, 0, []string{"a"}},
+		{"*.{js,ts}", true, 0, []string{"*.js", "*.ts"}},
+		{"a{b,c{d,e}}f", true, 0, []string{"abf", "acdf", "acef"}},
+		{"{a,b}{c,d}", true, 0, []string{"ac", "ad", "bc", "bd"}},
+		{"{a}{b,c}", true, 0, []string{"{a}b", "{a}c"}},
+		{"{a,b", true, 0, []string{"{a,b"}},
+		{`\{a,b}`, true, 0, []string{`\{a,b}`}},
+		{`x\{a,b}`, false, 0, []string{`x\a`, `x\b`}},
+		{"{a,b}{c,d}", true, 3, nil},
+		{"{a,b}{c,d}", true, 4, []string{"ac", "ad", "bc", "bd"}},
+		{"log.{1..3}", true, 0, []string{"log.1", "log.2", "log.3"}},
+		{"log.{08..11}.gz", true, 0, []string{"log.08.gz", "log.09.gz", "log.10.gz", "log.11.gz"}},
+		{"{3..1}", true, 0, []string{"3", "2", "1"}},
+		{"{-1..1}", true, 0, []string{"-1", "0", "1"}},
+		{"{1..10..4}", true, 0, []string{"1", "5", "9"}},
+		{"{001..2}", true, 0, []string{"001", "002"}},
+		{"{a,{1..2}}", true, 0, []string{"a", "1", "2"}},
+		{"{1..a}", true, 0, []string{"{1..a}"}},
+		{"{1..1000000000}", true, 100, nil},
+		{"{1..3}{1..3}", true, 8, nil},
+	}
+	for _, test := range tests {
+		got, ok := Expand(test.pattern, test.escapes, test.max)
+		if ok != (test.want != nil) || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Expand(%q, %v, %d) = %q, %v, want %q", test.pattern, test.escapes, test.max, got, ok, test.want)
+		}
+	}
+}
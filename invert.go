@@ -0,0 +1,26 @@
+package patternmatcher
+
+// Invert returns a PatternMatcher matching the paths pm doesn't match, and
+// the other way around, so that a single ignore file can both exclude paths
+// from a build and list them for a cleanup step. pm isn't modified, and its
+// compiled patterns are reused.
+//
+// The patterns of the returned matcher are those of pm with their exclusion
+// flipped, so that it matches what pm excludes, paths beneath matched
+// directories and re-included paths included: "build" and "!build/keep"
+// become "!build" and "build/keep", and the paths no pattern matches are
+// matched. Its Patterns report them as such. The root isn't matched either
+// way with RootNotMatched. Inverting the returned matcher gives back one
+// equivalent to pm.
+func (pm *PatternMatcher) Invert() *PatternMatcher {
+	o := *pm.baseOpts
+	o.inverted = !o.inverted
+	inverted, err := newPatternMatcher(pm.all, &o, pm.disabled)
+	if err != nil {
+		// The only error is the combined regexp failing to compile, which
+		// doesn't depend on whether patterns are exclusions, and it
+		// compiled for pm.
+		panic(err)
+	}
+	return inverted
+}
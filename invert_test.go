@@ -0,0 +1,56 @@
+package patternmatcher
+
+import "testing"
+
+func TestInvert(t *testing.T) {
+	patterns := []string{"build", "!build/keep", "*.log"}
+	for _, opts := range [][]Option{nil, {WithSinglePassMatching()}, {WithGitignoreSemantics()}, {WithResolution(MostSpecificWins)}} {
+		pm, err := New(patterns, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inverted := pm.Invert()
+		for _, path := range []string{".", "main.go", "debug.log", "src/debug.log", "build", "build/out.o", "build/keep", "build/keep/x"} {
+			matched, err := pm.MatchesOrParentMatches(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			invertedMatched, err := inverted.MatchesOrParentMatches(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path == "." {
+				if matched || invertedMatched {
+					t.Errorf("expected the root not to be matched")
+				}
+				continue
+			}
+			if invertedMatched == matched {
+				t.Errorf("%q: expected the inverted matcher to return %v", path, !matched)
+			}
+			if twice, _ := inverted.Invert().MatchesOrParentMatches(path); twice != matched {
+				t.Errorf("%q: expected inverting twice to return %v", path, matched)
+			}
+		}
+	}
+
+	pm := MustNew(patterns)
+	inverted := pm.Invert()
+	if got := inverted.Patterns()[0].String(); got != "!build" {
+		t.Errorf("expected the first pattern to be %q, got %q", "!build", got)
+	}
+	if inverted.ExclusionCount() != 2 {
+		t.Errorf("expected 2 exclusions, got %d", inverted.ExclusionCount())
+	}
+	if pm.Patterns()[0].Exclusion {
+		t.Error("expected pm not to be modified")
+	}
+	// Patterns added later are inverted as well.
+	extended, err := inverted.With("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched, _ := extended.MatchesOrParentMatches("main.go"); matched {
+		t.Error("expected main.go not to be matched")
+	}
+}
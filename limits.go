@@ -0,0 +1,127 @@
+package patternmatcher
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+)
+
+// Limits bound the resources used to compile patterns, which protects
+// against pattern files supplied by untrusted parties. A zero value means no
+// limit.
+type Limits struct {
+	// MaxPatterns is the maximum number of patterns, counted once braces
+	// are expanded.
+	MaxPatterns int
+	// MaxPatternLength is the maximum length of a pattern in bytes.
+	MaxPatternLength int
+	// MaxProgramSize is the maximum number of instructions of the
+	// compiled regular expression of a pattern.
+	MaxProgramSize int
+	// MaxBraceExpansion is the maximum number of patterns a pattern can
+	// expand into with WithBraceExpansion. Unlike the other limits, it
+	// defaults to DefaultMaxBraceExpansion.
+	MaxBraceExpansion int
+}
+
+// DefaultMaxBraceExpansion is the maximum number of patterns a pattern can
+// expand into when Limits.MaxBraceExpansion isn't set.
+const DefaultMaxBraceExpansion = 4096
+
+// WithLimits makes NewPatterns, and the functions creating a PatternMatcher,
+// enforce limits. Exceeding a limit returns an error wrapping a
+// *LimitError, in a *PatternError for the limits applying to a single
+// pattern.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// LimitError reports that a limit of Limits was exceeded. It wraps
+// ErrLimitExceeded.
+type LimitError struct {
+	// Limit is the name of the field of Limits which was exceeded.
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return e.Limit + " of " + strconv.Itoa(e.Max) + " exceeded"
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// checkPattern checks the limits applying to the text of pattern p.
+func (l *Limits) checkPattern(p string) error {
+	if l.MaxPatternLength > 0 && len(p) > l.MaxPatternLength {
+		return &PatternError{Pattern: p, Err: &LimitError{Limit: "MaxPatternLength", Max: l.MaxPatternLength}}
+	}
+	return nil
+}
+
+// checkCompiled checks the limits applying to the compiled pattern p.
+func (l *Limits) checkCompiled(p *Pattern) error {
+	if l.MaxProgramSize > 0 && p.Regexp != nil && programSize(p.Regexp) > l.MaxProgramSize {
+		return &PatternError{Pattern: p.String(), Err: &LimitError{Limit: "MaxProgramSize", Max: l.MaxProgramSize}}
+	}
+	return nil
+}
+
+// programSize returns the number of instructions of the program re compiles
+// to.
+func programSize(re *regexp.Regexp) int {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return 0
+	}
+	return len(prog.Inst)
+}
+
+// WithEvaluationBudget caps the work of matching a single path, so that
+// adversarial combinations of patterns and paths can't tie up a server. A
+// step is the comparison of a pattern to the path or one of its parent dirs,
+// and comparisons evaluating a regular expression cost an extra step per
+// byte of the path, since the work of the regexp engine is linear in the
+// length of its input.
+//
+// Matching a path which exhausts the budget returns a *PathError wrapping
+// ErrBudgetExceeded. The budget applies to MatchesOrParentMatches,
+// MatchesWithOpts and MatchesWithLabels, and disables
+// WithSinglePassMatching.
+func WithEvaluationBudget(steps int) Option {
+	return func(o *options) {
+		o.budget = steps
+	}
+}
+
+// spend accounts for comparing pattern to path, and reports whether the
+// budget allows it.
+func (e *evaluation) spend(pattern *Pattern, path string) bool {
+	if e.budgetExceeded {
+		return false
+	}
+	e.steps++
+	if pattern.MatchType == RegexpMatch || pattern.MatchType == ClassMatch {
+		e.steps += len(path)
+	}
+	if e.steps > e.pm.opts.budget {
+		e.budgetExceeded = true
+		return false
+	}
+	return true
+}
+
+// pathError returns the error to report for matching file, if any.
+func (e *evaluation) pathError(file string) error {
+	if e.budgetExceeded {
+		return &PathError{Path: file, Err: ErrBudgetExceeded}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package patternmatcher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		limits   Limits
+		limit    string
+	}{
+		{[]string{"a", "b", "c"}, Limits{MaxPatterns: 2}, "MaxPatterns"},
+		{[]string{"a", "", "b"}, Limits{MaxPatterns: 2}, ""},
+		{[]string{"{a,b,c}"}, Limits{MaxPatterns: 2}, "MaxPatterns"},
+		{[]string{"short", strings.Repeat("x", 11)}, Limits{MaxPatternLength: 10}, "MaxPatternLength"},
+		{[]string{strings.Repeat("x", 10)}, Limits{MaxPatternLength: 10}, ""},
+		{[]string{strings.Repeat("*a", 50)}, Limits{MaxProgramSize: 100}, "MaxProgramSize"},
+		{[]string{"*.go"}, Limits{MaxProgramSize: 100}, ""},
+		{[]string{"{a,b}{c,d}{e,f}"}, Limits{MaxBraceExpansion: 7}, "MaxBraceExpansion"},
+		{[]string{"{a,b}{c,d}{e,f}"}, Limits{MaxBraceExpansion: 8}, ""},
+	}
+	for _, tt := range tests {
+		_, err := NewPatterns(tt.patterns, WithLimits(tt.limits), WithBraceExpansion())
+		if tt.limit == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.patterns, err)
+			}
+			continue
+		}
+		var limitErr *LimitError
+		if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != tt.limit {
+			t.Errorf("%q: expected %s to be exceeded, got %v", tt.patterns, tt.limit, err)
+		}
+	}
+}
+
+func TestBraceExpansion(t *testing.T) {
+	pm, err := New([]string{"*.{js,ts}", "!{test,spec}.{js,ts}", "{a}"}, WithBraceExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		pass bool
+	}{
+		{"a.js", true},
+		{"a.ts", true},
+		{"a.go", false},
+		{"spec.ts", false},
+		{"{a}", true},
+		{"a", false},
+	}
+	for _, tt := range tests {
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("path %q: expected %v, got %v", tt.path, tt.pass, match)
+		}
+	}
+
+	pm, err = New([]string{"*.{js,ts}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesOrParentMatches("a.js"); match {
+		t.Error("expected braces to be literal without WithBraceExpansion")
+	}
+}
+
+func TestEvaluationBudget(t *testing.T) {
+	patterns := []string{"a", "b", "c", "**/*.go"}
+	tests := []struct {
+		budget int
+		path   string
+		ok     bool
+	}{
+		{0, "x/y/z/main.go", true},
+		{100, "x/y/z/main.go", true},
+		{10, "x/y/z/main.go", false},
+		{2, "d", true},
+		{1, "d", false},
+	}
+	for _, tt := range tests {
+		pm, err := New(patterns, WithEvaluationBudget(tt.budget), WithSinglePassMatching())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range []func(string) (bool, error){
+			pm.MatchesOrParentMatches,
+			func(p string) (bool, error) { return pm.MatchesWithOpts(p, MatchOpts{}) },
+			func(p string) (bool, error) {
+				m, _, err := pm.MatchesWithLabels(p)
+				return m, err
+			},
+		} {
+			_, err := match(tt.path)
+			if tt.ok && err != nil {
+				t.Errorf("budget %d, path %q: unexpected error %v", tt.budget, tt.path, err)
+			}
+			if !tt.ok && !errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("budget %d, path %q: expected ErrBudgetExceeded, got %v", tt.budget, tt.path, err)
+			}
+		}
+	}
+}
+
+func TestBraceExpansionRanges(t *testing.T) {
+	pm, err := New([]string{"log.{01..31}.gz"}, WithBraceExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, pass := range map[string]bool{"log.01.gz": true, "log.31.gz": true, "log.1.gz": false, "log.32.gz": false} {
+		if match, _ := pm.MatchesOrParentMatches(path); match != pass {
+			t.Errorf("path %q: expected %v, got %v", path, pass, match)
+		}
+	}
+
+	_, err = New([]string{"{1..100000}"}, WithBraceExpansion())
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Max != DefaultMaxBraceExpansion {
+		t.Errorf("expected the default expansion limit to apply, got %v", err)
+	}
+}
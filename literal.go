@@ -0,0 +1,44 @@
+package patternmatcher
+
+// The literal comparisons of patterns check the lengths first, then the
+// byte of the literal least likely to be shared with a path before
+// comparing the rest. Paths in deep trees share long prefixes, such as
+// "vendor/github.com/", with the patterns, so for prefixes and exact
+// matches that is the last byte, and for suffixes the first one.
+
+// equalLiteral reports whether path equals literal.
+func equalLiteral(path, literal string) bool {
+	n := len(literal)
+	if len(path) != n {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+	return path[n-1] == literal[n-1] && path[:n-1] == literal[:n-1]
+}
+
+// hasPrefixLiteral reports whether path starts with literal.
+func hasPrefixLiteral(path, literal string) bool {
+	n := len(literal)
+	if len(path) < n {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+	return path[n-1] == literal[n-1] && path[:n-1] == literal[:n-1]
+}
+
+// hasSuffixLiteral reports whether path ends with literal.
+func hasSuffixLiteral(path, literal string) bool {
+	n := len(literal)
+	if len(path) < n {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+	start := len(path) - n
+	return path[start] == literal[0] && path[start+1:] == literal[1:]
+}
@@ -0,0 +1,65 @@
+package patternmatcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLiteralComparisons(t *testing.T) {
+	for _, tt := range []struct {
+		path, literal string
+	}{
+		{"", ""},
+		{"a", ""},
+		{"", "a"},
+		{"abc", "abc"},
+		{"abc", "abd"},
+		{"abc", "xbc"},
+		{"abcd", "abc"},
+		{"abcd", "bcd"},
+		{"ab", "abc"},
+	} {
+		if got, want := equalLiteral(tt.path, tt.literal), tt.path == tt.literal; got != want {
+			t.Errorf("equalLiteral(%q, %q) = %v, want %v", tt.path, tt.literal, got, want)
+		}
+		if got, want := hasPrefixLiteral(tt.path, tt.literal), strings.HasPrefix(tt.path, tt.literal); got != want {
+			t.Errorf("hasPrefixLiteral(%q, %q) = %v, want %v", tt.path, tt.literal, got, want)
+		}
+		if got, want := hasSuffixLiteral(tt.path, tt.literal), strings.HasSuffix(tt.path, tt.literal); got != want {
+			t.Errorf("hasSuffixLiteral(%q, %q) = %v, want %v", tt.path, tt.literal, got, want)
+		}
+	}
+}
+
+// longPath returns a path of about 4000 bytes in a deep vendored tree.
+func longPath() string {
+	elems := make([]string, 0, 200)
+	for len(elems) < 200 {
+		elems = append(elems, "vendor", "github.com", "org", "module")
+	}
+	return strings.Join(elems, "/") + "/file.go"
+}
+
+func BenchmarkLongPathLiterals(b *testing.B) {
+	file := longPath()
+	for _, tt := range []struct {
+		name    string
+		pattern string
+	}{
+		{"exact", "vendor/github.com/org/other"},
+		{"prefix", "vendor/github.com/org/other/**"},
+		{"suffix", "**/testdata"},
+	} {
+		pm, err := New([]string{tt.pattern})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if matched, _ := pm.MatchesOrParentMatches(file); matched {
+					b.Fatal("unexpected match")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package patternmatcher
+
+import "strings"
+
+// MatchAllPatterns returns the indices of all the patterns matching file or
+// one of its parent directories, in increasing order, for auditing which
+// rules a path hits. Unlike MatchesOrParentMatches, every pattern is
+// evaluated, whatever the patterns preceding it decide.
+//
+// The "file" argument should be a slash-delimited path.
+func MatchAllPatterns(patterns []*Pattern, file string) ([]int, error) {
+	if err := checkPatterns(patterns); err != nil {
+		return nil, err
+	}
+	pm := PatternMatcher{patterns: patterns, opts: defaultOptions}
+	return pm.matchAll(file, &evaluation{}), nil
+}
+
+// MatchAllPatterns is like the MatchAllPatterns function, with the patterns
+// of pm and opts applied. The indices are those of the patterns returned by
+// Patterns.
+func (pm *PatternMatcher) MatchAllPatterns(file string, opts MatchOpts) ([]int, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return nil, err
+	}
+	e := &evaluation{opts: &opts}
+	indices := pm.matchAll(file, e)
+	if err := e.pathError(file); err != nil {
+		return nil, err
+	}
+	return indices, nil
+}
+
+func (pm *PatternMatcher) matchAll(file string, e *evaluation) []int {
+	o := pm.opts
+	if file = o.style.clean(file); file == "." && o.root == RootNotMatched {
+		return nil
+	}
+	file = o.style.fromSlash(file)
+	if e.opts != nil && e.opts.FoldCase {
+		file = foldString(file)
+	}
+	var parentPathDirs []string
+	if parentPath := o.style.dir(file); parentPath != "." && !o.noParents {
+		parentPathDirs = strings.Split(parentPath, string(o.style.sep()))
+	}
+
+	e.pm = pm
+	e.file = file
+	isDir := e.opts != nil && (e.opts.IsDir || e.opts.Info != nil && e.opts.Info.IsDir())
+	var indices []int
+	for i, pattern := range pm.patterns {
+		if e.matchesOrParent(pattern, file, isDir, parentPathDirs) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,53 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchAllPatterns(t *testing.T) {
+	patterns := MustCompilePatterns("docs", "*.md", "!docs/README.md", "docs/*", "build/")
+	tests := []struct {
+		path     string
+		expected []int
+	}{
+		{"docs/README.md", []int{0, 2, 3}},
+		{"docs/a/b.md", []int{0, 3}},
+		{"README.md", []int{1}},
+		{"src/main.go", nil},
+		{".", nil},
+	}
+	for _, tt := range tests {
+		indices, err := MatchAllPatterns(patterns, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(indices, tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.path, tt.expected, indices)
+		}
+	}
+
+	pm := MustNew([]string{"build/", "*.o"})
+	for _, tt := range []struct {
+		path     string
+		isDir    bool
+		expected []int
+	}{
+		{"build", false, nil},
+		{"build", true, []int{0}},
+		{"build/main.o", false, []int{0}},
+		{"main.o", false, []int{1}},
+	} {
+		indices, err := pm.MatchAllPatterns(tt.path, MatchOpts{IsDir: tt.isDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(indices, tt.expected) {
+			t.Errorf("%q (dir: %v): expected %v, got %v", tt.path, tt.isDir, tt.expected, indices)
+		}
+	}
+
+	if _, err := MatchAllPatterns([]*Pattern{{}}, "a"); err == nil {
+		t.Error("expected an error for a pattern which isn't compiled")
+	}
+}
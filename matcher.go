@@ -0,0 +1,408 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matcher is implemented by the types matching slash-delimited paths, such
+// as PatternMatcher and Chain, so that the helpers accepting it, such as
+// Filter and WalkDirMatcher, also work with hand-written matchers. Matches
+// reports whether path is matched, that is excluded, and fails for the
+// paths the matcher rejects.
+type Matcher interface {
+	Matches(path string) (bool, error)
+}
+
+// PatternMatcher matches paths against a compiled list of patterns, applying
+// the options it was created with.
+//
+// A PatternMatcher is immutable once created, and is safe for concurrent use
+// by multiple goroutines, so that a server can share one between its
+// requests. Its patterns are compiled when it is created; the state built
+// lazily, such as the case-insensitive regexps of MatchOpts.FoldCase, is
+// built once under a sync.Once. The Instrumentation it is created with
+// must be safe for concurrent use as well. MatchesBatch matches a list of
+// paths from several goroutines.
+type PatternMatcher struct {
+	// all holds the patterns of every group, and patterns those of the
+	// groups which aren't disabled.
+	all      []*Pattern
+	patterns []*Pattern
+	disabled map[string]bool
+	// opts are the options applied when matching, derived from baseOpts,
+	// the options the matcher was created with.
+	opts     *options
+	baseOpts *options
+	combined *combinedRegexp
+	index    *segmentIndex
+	// predicates reports that some patterns have predicates, which need
+	// the attributes of the paths.
+	predicates bool
+	// exclusions is the number of patterns which are exclusions.
+	exclusions int
+
+	foldOnce       sync.Once
+	foldRegexps    map[*Pattern]*regexp.Regexp
+	foldedLiterals map[*Pattern]string
+}
+
+// New creates a PatternMatcher from a list of patterns. The patterns are
+// compiled in the same way as NewPatterns does.
+func New(patterns []string, opts ...Option) (*PatternMatcher, error) {
+	o := newOptions(opts)
+	ps, err := newPatterns(patterns, o)
+	if err != nil {
+		return nil, err
+	}
+	return newPatternMatcher(ps, o, nil)
+}
+
+// MustNew is like New, but panics if the patterns can't be compiled. It
+// simplifies the initialization of package-level variables and tests.
+func MustNew(patterns []string, opts ...Option) *PatternMatcher {
+	pm, err := New(patterns, opts...)
+	if err != nil {
+		panic("patternmatcher: MustNew: " + err.Error())
+	}
+	return pm
+}
+
+func newPatternMatcher(all []*Pattern, o *options, disabled map[string]bool) (*PatternMatcher, error) {
+	patterns := all
+	if len(disabled) != 0 || o.inverted {
+		patterns = make([]*Pattern, 0, len(all))
+		for _, p := range all {
+			if disabled[p.group] {
+				continue
+			}
+			if o.inverted {
+				p = p.Clone()
+				p.Exclusion = !p.Exclusion
+			}
+			patterns = append(patterns, p)
+		}
+	}
+	pm := &PatternMatcher{
+		all:      all,
+		patterns: patterns,
+		disabled: disabled,
+		opts:     o,
+		baseOpts: o,
+		index:    newSegmentIndex(patterns, o.style.sep()),
+	}
+	pm.predicates = hasPredicates(patterns)
+	for _, p := range patterns {
+		if p.Exclusion {
+			pm.exclusions++
+		}
+	}
+	if len(all) == 0 && o.emptyMatchesAll {
+		effective := *o
+		effective.defaultMatch = true
+		pm.opts = &effective
+	}
+	if o.inverted {
+		effective := *pm.opts
+		effective.defaultMatch = !effective.defaultMatch
+		pm.opts = &effective
+	}
+	if o.singlePass && o.resolution == LastMatchWins && !o.legacyParents && !o.gitReinclusion && o.instrumentation == nil && o.budget == 0 && o.wildcards == RuneWildcards && !pm.predicates && !hasPathPredicates(patterns) && !o.noParents {
+		var err error
+		pm.combined, err = newCombinedRegexp(patterns, o.style)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pm, nil
+}
+
+// With returns a new PatternMatcher matching the patterns of pm followed by
+// lines, such as patterns passed on the command line on top of those of an
+// ignore file. The lines are compiled with the options of pm, whose
+// patterns are reused without being compiled again.
+func (pm *PatternMatcher) With(lines ...string) (*PatternMatcher, error) {
+	extra, err := newPatterns(lines, pm.baseOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(pm.all) != 0 {
+		// Extra patterns are layered on top of the highest precedence.
+		for _, p := range extra {
+			p.precedence = pm.all[len(pm.all)-1].precedence
+		}
+	}
+	patterns := make([]*Pattern, 0, len(pm.all)+len(extra))
+	patterns = append(patterns, pm.all...)
+	patterns = append(patterns, extra...)
+	return newPatternMatcher(patterns, pm.baseOpts, pm.disabled)
+}
+
+// WithAdditionalPatterns is the same as With, for deriving the matcher of a
+// request from a base ignore set:
+//
+//	pm, err := base.WithAdditionalPatterns("!vendor/")
+func (pm *PatternMatcher) WithAdditionalPatterns(lines ...string) (*PatternMatcher, error) {
+	return pm.With(lines...)
+}
+
+// Clone returns a PatternMatcher equivalent to pm, sharing its compiled
+// patterns. As a PatternMatcher is immutable, it is only needed by code
+// which wants a distinct value, such as one whose lazily built caches
+// aren't shared with pm.
+func (pm *PatternMatcher) Clone() *PatternMatcher {
+	clone, err := newPatternMatcher(pm.all, pm.baseOpts, pm.disabled)
+	if err != nil {
+		// The only error is the combined regexp failing to compile, and
+		// it compiled for pm from the same patterns and options.
+		panic(err)
+	}
+	return clone
+}
+
+// Exclusions reports whether some of the patterns in effect in pm are
+// exclusions, starting with "!". Without them, no path beneath a matched
+// directory can be re-included, so a walk can prune every matched
+// directory.
+func (pm *PatternMatcher) Exclusions() bool {
+	return pm.exclusions != 0
+}
+
+// ExclusionCount returns the number of patterns in effect in pm which are
+// exclusions, counted once braces are expanded.
+func (pm *PatternMatcher) ExclusionCount() int {
+	return pm.exclusions
+}
+
+// Patterns returns copies of the patterns in effect in pm, normalized and in
+// the order they are evaluated, without those of the disabled groups. Their
+// String, MatchType and Exclusion describe the rules, and their Source,
+// Index, Group and Labels where they come from, so that tools can show the
+// rules actually applied.
+func (pm *PatternMatcher) Patterns() []*Pattern {
+	patterns := make([]*Pattern, len(pm.patterns))
+	for i, p := range pm.patterns {
+		patterns[i] = p.Clone()
+	}
+	return patterns
+}
+
+// Matches returns true if file, or one of its parent directories, matches
+// any of the patterns of pm and isn't excluded by any of the subsequent
+// patterns. It is the same as MatchesOrParentMatches.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) Matches(file string) (bool, error) {
+	return pm.MatchesOrParentMatches(file)
+}
+
+// MatchesOrParentMatches returns true if file matches any of the patterns
+// and isn't excluded by any of the subsequent patterns.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) MatchesOrParentMatches(file string) (bool, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, err
+	}
+	if pm.combined != nil {
+		matched, _ := pm.singlePassMatches(file)
+		return matched, nil
+	}
+	e := &evaluation{}
+	matched, _ := pm.matchesOrParentMatches(file, e)
+	if err := e.pathError(file); err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+// MatchOpts are options applying to a single call to MatchesWithOpts.
+type MatchOpts struct {
+	// IsDir reports that the path is a directory. Patterns ending with a
+	// path separator, such as "build/", only match directories and their
+	// contents.
+	IsDir bool
+	// FoldCase makes the patterns match regardless of case.
+	FoldCase bool
+	// Info holds the attributes of the path, if known, against which the
+	// predicates of rules are evaluated. A directory is also taken as such
+	// for the trailing separator of patterns.
+	Info fs.FileInfo
+}
+
+// MatchesWithOpts is like MatchesOrParentMatches, but applies opts to the
+// call, so that a single PatternMatcher can serve different callers.
+//
+// Unlike MatchesOrParentMatches, which can't tell whether a path is a
+// directory, it honors the trailing separator of patterns: with the zero
+// value of MatchOpts, "build/" doesn't match a file named "build".
+func (pm *PatternMatcher) MatchesWithOpts(file string, opts MatchOpts) (bool, error) {
+	return pm.matchesWalked(file, opts, nil)
+}
+
+// matchesWalked is MatchesWithOpts for a path whose parent dirs have the
+// attributes parentInfos, as known to the walkers, so that the predicates
+// of rules matching them also match the path.
+func (pm *PatternMatcher) matchesWalked(file string, opts MatchOpts, parentInfos []fs.FileInfo) (bool, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, err
+	}
+	e := &evaluation{opts: &opts, parentInfos: parentInfos}
+	matched, _ := pm.matchesOrParentMatches(file, e)
+	if err := e.pathError(file); err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+// MatchesViaParent is like MatchesWithOpts, but also reports whether the
+// result was decided by a pattern matching a parent directory of file
+// rather than file itself, so that an archiver can tell a directory
+// matched by "build/" from the paths beneath it. viaParent is false if no
+// pattern matches.
+func (pm *PatternMatcher) MatchesViaParent(file string, opts MatchOpts) (matched, viaParent bool, err error) {
+	matched, _, viaParent, err = pm.matchesVia(file, opts)
+	return matched, viaParent, err
+}
+
+// matchesVia returns the result of MatchesViaParent along with the pattern
+// deciding it, or nil if no pattern matches.
+func (pm *PatternMatcher) matchesVia(file string, opts MatchOpts) (matched bool, pattern *Pattern, viaParent bool, err error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, nil, false, err
+	}
+	e := &evaluation{opts: &opts, needPattern: true}
+	matched, pattern = pm.matchesOrParentMatches(file, e)
+	if pattern != nil {
+		file = pm.opts.style.fromSlash(pm.opts.style.clean(file))
+		if opts.FoldCase {
+			file = foldString(file)
+		}
+		viaParent = !e.match(pattern, file, opts.IsDir)
+	}
+	if err := e.pathError(file); err != nil {
+		return false, nil, false, err
+	}
+	return matched, pattern, viaParent, nil
+}
+
+// MatchesWithLabels is like MatchesOrParentMatches, but also returns the
+// labels of the pattern deciding the result, as set by its Source. The
+// labels are nil if no pattern matches, or if the deciding pattern has none.
+// The returned map must not be modified.
+func (pm *PatternMatcher) MatchesWithLabels(file string) (bool, map[string]string, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, nil, err
+	}
+	var (
+		matched bool
+		pattern *Pattern
+	)
+	if pm.combined != nil {
+		var i int
+		if matched, i = pm.singlePassMatches(file); i != -1 {
+			pattern = pm.patterns[i]
+		}
+	} else {
+		e := &evaluation{needPattern: true}
+		matched, pattern = pm.matchesOrParentMatches(file, e)
+		if err := e.pathError(file); err != nil {
+			return false, nil, err
+		}
+	}
+	if pattern == nil {
+		return matched, nil, nil
+	}
+	return matched, pattern.labels, nil
+}
+
+// foldRegexp returns the case-insensitive version of the regexp of pattern,
+// if it has one.
+func (pm *PatternMatcher) foldRegexp(pattern *Pattern) *regexp.Regexp {
+	if pattern.Regexp == nil {
+		return nil
+	}
+	pm.initFold()
+	return pm.foldRegexps[pattern]
+}
+
+// foldedLiteral returns the cleaned pattern of pattern folded with
+// foldString.
+func (pm *PatternMatcher) foldedLiteral(pattern *Pattern) string {
+	pm.initFold()
+	if folded, ok := pm.foldedLiterals[pattern]; ok {
+		return folded
+	}
+	// The pattern was modified through Clone, or isn't one of pm.
+	return foldString(pattern.CleanedPattern)
+}
+
+// initFold folds the patterns of pm the first time they are needed, so that
+// matching regardless of case only folds the paths.
+func (pm *PatternMatcher) initFold() {
+	pm.foldOnce.Do(func() {
+		pm.foldRegexps = make(map[*Pattern]*regexp.Regexp)
+		pm.foldedLiterals = make(map[*Pattern]string, len(pm.patterns))
+		for _, p := range pm.patterns {
+			if p.bytewise {
+				pm.foldRegexps[p] = p.foldBytesRegexp()
+			} else if p.Regexp != nil {
+				pm.foldRegexps[p] = regexp.MustCompile("(?i)" + p.Regexp.String())
+			}
+			pm.foldedLiterals[p] = foldString(p.CleanedPattern)
+		}
+	})
+}
+
+// MatchesUsingParentResults returns true if file matches any of the patterns
+// and isn't excluded by any of the subsequent patterns. See the
+// MatchesUsingParentResults function for a description of parentMatched.
+//
+// The positional evaluation of parentMatched can't express
+// WithResolution(MostSpecificWins), WithGitReinclusionRules or the
+// predicates of rules, so pm is then evaluated as MatchesWithParentResults
+// does, which returns the same results as MatchesOrParentMatches. The
+// results then have the meaning, and with WithGitReinclusionRules the
+// length, described for ParentCache, and must only be passed back to pm.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) MatchesUsingParentResults(file string, parentMatched []bool) (bool, []bool, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, nil, err
+	}
+	if o := pm.opts; o.resolution == MostSpecificWins || o.gitReinclusion || pm.predicates {
+		if len(parentMatched) == 0 {
+			parentMatched = nil
+		}
+		return pm.matchesWithParentInfo(o.style.clean(o.style.fromSlash(file)), false, parentMatched)
+	}
+	return matchesUsingParentResults(pm.patterns, file, parentMatched, pm.opts)
+}
+
+// checkPath validates an input path according to the options.
+func (o *options) checkPath(file string) error {
+	if o.controlChars == ControlCharReject && hasControlChar(file) {
+		return &PathError{Path: file, Err: ErrControlCharacter}
+	}
+	if !o.strictPaths {
+		return nil
+	}
+	if strings.HasPrefix(file, "/") || o.style.isAbs(o.style.fromSlash(file)) || o.style.volumeName(file) != "" {
+		return &PathError{Path: file, Err: ErrAbsolutePath}
+	}
+	isSep := func(r rune) bool { return r < utf8.RuneSelf && o.style.isSep(byte(r)) }
+	for _, elem := range strings.FieldsFunc(file, isSep) {
+		if elem == ".." {
+			return &PathError{Path: file, Err: ErrPathEscapesRoot}
+		}
+	}
+	return nil
+}
+
+func hasControlChar(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}
@@ -0,0 +1,772 @@
+package patternmatcher
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPatternMatcherMatches(t *testing.T) {
+	pm, err := New([]string{"docs", "!docs/README.md", "*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string]bool{
+		"docs":           true,
+		"docs/a/b":       true,
+		"docs/README.md": false,
+		"main.go":        true,
+		"pkg/main.go":    false,
+	} {
+		matched, err := pm.Matches(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", file, expected, matched)
+		}
+	}
+}
+
+func TestExclusions(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		count    int
+	}{
+		{nil, 0},
+		{[]string{"docs", "*.go"}, 0},
+		{[]string{"docs", "!docs/README.md"}, 1},
+		{[]string{"docs", "!docs/{a,b}.md", "!*.go"}, 3},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, WithBraceExpansion())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := pm.ExclusionCount(); n != tt.count {
+			t.Errorf("%q: expected %d exclusions, got %d", tt.patterns, tt.count, n)
+		}
+		if pm.Exclusions() != (tt.count != 0) {
+			t.Errorf("%q: expected Exclusions to be %v", tt.patterns, tt.count != 0)
+		}
+	}
+
+	// Disabled groups don't count.
+	pm, err := NewFromSources([]Source{{Group: "docs", Patterns: []string{"!docs/README.md"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm = pm.DisableGroups("docs"); pm.Exclusions() {
+		t.Error("expected no exclusions once the group is disabled")
+	}
+}
+
+func TestStrictPaths(t *testing.T) {
+	pm, err := New([]string{"docs"}, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{"docs/README.md", nil},
+		{"./docs", nil},
+		{"/docs", ErrAbsolutePath},
+		{"../docs", ErrPathEscapesRoot},
+		{"src/../docs", ErrPathEscapesRoot},
+		{"docs/..", ErrPathEscapesRoot},
+		{"docs/..foo", nil},
+	}
+	for _, tt := range tests {
+		_, err := pm.MatchesOrParentMatches(tt.path)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("path %q: expected error %v, got %v", tt.path, tt.err, err)
+		}
+		if tt.err != nil {
+			var pathErr *PathError
+			if !errors.As(err, &pathErr) || pathErr.Path != tt.path {
+				t.Errorf("path %q: expected a *PathError, got %#v", tt.path, err)
+			}
+		}
+		_, _, err = pm.MatchesUsingParentResults(tt.path, nil)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("path %q: expected error %v from MatchesUsingParentResults, got %v", tt.path, tt.err, err)
+		}
+	}
+
+	pm, err = New([]string{"docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := pm.MatchesOrParentMatches("src/../docs"); err != nil || !match {
+		t.Errorf("expected path to be cleaned without WithStrictPaths, got %v, %v", match, err)
+	}
+}
+
+func TestControlCharPaths(t *testing.T) {
+	pm, err := New([]string{"docs"}, WithControlCharPolicy(ControlCharReject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.MatchesOrParentMatches("docs/a\nb"); !errors.Is(err, ErrControlCharacter) {
+		t.Errorf("expected ErrControlCharacter, got %v", err)
+	}
+	if match, err := pm.MatchesOrParentMatches("docs/ab"); err != nil || !match {
+		t.Errorf("expected match, got %v, %v", match, err)
+	}
+}
+
+func TestRootPolicy(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		policy   RootPolicy
+		pass     bool
+	}{
+		{[]string{"**"}, RootNotMatched, false},
+		{[]string{"**"}, RootEvaluated, true},
+		{[]string{"."}, RootEvaluated, true},
+		{[]string{"**", "!."}, RootEvaluated, false},
+		{[]string{"docs"}, RootEvaluated, false},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, WithRootPolicy(tt.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, root := range []string{".", "./", ""} {
+			match, err := pm.MatchesOrParentMatches(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.pass {
+				t.Errorf("patterns %q, policy %v, path %q: expected %v, got %v", tt.patterns, tt.policy, root, tt.pass, match)
+			}
+		}
+	}
+}
+
+// TestLegacyParentSemantics checks that WithLegacyParentSemantics gives the
+// same results as the matches helper, which implements the old behavior.
+func TestLegacyParentSemantics(t *testing.T) {
+	tests := []multiPatternTestCase{
+		{[]string{"docs"}, "docs/README.md", true},
+		{[]string{"docs"}, "docs/sub/README.md", true},
+		{[]string{"*/sub"}, "docs/sub/README.md", true},
+		{[]string{"sub"}, "docs/sub/README.md", false},
+		{[]string{"**/sub"}, "a/b/sub/README.md", false},
+		{[]string{"a/b/c"}, "a/b", false},
+		{[]string{"**", "!docs"}, "docs/README.md", false},
+	}
+	for _, tt := range matchTests {
+		if tt.err == nil {
+			tests = append(tests, multiPatternTestCase{[]string{tt.pattern}, tt.s, tt.match})
+		}
+	}
+
+	for _, tt := range tests {
+		want, err := matches(tt.text, tt.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != tt.pass {
+			t.Fatalf("patterns %q, path %q: test case expects %v, but matches returns %v", tt.patterns, tt.text, tt.pass, want)
+		}
+
+		pm, err := New(tt.patterns, WithLegacyParentSemantics())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := pm.MatchesOrParentMatches(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("patterns %q, path %q: expected %v, got %v", tt.patterns, tt.text, want, got)
+		}
+	}
+
+	pm, err := New([]string{"**/sub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesOrParentMatches("a/b/sub/README.md"); !match {
+		t.Errorf("expected full semantics to match any parent directory")
+	}
+}
+
+func TestGitReinclusionRules(t *testing.T) {
+	tests := []struct {
+		multiPatternTestCase
+		dockerPass bool
+	}{
+		{multiPatternTestCase{[]string{"docs", "!docs/README.md"}, "docs/README.md", true}, false},
+		{multiPatternTestCase{[]string{"docs/", "!docs/README.md"}, "docs/README.md", true}, false},
+		{multiPatternTestCase{[]string{"docs/*", "!docs/README.md"}, "docs/README.md", false}, false},
+		{multiPatternTestCase{[]string{"docs/**", "!docs/README.md"}, "docs/README.md", false}, false},
+		{multiPatternTestCase{[]string{"docs", "!docs"}, "docs/README.md", false}, false},
+		{multiPatternTestCase{[]string{"**", "!src", "!src/**"}, "src/main.go", false}, false},
+		{multiPatternTestCase{[]string{"**", "!src/**"}, "src/main.go", true}, false},
+		{multiPatternTestCase{[]string{"*.go"}, "src/main.go", false}, false},
+		{multiPatternTestCase{[]string{"src/*.go"}, "src/main.go", true}, true},
+	}
+	for _, tt := range tests {
+		for _, mode := range []struct {
+			opts []Option
+			pass bool
+		}{
+			{[]Option{WithGitReinclusionRules()}, tt.pass},
+			{nil, tt.dockerPass},
+		} {
+			pm, err := New(tt.patterns, mode.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			match, err := pm.MatchesOrParentMatches(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != mode.pass {
+				t.Errorf("patterns %q, path %q, %d options: expected %v, got %v", tt.patterns, tt.text, len(mode.opts), mode.pass, match)
+			}
+		}
+	}
+}
+
+func TestGitReinclusionRulesDefaultMatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		expected bool
+	}{
+		{[]string{"!docs/secret"}, "docs/secret", false},
+		{[]string{"!docs/secret"}, "docs/secret/a", true},
+		{[]string{"!docs/secret"}, "docs/README.md", true},
+		{[]string{"docs", "!docs/secret"}, "docs/secret", true},
+		{[]string{"!docs", "docs/a", "!docs/a/b"}, "docs/a/b", true},
+		{[]string{"!docs", "!docs/a/b"}, "docs/a/b", false},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, WithDefaultMatch(true), WithGitReinclusionRules())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range []*PatternMatcher{pm, pm.Invert()} {
+			matched, err := m.MatchesOrParentMatches(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := tt.expected != (m != pm); matched != expected {
+				t.Errorf("patterns %q, path %q, inverted %v: expected %v, got %v", tt.patterns, tt.path, m != pm, expected, matched)
+			}
+		}
+	}
+}
+
+func TestMostSpecificWins(t *testing.T) {
+	tests := []multiPatternTestCase{
+		{[]string{"!docs/README.md", "docs"}, "docs/README.md", false},
+		{[]string{"docs", "!docs/README.md"}, "docs/README.md", false},
+		{[]string{"!docs/*.md", "docs/README.md"}, "docs/README.md", true},
+		{[]string{"docs/READ*", "!docs/R*"}, "docs/README.md", true},
+		{[]string{"docs/*", "!docs/*"}, "docs/README.md", false},
+		{[]string{"!docs/*", "docs/*"}, "docs/README.md", true},
+		{[]string{"**/*.md", "!docs"}, "docs/README.md", true},
+		{[]string{"!**/*.md", "docs"}, "docs/README.md", false},
+		{[]string{"!src"}, "docs/README.md", false},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, WithResolution(MostSpecificWins))
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := pm.MatchesOrParentMatches(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("patterns %q, path %q: expected %v, got %v", tt.patterns, tt.text, tt.pass, match)
+		}
+	}
+}
+
+func TestDefaultMatch(t *testing.T) {
+	tests := []multiPatternTestCase{
+		{[]string{"!src", "!go.mod"}, "src/main.go", false},
+		{[]string{"!src", "!go.mod"}, "go.mod", false},
+		{[]string{"!src", "!go.mod"}, "README.md", true},
+		{[]string{"!src", "src/*_test.go"}, "src/main_test.go", true},
+		{[]string{}, "README.md", true},
+	}
+	for _, tt := range tests {
+		for _, opts := range [][]Option{
+			{WithDefaultMatch(true)},
+			{WithDefaultMatch(true), WithResolution(MostSpecificWins)},
+		} {
+			pm, err := New(tt.patterns, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			match, err := pm.MatchesOrParentMatches(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.pass {
+				t.Errorf("patterns %q, path %q, %d options: expected %v, got %v", tt.patterns, tt.text, len(opts), tt.pass, match)
+			}
+			match, _, err = pm.MatchesUsingParentResults(tt.text, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.pass {
+				t.Errorf("patterns %q, path %q, %d options: expected %v from MatchesUsingParentResults, got %v", tt.patterns, tt.text, len(opts), tt.pass, match)
+			}
+		}
+	}
+}
+
+func TestEmptyMatchesAll(t *testing.T) {
+	for _, patterns := range [][]string{nil, {}, {"", "  "}} {
+		pm, err := New(patterns, WithEmptyMatchesAll())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match, _ := pm.MatchesOrParentMatches("any/path"); !match {
+			t.Errorf("patterns %q: expected empty list to match everything", patterns)
+		}
+	}
+
+	pm, err := New([]string{"docs"}, WithEmptyMatchesAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesOrParentMatches("any/path"); match {
+		t.Errorf("expected non-empty list to only match its patterns")
+	}
+
+	pm, err = New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesOrParentMatches("any/path"); match {
+		t.Errorf("expected empty list not to match by default")
+	}
+}
+
+func TestWith(t *testing.T) {
+	base, err := New([]string{"**", "!src"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := base.With("src/*_test.go", "", "!src/testdata_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.patterns[0] != base.patterns[0] || derived.patterns[1] != base.patterns[1] {
+		t.Errorf("expected base patterns to be reused")
+	}
+
+	tests := []struct {
+		path          string
+		base, derived bool
+	}{
+		{"README.md", true, true},
+		{"src/main.go", false, false},
+		{"src/main_test.go", false, true},
+		{"src/testdata_test.go", false, false},
+	}
+	for _, tt := range tests {
+		if match, _ := base.MatchesOrParentMatches(tt.path); match != tt.base {
+			t.Errorf("path %q: expected base matcher to return %v, got %v", tt.path, tt.base, match)
+		}
+		if match, _ := derived.MatchesOrParentMatches(tt.path); match != tt.derived {
+			t.Errorf("path %q: expected derived matcher to return %v, got %v", tt.path, tt.derived, match)
+		}
+	}
+
+	if _, err := base.With("["); err == nil {
+		t.Errorf("expected error for malformed pattern")
+	}
+
+	empty, err := New(nil, WithEmptyMatchesAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err = empty.With("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := derived.MatchesOrParentMatches("src/main.go"); match {
+		t.Errorf("expected derived matcher to only match its patterns")
+	}
+}
+
+func TestWithAdditionalPatterns(t *testing.T) {
+	base := MustNew([]string{"vendor/", "*.log"}, WithSinglePassMatching()).DisableGroups("tests")
+	derived, err := base.WithAdditionalPatterns("!vendor/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.patterns[0] != base.patterns[0] || derived.opts != base.opts || !reflect.DeepEqual(derived.disabled, base.disabled) {
+		t.Errorf("expected the patterns and options of base to be reused")
+	}
+	if matched, _ := derived.MatchesWithOpts("vendor", MatchOpts{IsDir: true}); matched {
+		t.Errorf("expected vendor to be re-included")
+	}
+
+	clone := base.Clone()
+	if clone == base || clone.patterns[1] != base.patterns[1] || clone.combined == nil {
+		t.Errorf("expected a distinct matcher sharing the patterns of base")
+	}
+	if matched, _ := clone.MatchesOrParentMatches("a.log"); !matched {
+		t.Errorf("expected the clone to match a.log")
+	}
+}
+
+func TestMatchesWithOpts(t *testing.T) {
+	pm, err := New([]string{"build/", "*.LOG", "Vendor/**", "**/Testdata", "[A-C]*.md", "!Build/keep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		opts MatchOpts
+		pass bool
+	}{
+		{"build", MatchOpts{}, false},
+		{"build", MatchOpts{IsDir: true}, true},
+		{"build/output", MatchOpts{}, true},
+		{"BUILD", MatchOpts{IsDir: true}, false},
+		{"BUILD", MatchOpts{IsDir: true, FoldCase: true}, true},
+		{"build/keep", MatchOpts{}, true},
+		{"build/keep", MatchOpts{FoldCase: true}, false},
+		{"app.log", MatchOpts{}, false},
+		{"app.log", MatchOpts{FoldCase: true}, true},
+		{"vendor/lib/lib.go", MatchOpts{FoldCase: true}, true},
+		{"src/testdata/x", MatchOpts{FoldCase: true}, true},
+		{"src/testdata/x", MatchOpts{}, false},
+		{"about.md", MatchOpts{}, false},
+		{"about.md", MatchOpts{FoldCase: true}, true},
+	}
+	for _, tt := range tests {
+		match, err := pm.MatchesWithOpts(tt.path, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("path %q, opts %+v: expected %v, got %v", tt.path, tt.opts, tt.pass, match)
+		}
+	}
+
+	// MatchesOrParentMatches can't tell directories from files.
+	if match, _ := pm.MatchesOrParentMatches("build"); !match {
+		t.Errorf("expected MatchesOrParentMatches to ignore trailing separators")
+	}
+}
+
+func TestTrailingGlobstar(t *testing.T) {
+	tests := []struct {
+		mode TrailingGlobstar
+		path string
+		pass bool
+	}{
+		{GlobstarContents, "dir", false},
+		{GlobstarContents, "dir/a", true},
+		{GlobstarContents, "dir/a/b", true},
+		{GlobstarContents, "src/gen", false},
+		{GlobstarContents, "src/gen/a", true},
+		{GlobstarDirAndContents, "dir", true},
+		{GlobstarDirAndContents, "dir/a", true},
+		{GlobstarDirAndContents, "dirx", false},
+		{GlobstarDirAndContents, "src/gen", true},
+		{GlobstarDirAndContents, "src/gen/a", true},
+		{GlobstarDirAndContents, "src/gen/keep", false},
+	}
+	for _, tt := range tests {
+		pm, err := New([]string{"dir/**", "src/*/**/", "!src/gen/keep/**"}, WithTrailingGlobstar(tt.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("mode %v, path %q: expected %v, got %v", tt.mode, tt.path, tt.pass, match)
+		}
+	}
+}
+
+func TestMaxGlobstarDepth(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		pass    bool
+	}{
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/b", true},
+		{"a/**/b", "a/x/y/b", false},
+		{"**/b", "b", true},
+		{"**/b", "x/b", true},
+		{"**/b", "x/y/b", false},
+		{"**b", "xb", true},
+		{"**b", "x/yb", true},
+		{"**b", "x/y/b", false},
+		{"a/**/*.go", "a/x/m.go", true},
+		{"a/**/*.go", "a/x/y/m.go", false},
+		{"a/**", "a/x/y/z", true},
+	}
+	for _, tt := range tests {
+		pm, err := New([]string{tt.pattern}, WithMaxGlobstarDepth(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("pattern %q, path %q: expected %v, got %v", tt.pattern, tt.path, tt.pass, match)
+		}
+	}
+
+	pm, err := New([]string{"**", "!src/**/keep"}, WithMaxGlobstarDepth(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for dir, want := range map[string]bool{
+		"src":       true,
+		"src/a":     true,
+		"src/a/b":   false,
+		"vendor":    false,
+		"src/a/b/c": false,
+	} {
+		watch, err := pm.ShouldWatch(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if watch != want {
+			t.Errorf("ShouldWatch(%q): expected %v, got %v", dir, want, watch)
+		}
+	}
+}
+
+func TestMaxGlobstarDepthInvalid(t *testing.T) {
+	for _, depth := range []int{-1, 2000} {
+		if _, err := New([]string{"a/**/b"}, WithMaxGlobstarDepth(depth)); err == nil {
+			t.Errorf("depth %d: expected an error", depth)
+		}
+	}
+	pm, err := New([]string{"a/**/b"}, WithMaxGlobstarDepth(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := pm.MatchesOrParentMatches("a/x/y/b"); err != nil || !match {
+		t.Errorf("expected a/x/y/b to match, got %v, %v", match, err)
+	}
+}
+
+func TestMatchesViaParent(t *testing.T) {
+	pm, err := New([]string{"build/", "*.log", "!build/keep.log", "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path      string
+		isDir     bool
+		matched   bool
+		viaParent bool
+	}{
+		{"build", true, true, false},
+		{"build", false, false, false},
+		{"build/out.o", false, true, true},
+		{"build/keep.log", false, false, false},
+		{"app.log", false, true, false},
+		{"app.log/x", false, true, true},
+		{"docs/a/b", false, true, true},
+		{"src/main.go", false, false, false},
+	}
+	for _, tt := range tests {
+		matched, viaParent, err := pm.MatchesViaParent(tt.path, MatchOpts{IsDir: tt.isDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != tt.matched || viaParent != tt.viaParent {
+			t.Errorf("%q: expected %v, %v, got %v, %v", tt.path, tt.matched, tt.viaParent, matched, viaParent)
+		}
+	}
+}
+
+func TestLiteralBrackets(t *testing.T) {
+	pm, err := New([]string{"app/[id]/*.test.tsx", "[abc]", "!app/[id]/keep.test.tsx"}, WithLiteralBrackets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		pass bool
+	}{
+		{"app/[id]/page.test.tsx", true},
+		{"app/i/page.test.tsx", false},
+		{"app/[id]/keep.test.tsx", false},
+		{"[abc]", true},
+		{"a", false},
+		{"[abc]/x", true},
+	}
+	for _, tt := range tests {
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("path %q: expected %v, got %v", tt.path, tt.pass, match)
+		}
+	}
+}
+
+func TestEscapeChar(t *testing.T) {
+	tests := []struct {
+		pattern string
+		opts    []Option
+		path    string
+		pass    bool
+	}{
+		{"file`*", nil, "file*", true},
+		{"file`*", nil, "filex", false},
+		{"file`?", nil, "file?", true},
+		{"file`?", nil, "filex", false},
+		{"`[x]", nil, "[x]", true},
+		{"`[x]", nil, "x", false},
+		{"a``b", nil, "a`b", true},
+		{"x`y", nil, "xy", true},
+		{"dir/`*/*.go", nil, "dir/*/main.go", true},
+		{"dir/`*/*.go", nil, "dir/a/main.go", false},
+		{"`*[id]", []Option{WithLiteralBrackets()}, "*[id]", true},
+		{"`*[id]", []Option{WithLiteralBrackets()}, "x[id]", false},
+		{"`[id]", []Option{WithLiteralBrackets()}, "[id]", true},
+	}
+	for _, tt := range tests {
+		pm, err := New([]string{tt.pattern}, append(tt.opts, WithEscapeChar('`'))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("pattern %q, path %q: expected %v, got %v", tt.pattern, tt.path, tt.pass, match)
+		}
+	}
+
+	for _, c := range []rune{'*', '/', '['} {
+		if _, err := New([]string{"a"}, WithEscapeChar(c)); err == nil {
+			t.Errorf("expected an error with escape character %q", c)
+		}
+	}
+}
+
+func TestGitignoreSemantics(t *testing.T) {
+	pm, err := New([]string{"*.o", "build/", "/vendor", "docs/*.md", "logs", "!logs/keep.log"}, WithGitignoreSemantics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"main.o", false, true},
+		{"src/pkg/main.o", false, true},
+		{"build", true, true},
+		{"src/build", true, true},
+		{"src/build", false, false},
+		{"vendor", true, true},
+		{"src/vendor", true, false},
+		{"docs/a.md", false, true},
+		{"src/docs/a.md", false, false},
+		// A path can't be re-included if its parent dir is excluded.
+		{"logs/keep.log", false, true},
+	}
+	for _, tt := range tests {
+		matched, err := pm.MatchesWithOpts(tt.path, MatchOpts{IsDir: tt.isDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != tt.expected {
+			t.Errorf("%q (dir: %v): expected %v, got %v", tt.path, tt.isDir, tt.expected, matched)
+		}
+	}
+}
+
+func TestWithoutParentMatching(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithoutParentMatching()},
+		{WithoutParentMatching(), WithSinglePassMatching()},
+	} {
+		pm, err := New([]string{"docs", "src/**/*.go"}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, expected := range map[string]bool{
+			"docs":             true,
+			"docs/README.md":   false,
+			"src/main.go":      true,
+			"src/main.go/file": false,
+		} {
+			matched, err := pm.MatchesOrParentMatches(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != expected {
+				t.Errorf("%q: expected %v, got %v", path, expected, matched)
+			}
+			matched, _, err = pm.MatchesUsingParentResults(path, []bool{true, true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != expected {
+				t.Errorf("%q: expected %v from MatchesUsingParentResults, got %v", path, expected, matched)
+			}
+		}
+		if watch, err := pm.ShouldWatch("docs"); err != nil || !watch {
+			t.Errorf("expected docs to be watched, got %v, %v", watch, err)
+		}
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	pm, err := New([]string{"Docs/*.MD", "vendor", "!vendor/KEEP"}, WithCaseInsensitive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]bool{
+		"docs/readme.md": true,
+		"DOCS/README.md": true,
+		"Vendor/x":       true,
+		"VENDOR/keep":    false,
+		"src/docs/a.md":  false,
+	} {
+		matched, err := pm.MatchesOrParentMatches(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+}
+
+func TestWithSeparator(t *testing.T) {
+	pm, err := New([]string{`docs\*.md`}, WithSeparator('\\'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched, err := pm.MatchesOrParentMatches(`docs\README.md`); err != nil || !matched {
+		t.Errorf("expected a match, got %v, %v", matched, err)
+	}
+	if _, err := New([]string{"docs"}, WithSeparator(':')); err == nil {
+		t.Error("expected an error for an unsupported separator")
+	}
+}
@@ -0,0 +1,225 @@
+// Package mobycompat mirrors the exported API of the upstream
+// github.com/moby/patternmatcher package, implemented with this module, so
+// that projects can switch their imports to it without changing their code:
+//
+//	import patternmatcher "github.com/moby/patternmatcher/mobycompat"
+//
+// The underlying matcher and patterns are available through
+// PatternMatcher.Unwrap and Pattern.Unwrap, for adopting the newer APIs
+// incrementally.
+package mobycompat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+)
+
+// PatternMatcher allows checking paths against a list of patterns.
+type PatternMatcher struct {
+	pm       *patternmatcher.PatternMatcher
+	patterns []*Pattern
+}
+
+// New creates a new matcher object for specific patterns that can
+// be used later to match against patterns against paths
+func New(patterns []string) (*PatternMatcher, error) {
+	pm, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, err
+	}
+	compat := &PatternMatcher{pm: pm}
+	for _, p := range pm.Patterns() {
+		compat.patterns = append(compat.patterns, &Pattern{p: p})
+	}
+	return compat, nil
+}
+
+// Unwrap returns the PatternMatcher of this module pm is implemented with.
+func (pm *PatternMatcher) Unwrap() *patternmatcher.PatternMatcher {
+	return pm.pm
+}
+
+// Matches returns true if "file" matches any of the patterns
+// and isn't excluded by any of the subsequent patterns.
+//
+// The "file" argument should be a slash-delimited path.
+//
+// Matches is not safe to call concurrently.
+//
+// Deprecated: This implementation is buggy (it only checks a single parent dir
+// against the pattern) and will be removed soon. Use either
+// MatchesOrParentMatches or MatchesUsingParentResults instead.
+func (pm *PatternMatcher) Matches(file string) (bool, error) {
+	matched := false
+	file = filepath.FromSlash(file)
+	parentPath := filepath.Dir(file)
+	parentPathDirs := strings.Split(parentPath, string(os.PathSeparator))
+
+	for _, pattern := range pm.patterns {
+		// Skip evaluation if this is an inclusion and the filename
+		// already matched the pattern, or it's an exclusion and it has
+		// not matched the pattern yet.
+		if pattern.Exclusion() != matched {
+			continue
+		}
+
+		match, err := pattern.Match(file)
+		if err != nil {
+			return false, err
+		}
+
+		if !match && parentPath != "." {
+			// Check to see if the pattern matches one of our parent dirs.
+			if dirs := pattern.p.Dirs; len(dirs) <= len(parentPathDirs) {
+				match, _ = pattern.Match(strings.Join(parentPathDirs[:len(dirs)], string(os.PathSeparator)))
+			}
+		}
+
+		if match {
+			matched = !pattern.Exclusion()
+		}
+	}
+	return matched, nil
+}
+
+// MatchesOrParentMatches returns true if "file" matches any of the patterns
+// and isn't excluded by any of the subsequent patterns.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) MatchesOrParentMatches(file string) (bool, error) {
+	return pm.pm.MatchesOrParentMatches(file)
+}
+
+// MatchesUsingParentResult returns true if "file" matches any of the patterns
+// and isn't excluded by any of the subsequent patterns. The functionality is
+// the same as Matches, but as an optimization, the caller keeps track of
+// whether the parent directory matched.
+//
+// The "file" argument should be a slash-delimited path.
+//
+// Deprecated: this function doesn't behave correctly in some cases (see
+// https://github.com/docker/buildx/issues/850).
+//
+// Use MatchesUsingParentResults instead.
+func (pm *PatternMatcher) MatchesUsingParentResult(file string, parentMatched bool) (bool, error) {
+	matched := parentMatched
+	file = filepath.FromSlash(file)
+
+	for _, pattern := range pm.patterns {
+		// Skip evaluation if this is an inclusion and the filename
+		// already matched the pattern, or it's an exclusion and it has
+		// not matched the pattern yet.
+		if pattern.Exclusion() != matched {
+			continue
+		}
+
+		match, err := pattern.Match(file)
+		if err != nil {
+			return false, err
+		}
+
+		if match {
+			matched = !pattern.Exclusion()
+		}
+	}
+	return matched, nil
+}
+
+// MatchInfo tracks the results of matching a path against the patterns of a
+// PatternMatcher, for passing them to the calls for its children.
+type MatchInfo struct {
+	parentMatched []bool
+}
+
+// MatchesUsingParentResults returns true if "file" matches any of the patterns
+// and isn't excluded by any of the subsequent patterns. The functionality is
+// the same as Matches, but as an optimization, the caller passes in
+// intermediate results from matching the parent directory.
+//
+// The "file" argument should be a slash-delimited path.
+//
+// parentMatchInfo can be set to a zero MatchInfo if there is no
+// parent directory.
+func (pm *PatternMatcher) MatchesUsingParentResults(file string, parentMatchInfo MatchInfo) (bool, MatchInfo, error) {
+	matched, parentMatched, err := pm.pm.MatchesUsingParentResults(file, parentMatchInfo.parentMatched)
+	if err != nil {
+		return false, MatchInfo{}, err
+	}
+	return matched, MatchInfo{parentMatched: parentMatched}, nil
+}
+
+// Exclusions returns true if any of the patterns define exclusions
+func (pm *PatternMatcher) Exclusions() bool {
+	return pm.pm.Exclusions()
+}
+
+// Patterns returns array of active patterns
+func (pm *PatternMatcher) Patterns() []*Pattern {
+	return pm.patterns
+}
+
+// Pattern defines a single regexp used to filter file paths.
+type Pattern struct {
+	p *patternmatcher.Pattern
+}
+
+// Unwrap returns the Pattern of this module p is implemented with.
+func (p *Pattern) Unwrap() *patternmatcher.Pattern {
+	return p.p
+}
+
+// String returns the pattern, with the leading "!" of exclusions.
+func (p *Pattern) String() string {
+	return p.p.String()
+}
+
+// Exclusion returns true if this pattern defines exclusion
+func (p *Pattern) Exclusion() bool {
+	return p.p.Exclusion
+}
+
+// Match returns true if path matches the pattern.
+func (p *Pattern) Match(path string) (bool, error) {
+	return p.p.MatchE(path)
+}
+
+// Matches returns true if file matches any of the patterns
+// and isn't excluded by any of the subsequent patterns.
+//
+// Deprecated: This implementation is buggy (it only checks a single parent
+// dir against the pattern) and will be removed soon. Use
+// MatchesOrParentMatches instead.
+func Matches(file string, patterns []string) (bool, error) {
+	pm, err := New(patterns)
+	if err != nil {
+		return false, err
+	}
+	file = filepath.Clean(file)
+
+	if file == "." {
+		// Don't let them exclude everything, kind of silly.
+		return false, nil
+	}
+
+	return pm.Matches(file)
+}
+
+// MatchesOrParentMatches returns true if file matches any of the patterns
+// and isn't excluded by any of the subsequent patterns.
+func MatchesOrParentMatches(file string, patterns []string) (bool, error) {
+	pm, err := New(patterns)
+	if err != nil {
+		return false, err
+	}
+	file = filepath.Clean(file)
+
+	if file == "." {
+		// Don't let them exclude everything, kind of silly.
+		return false, nil
+	}
+
+	return pm.MatchesOrParentMatches(file)
+}
@@ -0,0 +1,79 @@
+package mobycompat
+
+import (
+	"testing"
+)
+
+func TestPatternMatcher(t *testing.T) {
+	pm, err := New([]string{"docs", "!docs/README.md", "**/c", "*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pm.Exclusions() {
+		t.Error("expected exclusions")
+	}
+	if n := len(pm.Patterns()); n != 4 {
+		t.Fatalf("expected 4 patterns, got %d", n)
+	}
+	if p := pm.Patterns()[1]; !p.Exclusion() || p.String() != "!docs/README.md" {
+		t.Errorf("unexpected pattern %q", p)
+	}
+
+	tests := []struct {
+		file          string
+		matches       bool
+		parentMatches bool
+	}{
+		{"docs", true, true},
+		{"docs/README.md", false, false},
+		{"docs/a/b", true, true},
+		{"main.go", true, true},
+		{"pkg/main.go", false, false},
+		// Matches only checks the parent dir with as many elements as the
+		// pattern.
+		{"a/b/c/d", false, true},
+	}
+	for _, tt := range tests {
+		//nolint:staticcheck // Matches is tested for compatibility.
+		if matched, err := pm.Matches(tt.file); err != nil || matched != tt.matches {
+			t.Errorf("Matches(%q) = %v, %v, want %v", tt.file, matched, err, tt.matches)
+		}
+		if matched, err := pm.MatchesOrParentMatches(tt.file); err != nil || matched != tt.parentMatches {
+			t.Errorf("MatchesOrParentMatches(%q) = %v, %v, want %v", tt.file, matched, err, tt.parentMatches)
+		}
+	}
+}
+
+func TestMatchesUsingParentResults(t *testing.T) {
+	pm, err := New([]string{"docs", "!docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched, info, err := pm.MatchesUsingParentResults("docs", MatchInfo{})
+	if err != nil || !matched {
+		t.Fatalf("expected docs to match, got %v, %v", matched, err)
+	}
+	if matched, _, err := pm.MatchesUsingParentResults("docs/a", info); err != nil || !matched {
+		t.Errorf("expected docs/a to match, got %v, %v", matched, err)
+	}
+	if matched, _, err := pm.MatchesUsingParentResults("docs/README.md", info); err != nil || matched {
+		t.Errorf("expected docs/README.md not to match, got %v, %v", matched, err)
+	}
+
+	//nolint:staticcheck // MatchesUsingParentResult is tested for compatibility.
+	if matched, err := pm.MatchesUsingParentResult("docs/a", true); err != nil || !matched {
+		t.Errorf("expected docs/a to match, got %v, %v", matched, err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	if matched, err := MatchesOrParentMatches("docs/a", []string{"docs"}); err != nil || !matched {
+		t.Errorf("expected docs/a to match, got %v, %v", matched, err)
+	}
+	if matched, err := MatchesOrParentMatches(".", []string{"*"}); err != nil || matched {
+		t.Errorf("expected . not to match, got %v, %v", matched, err)
+	}
+	if _, err := Matches("a", []string{"["}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
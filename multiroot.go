@@ -0,0 +1,114 @@
+package patternmatcher
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// MultiRoot matches the paths of several roots, such as the contexts of
+// several builds or the packages of a workspace, each with its own
+// PatternMatcher, and routes absolute paths to the matcher of the root
+// containing them. Roots may be nested, in which case the innermost one
+// applies. Paths follow the PathStyle of the matchers, which must be the
+// same.
+//
+// A MultiRoot is immutable, and safe for concurrent use if its matchers
+// are.
+type MultiRoot struct {
+	// roots are sorted by decreasing length, so that the first root
+	// containing a path is the innermost one.
+	roots []multiRootEntry
+	style PathStyle
+}
+
+type multiRootEntry struct {
+	dir string
+	pm  *PatternMatcher
+}
+
+// NewMultiRoot returns a MultiRoot applying the matcher of each root, keyed
+// by its absolute path, to the paths beneath it.
+func NewMultiRoot(roots map[string]*PatternMatcher) (*MultiRoot, error) {
+	m := &MultiRoot{}
+	first := true
+	for _, pm := range roots {
+		if style := pm.opts.style.resolve(); first {
+			m.style, first = style, false
+		} else if style != m.style {
+			return nil, errors.New("matchers of different path styles")
+		}
+	}
+	seen := make(map[string]bool, len(roots))
+	for dir, pm := range roots {
+		if !m.style.isAbs(dir) {
+			return nil, &PathError{Path: dir, Err: ErrNotAbsolute}
+		}
+		dir = m.style.clean(dir)
+		if seen[dir] {
+			return nil, &PathError{Path: dir, Err: errors.New("duplicate root")}
+		}
+		seen[dir] = true
+		m.roots = append(m.roots, multiRootEntry{dir: dir, pm: pm})
+	}
+	sort.Slice(m.roots, func(i, j int) bool {
+		a, b := m.roots[i].dir, m.roots[j].dir
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return m, nil
+}
+
+// Root returns the innermost root containing the absolute path abs, its
+// matcher, and the path of abs relative to it, using the separator of the
+// style of the matchers. It fails with ErrNotAbsolute or ErrNoRoot.
+func (m *MultiRoot) Root(abs string) (root string, pm *PatternMatcher, rel string, err error) {
+	if !m.style.isAbs(abs) {
+		return "", nil, "", &PathError{Path: abs, Err: ErrNotAbsolute}
+	}
+	abs = m.style.clean(abs)
+	for _, r := range m.roots {
+		if rel, ok := cutDir(abs, r.dir, m.style.sep()); ok {
+			return r.dir, r.pm, rel, nil
+		}
+	}
+	return "", nil, "", &PathError{Path: abs, Err: ErrNoRoot}
+}
+
+// MatchesOrParentMatches reports whether the matcher of the root containing
+// the absolute path abs matches it, relative to the root.
+func (m *MultiRoot) MatchesOrParentMatches(abs string) (bool, error) {
+	_, pm, rel, err := m.Root(abs)
+	if err != nil {
+		return false, err
+	}
+	return pm.MatchesOrParentMatches(rel)
+}
+
+// MatchesWithOpts is like MatchesOrParentMatches, but applies opts to the
+// call.
+func (m *MultiRoot) MatchesWithOpts(abs string, opts MatchOpts) (bool, error) {
+	_, pm, rel, err := m.Root(abs)
+	if err != nil {
+		return false, err
+	}
+	return pm.MatchesWithOpts(rel, opts)
+}
+
+// cutDir returns the cleaned path p relative to the cleaned directory dir,
+// both separated by sep, or false if p isn't dir or beneath it.
+func cutDir(p, dir string, sep byte) (string, bool) {
+	if p == dir {
+		return ".", true
+	}
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(sep)) {
+		prefix += string(sep)
+	}
+	if !strings.HasPrefix(p, prefix) {
+		return "", false
+	}
+	return p[len(prefix):], true
+}
@@ -0,0 +1,63 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiRoot(t *testing.T) {
+	base := t.TempDir()
+	web := filepath.Join(base, "web")
+	api := filepath.Join(base, "services", "api")
+	m, err := NewMultiRoot(map[string]*PatternMatcher{
+		base: MustNew([]string{"services/*/tmp", "*.log"}),
+		web:  MustNew([]string{"node_modules", "dist"}),
+		api:  MustNew([]string{"*.log", "!keep.log"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		root     string
+		expected bool
+	}{
+		{"web/node_modules/x.js", web, true},
+		{"web/src/app.js", web, false},
+		{"debug.log", base, true},
+		{"web/debug.log", web, false},
+		{"services/api/keep.log", api, false},
+		{"services/api/other.log", api, true},
+		{"services/db/tmp/x", base, true},
+		{"services/api/tmp/x", api, false},
+		{"services/apiv2/dist", base, false},
+	}
+	for _, tt := range tests {
+		abs := filepath.Join(base, filepath.FromSlash(tt.path))
+		root, _, _, err := m.Root(abs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != tt.root {
+			t.Errorf("%q: expected root %q, got %q", tt.path, tt.root, root)
+		}
+		matched, err := m.MatchesOrParentMatches(abs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.path, tt.expected, matched)
+		}
+	}
+
+	if _, err := m.MatchesOrParentMatches(filepath.Dir(base)); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("expected %v, got %v", ErrNoRoot, err)
+	}
+	if _, err := m.MatchesOrParentMatches("web/dist"); !errors.Is(err, ErrNotAbsolute) {
+		t.Errorf("expected %v, got %v", ErrNotAbsolute, err)
+	}
+	if _, err := NewMultiRoot(map[string]*PatternMatcher{"web": MustNew(nil)}); !errors.Is(err, ErrNotAbsolute) {
+		t.Errorf("expected %v, got %v", ErrNotAbsolute, err)
+	}
+}
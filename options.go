@@ -0,0 +1,393 @@
+package patternmatcher
+
+// Option configures how patterns are compiled and evaluated.
+type Option func(*options)
+
+type options struct {
+	parentRefs      ParentRefPolicy
+	strictPaths     bool
+	controlChars    ControlCharPolicy
+	root            RootPolicy
+	legacyParents   bool
+	gitReinclusion  bool
+	resolution      Resolution
+	defaultMatch    bool
+	emptyMatchesAll bool
+	singlePass      bool
+	skipDirs        map[string]bool
+	markers         []string
+	instrumentation Instrumentation
+	braces          bool
+	limits          Limits
+	budget          int
+	globstar        TrailingGlobstar
+	globstarDepth   int
+	wildcards       WildcardUnit
+	literalBrackets bool
+	escapeChar      rune
+	cleanPolicy     CleanPolicy
+	warn            func(error)
+	style           PathStyle
+	badSeparator    rune
+	foldCase        bool
+	gitignore       bool
+	noParents       bool
+	inverted        bool
+}
+
+// defaultOptions are used by the functions operating on a list of patterns.
+var defaultOptions = &options{}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ParentRefPolicy controls how patterns that refer to a location above the
+// root, such as "../secrets/**", are handled.
+type ParentRefPolicy int
+
+const (
+	// ParentRefAllow leaves patterns referring to a parent of the root
+	// unchanged. This is the default.
+	ParentRefAllow ParentRefPolicy = iota
+	// ParentRefReject rejects patterns referring to a parent of the root
+	// with an error wrapping ErrPatternEscapesRoot.
+	ParentRefReject
+	// ParentRefAnchor removes leading ".." elements, anchoring the pattern
+	// to the root.
+	ParentRefAnchor
+)
+
+// WithParentRefPolicy sets the policy applied to patterns that, once
+// cleaned, still start with a ".." element.
+func WithParentRefPolicy(policy ParentRefPolicy) Option {
+	return func(o *options) {
+		o.parentRefs = policy
+	}
+}
+
+// WithStrictPaths makes a PatternMatcher reject input paths that are
+// absolute or contain ".." elements, instead of cleaning them. The error
+// returned is a *PathError wrapping ErrAbsolutePath or ErrPathEscapesRoot.
+//
+// This is intended for matching paths supplied by untrusted clients, which
+// should always be relative to the root the patterns apply to.
+func WithStrictPaths() Option {
+	return func(o *options) {
+		o.strictPaths = true
+	}
+}
+
+// ControlCharPolicy controls how patterns and paths containing control
+// characters, such as newlines or NUL bytes, are handled.
+type ControlCharPolicy int
+
+const (
+	// ControlCharAllow passes control characters through unexamined. This is
+	// the default.
+	ControlCharAllow ControlCharPolicy = iota
+	// ControlCharEscape accepts control characters, but escapes them when a
+	// pattern is formatted for display by Pattern.String.
+	ControlCharEscape
+	// ControlCharReject rejects patterns and paths containing control
+	// characters with an error wrapping ErrControlCharacter.
+	ControlCharReject
+)
+
+// WithControlCharPolicy sets the policy applied to patterns and paths
+// containing control characters.
+func WithControlCharPolicy(policy ControlCharPolicy) Option {
+	return func(o *options) {
+		o.controlChars = policy
+	}
+}
+
+// CleanPolicy controls how patterns altered by cleaning, such as "./foo",
+// "a//b" or "dir/", are handled.
+type CleanPolicy int
+
+const (
+	// CleanAllow cleans patterns silently. This is the default.
+	CleanAllow CleanPolicy = iota
+	// CleanWarn cleans patterns, but reports those altered to the function
+	// set with WithWarnings, with a *PatternError wrapping
+	// ErrPatternCleaned.
+	CleanWarn
+	// CleanReject rejects patterns altered by cleaning with a *PatternError
+	// wrapping ErrPatternCleaned.
+	CleanReject
+)
+
+// WithCleanPolicy sets the policy applied to patterns altered by cleaning,
+// whose rewrite is a common source of confusion: "!./foo" isn't cleaned
+// into "!foo", for instance.
+func WithCleanPolicy(policy CleanPolicy) Option {
+	return func(o *options) {
+		o.cleanPolicy = policy
+	}
+}
+
+// WithWarnings sets a function called with the warnings about patterns
+// which are accepted, such as those of the CleanWarn policy. It is called
+// while the patterns are compiled.
+func WithWarnings(fn func(error)) Option {
+	return func(o *options) {
+		o.warn = fn
+	}
+}
+
+// RootPolicy controls how the root of the context, ".", is matched.
+type RootPolicy int
+
+const (
+	// RootNotMatched reports the root as never matching, so the patterns
+	// can't exclude everything. This is the default.
+	RootNotMatched RootPolicy = iota
+	// RootEvaluated matches the root against the patterns like any other
+	// path. Note that wildcard patterns such as "*" or "**" match it.
+	RootEvaluated
+)
+
+// WithRootPolicy sets the policy applied when matching the root of the
+// context.
+func WithRootPolicy(policy RootPolicy) Option {
+	return func(o *options) {
+		o.root = policy
+	}
+}
+
+// WithLegacyParentSemantics makes MatchesOrParentMatches replicate the
+// behavior of the deprecated Matches function of the fileutils package: a
+// pattern is only checked against the single parent directory having as many
+// path elements as the pattern, rather than against every parent directory.
+//
+// This is intended for comparing results while migrating from that function,
+// and doesn't affect MatchesUsingParentResults.
+func WithLegacyParentSemantics() Option {
+	return func(o *options) {
+		o.legacyParents = true
+	}
+}
+
+// WithGitReinclusionRules makes MatchesOrParentMatches follow the rule of
+// gitignore that a path can't be re-included by an exclusion pattern if one
+// of its parent directories is matched. For example, with the patterns
+// "docs" and "!docs/README.md", "docs/README.md" matches, since "docs" does.
+//
+// It takes precedence over WithLegacyParentSemantics.
+func WithGitReinclusionRules() Option {
+	return func(o *options) {
+		o.gitReinclusion = true
+	}
+}
+
+// WithGitignoreSemantics interprets patterns as gitignore does: a pattern
+// without a separator, other than a trailing one, matches at any depth as
+// if it started with "**/", a leading separator anchors a pattern to the
+// root and is removed, and WithGitReinclusionRules applies. Comments and
+// the escapes of gitignore files are handled by readers such as
+// ignorefile.ReadAll.
+func WithGitignoreSemantics() Option {
+	return func(o *options) {
+		o.gitignore = true
+		o.gitReinclusion = true
+	}
+}
+
+// WithoutParentMatching makes patterns only match the paths themselves, not
+// the paths beneath those they match: "docs" matches "docs" but not
+// "docs/README.md". This suits matching lists of paths rather than trees.
+// The walkers descend into every matched directory, since its contents may
+// not be matched.
+func WithoutParentMatching() Option {
+	return func(o *options) {
+		o.noParents = true
+	}
+}
+
+// WithCaseInsensitive makes all the patterns match regardless of case, as
+// MatchOpts.FoldCase does for a single call.
+func WithCaseInsensitive() Option {
+	return func(o *options) {
+		o.foldCase = true
+	}
+}
+
+// WithSeparator sets the separator of patterns and paths: '/' is the same as
+// WithPathStyle(UnixPaths), and '\\' as WithPathStyle(WindowsPaths). Other
+// separators aren't supported, and make the patterns fail to compile.
+func WithSeparator(sep rune) Option {
+	return func(o *options) {
+		o.badSeparator = 0
+		switch sep {
+		case '/':
+			o.style = UnixPaths
+		case '\\':
+			o.style = WindowsPaths
+		default:
+			o.badSeparator = sep
+		}
+	}
+}
+
+// Resolution defines which of the patterns matching a path decides whether
+// the path matches.
+type Resolution int
+
+const (
+	// LastMatchWins makes the last matching pattern decide. This is the
+	// default.
+	LastMatchWins Resolution = iota
+	// MostSpecificWins makes the most specific matching pattern decide: the
+	// one with the most path elements, then the one with the longest literal
+	// prefix. Among equally specific patterns, the last one decides.
+	//
+	// Patterns from a Source with a higher Precedence always win over those
+	// from a Source with a lower one, regardless of their specificity.
+	MostSpecificWins
+)
+
+// WithResolution sets the strategy used to decide between the patterns
+// matching a path.
+func WithResolution(resolution Resolution) Option {
+	return func(o *options) {
+		o.resolution = resolution
+	}
+}
+
+// WithDefaultMatch sets the result for paths that none of the patterns
+// match. By default, such paths don't match.
+//
+// Setting it to true allows expressing an allowlist without starting it
+// with "**": with the patterns "!src" and "!go.mod", every path other than
+// "go.mod" and the contents of "src" matches.
+func WithDefaultMatch(matched bool) Option {
+	return func(o *options) {
+		o.defaultMatch = matched
+	}
+}
+
+// WithEmptyMatchesAll makes a PatternMatcher created without any pattern
+// match every path. Blank patterns are ignored, so a list containing only
+// blank lines is empty as well.
+//
+// This suits callers using the patterns as a list of paths to include, for
+// which an absent or empty list means including everything.
+func WithEmptyMatchesAll() Option {
+	return func(o *options) {
+		o.emptyMatchesAll = true
+	}
+}
+
+// WithSinglePassMatching compiles the patterns of a PatternMatcher into a
+// single regular expression, so that MatchesOrParentMatches evaluates them
+// all in one pass instead of one pattern at a time. This is mostly useful for
+// long lists of patterns that are expensive to evaluate separately.
+//
+// It only applies with the LastMatchWins resolution, and is ignored when
+// WithLegacyParentSemantics, WithGitReinclusionRules, WithoutParentMatching
+// or WithInstrumentation is used, or when wildcards match bytes.
+func WithSinglePassMatching() Option {
+	return func(o *options) {
+		o.singlePass = true
+	}
+}
+
+// DefaultVCSDirs are the directories skipped by WithSkipVCSDirs when no
+// names are given.
+var DefaultVCSDirs = []string{".git", ".hg", ".svn"}
+
+// WithSkipVCSDirs makes the walkers of a PatternMatcher, such as WalkDir,
+// prune the directories with the given names at any depth, regardless of
+// the patterns. Without names, DefaultVCSDirs are pruned.
+//
+// It doesn't affect matching paths, so MatchesOrParentMatches still
+// evaluates paths within these directories against the patterns.
+func WithSkipVCSDirs(names ...string) Option {
+	if len(names) == 0 {
+		names = DefaultVCSDirs
+	}
+	return func(o *options) {
+		o.skipDirs = make(map[string]bool, len(names))
+		for _, name := range names {
+			o.skipDirs[name] = true
+		}
+	}
+}
+
+// WithExcludeIfPresent makes the walkers of a PatternMatcher, such as
+// WalkDir, prune the directories containing a file with one of the given
+// names, such as "CACHEDIR.TAG" or ".nobackup", regardless of the patterns,
+// as backup tools do. Only the presence of the file is checked, not its
+// contents. The root of a walk is never pruned.
+//
+// Like WithSkipVCSDirs, it doesn't affect matching paths.
+func WithExcludeIfPresent(markers ...string) Option {
+	return func(o *options) {
+		o.markers = append([]string(nil), markers...)
+	}
+}
+
+// WithBraceExpansion makes braces with alternatives expand into a pattern
+// per alternative, so that "*.{js,ts}" is equivalent to the patterns "*.js"
+// and "*.ts". Braces nest, and braces without a comma, such as "{a}", are
+// literal. Without this option, braces are always literal.
+//
+// As in shells, braces can also hold a numeric range, with an optional
+// step: "log.{1..3}" expands into "log.1", "log.2" and "log.3", and
+// "{01..31..2}" into the odd numbers padded to two digits. The number of
+// patterns a pattern expands into is limited by Limits.MaxBraceExpansion.
+func WithBraceExpansion() Option {
+	return func(o *options) {
+		o.braces = true
+	}
+}
+
+// WithLiteralBrackets disables character classes, so that brackets match
+// themselves: "[id]/page.tsx" matches the file of a directory named "[id]",
+// as found in the route folders of some web frameworks, without escaping
+// the brackets. Brackets are rewritten as classes matching them, so that
+// the pattern is reported as "[[]id]/page.tsx".
+func WithLiteralBrackets() Option {
+	return func(o *options) {
+		o.literalBrackets = true
+	}
+}
+
+// WithEscapeChar sets a character escaping the wildcard that follows it in
+// patterns, so that it matches itself: with WithEscapeChar('`'), "file`*"
+// only matches a file named "file*". The escape character escapes itself as
+// well, and is dropped before any other character.
+//
+// This allows escaping on Windows, where backslashes are separators and
+// can't escape. The character can't be a separator or a wildcard.
+func WithEscapeChar(c rune) Option {
+	return func(o *options) {
+		o.escapeChar = c
+	}
+}
+
+// TrailingGlobstar controls what a pattern ending with "/**" matches.
+type TrailingGlobstar int
+
+const (
+	// GlobstarContents makes "dir/**" match the contents of dir, but not dir
+	// itself, as in gitignore. This is the default.
+	GlobstarContents TrailingGlobstar = iota
+	// GlobstarDirAndContents makes "dir/**" match dir itself as well, like
+	// the pattern "dir".
+	GlobstarDirAndContents
+)
+
+// WithTrailingGlobstar sets what patterns ending with "/**" match, so that
+// dialects which don't distinguish a directory from its contents can be
+// reproduced.
+func WithTrailingGlobstar(mode TrailingGlobstar) Option {
+	return func(o *options) {
+		o.globstar = mode
+	}
+}
@@ -0,0 +1,120 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Order is the order of the paths returned by FilterPaths and
+// ListIncluded.
+type Order int
+
+const (
+	// InputOrder keeps the paths in the order they are given, or walked.
+	InputOrder Order = iota
+	// LexicalOrder sorts the paths bytewise, as sort.Strings does, so
+	// "a.txt" comes before "a/b".
+	LexicalOrder
+	// DepthFirstOrder sorts the paths element by element, so that the
+	// contents of a directory directly follow it, as in a walk: "a/b" comes
+	// before "a.txt".
+	DepthFirstOrder
+)
+
+// OutputOpts control the list of paths returned by FilterPaths and
+// ListIncluded, so that it can be made reproducible across platforms, such
+// as for hashes and golden files. Paths are always slash-separated.
+type OutputOpts struct {
+	Order Order
+	// Dedup removes the repeated paths, keeping the first one.
+	Dedup bool
+	// OmitDirs leaves out directories, keeping their contents.
+	OmitDirs bool
+}
+
+// FilterPaths returns the paths pm doesn't match, as arranged by opts.
+// Paths ending with a separator are directories, for patterns such as
+// "build/", and keep a trailing slash. Paths are compared once cleaned, so
+// "./a" and "a" are repeats of each other for Dedup.
+func (pm *PatternMatcher) FilterPaths(paths []string, opts OutputOpts) ([]string, error) {
+	st := pm.opts.style
+	var kept []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		isDir := p != "" && st.isSep(p[len(p)-1])
+		if isDir && opts.OmitDirs {
+			continue
+		}
+		matched, err := pm.MatchesWithOpts(p, MatchOpts{IsDir: isDir})
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			continue
+		}
+		p = st.toSlash(st.clean(st.fromSlash(p)))
+		if isDir && p != "." {
+			p += "/"
+		}
+		if opts.Dedup {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+		}
+		kept = append(kept, p)
+	}
+	sortPaths(kept, opts.Order)
+	return kept, nil
+}
+
+// ListIncluded walks the file tree rooted at root in fsys as WalkDir does,
+// and returns the paths pm doesn't match relative to root, as arranged by
+// opts, leaving out root itself. Walked paths can't repeat, so Dedup makes
+// no difference.
+func (pm *PatternMatcher) ListIncluded(fsys fs.FS, root string, opts OutputOpts) ([]string, error) {
+	var paths []string
+	err := pm.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(root, p)
+		if rel == "." || d.IsDir() && opts.OmitDirs {
+			return nil
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortPaths(paths, opts.Order)
+	return paths, nil
+}
+
+// sortPaths sorts the slash-separated paths in order.
+func sortPaths(paths []string, order Order) {
+	switch order {
+	case LexicalOrder:
+		sort.Strings(paths)
+	case DepthFirstOrder:
+		sort.SliceStable(paths, func(i, j int) bool {
+			return lessDepthFirst(strings.TrimSuffix(paths[i], "/"), strings.TrimSuffix(paths[j], "/"))
+		})
+	}
+}
+
+// lessDepthFirst reports whether the path a comes before b when comparing
+// them element by element.
+func lessDepthFirst(a, b string) bool {
+	for a != "" && b != "" {
+		ea, ra, _ := strings.Cut(a, "/")
+		eb, rb, _ := strings.Cut(b, "/")
+		if ea != eb {
+			return ea < eb
+		}
+		a, b = ra, rb
+	}
+	return a == "" && b != ""
+}
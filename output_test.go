@@ -0,0 +1,48 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterPaths(t *testing.T) {
+	pm, err := New([]string{"*.log", "build/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"a/b", "build/", "a.txt", "debug.log", "./a.txt", "build", "a/", "a.txt"}
+	tests := []struct {
+		opts OutputOpts
+		want []string
+	}{
+		{OutputOpts{}, []string{"a/b", "a.txt", "a.txt", "build", "a/", "a.txt"}},
+		{OutputOpts{Dedup: true}, []string{"a/b", "a.txt", "build", "a/"}},
+		{OutputOpts{Dedup: true, Order: LexicalOrder}, []string{"a.txt", "a/", "a/b", "build"}},
+		{OutputOpts{Dedup: true, Order: DepthFirstOrder}, []string{"a/", "a/b", "a.txt", "build"}},
+		{OutputOpts{Dedup: true, OmitDirs: true, Order: DepthFirstOrder}, []string{"a/b", "a.txt", "build"}},
+	}
+	for _, tt := range tests {
+		got, err := pm.FilterPaths(paths, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: expected %q, got %q", tt.opts, tt.want, got)
+		}
+	}
+}
+
+func TestListIncluded(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := pm.ListIncluded(walkFS, "ctx", OutputOpts{OmitDirs: true, Order: LexicalOrder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Dockerfile", "build/out", "docs/build", "node_modules/keep.js", "src/main.go", "src/pkg/lib.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
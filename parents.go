@@ -0,0 +1,191 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ParentCache stores the intermediate results of matching directories for
+// MatchesWithParentCache, keyed by the slash-separated path of the
+// directory. Implementations can be backed by the persisted results of a
+// previous run, so that an incremental tool resumes matching in the middle
+// of a tree without matching its ancestors again, as long as the patterns
+// are the same: the results hold a value per pattern, and one more with
+// WithGitReinclusionRules, and results of the wrong length make matching
+// fail.
+//
+// A ParentCache may be used by several goroutines at once if the walk using
+// it is concurrent.
+type ParentCache interface {
+	// Load returns the results stored for dir, or false if there are none.
+	Load(dir string) (matchInfo []bool, ok bool)
+	// Store stores the results of dir. The slice must not be modified.
+	Store(dir string, matchInfo []bool)
+}
+
+// ParentResults stores the intermediate results of matching directories,
+// keyed by their path, for MatchesWithParentResults. Unlike the positional
+// slices of MatchesUsingParentResults, the results don't need to be handed
+// back by the caller, so directories can be visited in any order, and by
+// several goroutines at once.
+//
+// The zero value is ready to use. A ParentResults must only be used with a
+// single PatternMatcher. It implements ParentCache.
+type ParentResults struct {
+	mu   sync.Mutex
+	dirs map[string][]bool
+}
+
+// Len returns the number of directories whose results are stored.
+func (r *ParentResults) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.dirs)
+}
+
+// Forget drops the results of dir and of the directories beneath it, once a
+// walk is done with them. The "dir" argument should be a slash-delimited
+// path.
+func (r *ParentResults) Forget(dir string) {
+	dir = path.Clean(filepath.ToSlash(dir))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for d := range r.dirs {
+		if d == dir || dir == "." || strings.HasPrefix(d, dir+"/") {
+			delete(r.dirs, d)
+		}
+	}
+}
+
+// Load returns the results stored for dir, or false if there are none.
+func (r *ParentResults) Load(dir string) ([]bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matchInfo, ok := r.dirs[dir]
+	return matchInfo, ok
+}
+
+// Store stores the results of dir.
+func (r *ParentResults) Store(dir string, matchInfo []bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dirs == nil {
+		r.dirs = make(map[string][]bool)
+	}
+	r.dirs[dir] = matchInfo
+}
+
+// MatchesWithParentResults is like MatchesUsingParentResults, but looks up
+// the results of the parent directory of file in results, rather than
+// taking them as an argument. If they aren't there, the parent directories
+// are matched as MatchesOrParentMatches does. If isDir is true, the results
+// for file are stored in turn for its children.
+//
+// It returns the same results as MatchesOrParentMatches whatever the
+// options of pm, such as WithLegacyParentSemantics, which
+// MatchesUsingParentResults ignores.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) MatchesWithParentResults(file string, isDir bool, results *ParentResults) (bool, error) {
+	return pm.MatchesWithParentCache(file, isDir, results)
+}
+
+// MatchesWithParentCache is like MatchesWithParentResults, but uses the
+// results of cache, which may have been seeded with those of a previous
+// run.
+//
+// The "file" argument should be a slash-delimited path.
+func (pm *PatternMatcher) MatchesWithParentCache(file string, isDir bool, cache ParentCache) (bool, error) {
+	if err := pm.opts.checkPath(file); err != nil {
+		return false, err
+	}
+	st := pm.opts.style
+	file = st.clean(st.fromSlash(file))
+	var parentInfo []bool
+	if parent := st.dir(file); parent != "." {
+		parentInfo, _ = cache.Load(st.toSlash(parent))
+	}
+	matched, matchInfo, err := pm.matchesWithParentInfo(file, isDir, parentInfo)
+	if err != nil {
+		return false, err
+	}
+	if isDir {
+		cache.Store(st.toSlash(file), matchInfo)
+	}
+	return matched, nil
+}
+
+// matchesWithParentInfo returns whether pm matches file, cleaned and using
+// the separator of pm, as matchesOrParentMatches does, given parentInfo,
+// the results of its parent directory, which are computed if nil. It also
+// returns the results of file for its children.
+//
+// The results hold whether each pattern matches the directory or one of
+// its parents. With git re-inclusion rules, they hold whether each pattern
+// matches the directory itself instead, followed by whether the directory
+// or one of its parents is matched for good, its contents being unable to
+// be re-included.
+func (pm *PatternMatcher) matchesWithParentInfo(file string, isDir bool, parentInfo []bool) (bool, []bool, error) {
+	o := pm.opts
+	n := len(pm.patterns)
+	if o.gitReinclusion {
+		n++
+	}
+	if parentInfo != nil && len(parentInfo) != n {
+		return false, nil, errors.New("wrong number of values in parentMatched")
+	}
+	if file == "." && o.root == RootNotMatched {
+		return false, make([]bool, n), nil
+	}
+	if o.noParents {
+		parentInfo = nil
+	} else if parent := o.style.dir(file); parentInfo == nil && parent != "." {
+		var err error
+		if _, parentInfo, err = pm.matchesWithParentInfo(parent, true, nil); err != nil {
+			return false, nil, err
+		}
+	}
+
+	e := &evaluation{pm: pm, file: file}
+	matchInfo := make([]bool, n)
+	best := -1
+	for i, pattern := range pm.patterns {
+		m := parentInfo != nil && parentInfo[i] && !o.gitReinclusion
+		if !m {
+			m = e.matchesOrParent(pattern, file, isDir, nil)
+		}
+		matchInfo[i] = m
+		if m && (best == -1 || o.resolution != MostSpecificWins || !pm.patterns[best].outranks(pattern)) {
+			best = i
+		}
+	}
+	if err := e.pathError(file); err != nil {
+		return false, nil, err
+	}
+
+	matched := o.defaultMatch
+	if best != -1 {
+		matched = !pm.patterns[best].Exclusion
+	}
+	if o.gitReinclusion {
+		// Whether a parent dir is matched by a pattern decides, as in
+		// matchesOrParentMatches.
+		locked := parentInfo != nil && parentInfo[n-1]
+		if locked {
+			matched = !o.inverted
+		}
+		matchInfo[n-1] = locked || best != -1 && matched != o.inverted
+	}
+	if o.legacyParents {
+		// Parent dirs are only matched against the patterns with as many
+		// elements, which the results can't tell.
+		matched, _ = pm.matchesOrParentMatches(file, e)
+		if err := e.pathError(file); err != nil {
+			return false, nil, err
+		}
+	}
+	return matched, matchInfo, nil
+}
@@ -0,0 +1,192 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+)
+
+func TestMatchesWithParentResults(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/build", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Directories are visited out of order, and some files before their
+	// parent directory.
+	paths := []struct {
+		path  string
+		isDir bool
+	}{
+		{"src/pkg/lib_test.go", false},
+		{"node_modules", true},
+		{"src", true},
+		{"node_modules/keep.js", false},
+		{"src/pkg", true},
+		{"node_modules/a", true},
+		{"src/pkg/lib.go", false},
+		{"node_modules/a/index.js", false},
+		{"docs/build", false},
+		{"build/out", false},
+	}
+	var results ParentResults
+	for _, p := range paths {
+		matched, err := pm.MatchesWithParentResults(p.path, p.isDir, &results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := pm.MatchesOrParentMatches(p.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != want {
+			t.Errorf("%q: expected %v, got %v", p.path, want, matched)
+		}
+	}
+	if results.Len() != 4 {
+		t.Errorf("expected 4 directories, got %d", results.Len())
+	}
+	results.Forget("node_modules")
+	if results.Len() != 2 {
+		t.Errorf("expected 2 directories after Forget, got %d", results.Len())
+	}
+}
+
+// mapCache is a ParentCache standing for the persisted results of a
+// previous run.
+type mapCache map[string][]bool
+
+func (c mapCache) Load(dir string) ([]bool, bool) {
+	matchInfo, ok := c[dir]
+	return matchInfo, ok
+}
+
+func (c mapCache) Store(dir string, matchInfo []bool) {
+	c[dir] = matchInfo
+}
+
+func TestMatchesWithParentCache(t *testing.T) {
+	patterns := []string{"node_modules", "!node_modules/keep.js", "**/build"}
+	pm, err := New(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := mapCache{}
+	for _, dir := range []string{"node_modules", "node_modules/a", "src"} {
+		if _, err := pm.MatchesWithParentCache(dir, true, previous); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := previous["node_modules/a"]; !ok || len(previous) != 3 {
+		t.Fatalf("expected slash-separated keys, got %v", previous)
+	}
+
+	// A new matcher resumes in the middle of the tree.
+	resumed, err := New(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]bool{
+		"node_modules/a/index.js": true,
+		"node_modules/keep.js":    false,
+		"src/main.go":             false,
+	} {
+		matched, err := resumed.MatchesWithParentCache(path, false, previous)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+
+	other, err := New([]string{"node_modules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.MatchesWithParentCache("node_modules/a/index.js", false, previous); err == nil {
+		t.Error("expected an error for results of other patterns")
+	}
+}
+
+// optionMatchers returns matchers whose options change how the results of
+// parent directories combine, for checking the APIs reusing them against
+// MatchesOrParentMatches.
+func optionMatchers(t *testing.T) map[string]*PatternMatcher {
+	t.Helper()
+	patterns := []string{"node_modules", "!node_modules/keep.js", "src", "!src/pkg", "src/pkg/*_test.go", "**/build"}
+	matchers := make(map[string]*PatternMatcher)
+	for name, opts := range map[string][]Option{
+		"default":          nil,
+		"MostSpecificWins": {WithResolution(MostSpecificWins)},
+		"GitReinclusion":   {WithGitReinclusionRules()},
+		"DefaultMatch":     {WithGitReinclusionRules(), WithDefaultMatch(true)},
+		"WithoutParents":   {WithoutParentMatching()},
+	} {
+		pm, err := New(patterns, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matchers[name] = pm
+		matchers[name+"/inverted"] = pm.Invert()
+	}
+	pm, err := NewFromRules([]Rule{
+		{Pattern: "**"},
+		{Pattern: "!src"},
+		{Pattern: "src/pkg", Predicates: []Predicate{IsDirectory()}},
+		{Pattern: "!node_modules/**", PathPredicates: []PathPredicate{DeeperThan(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchers["rules"] = pm
+	return matchers
+}
+
+func TestMatchesUsingParentResultsOptions(t *testing.T) {
+	for name, pm := range optionMatchers(t) {
+		results := make(map[string][]bool)
+		err := fs.WalkDir(walkFS, "ctx", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || p == "ctx" {
+				return err
+			}
+			rel := relPath("ctx", p)
+			matched, matchInfo, err := pm.MatchesUsingParentResults(rel, results[path.Dir(rel)])
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				results[rel] = matchInfo
+			}
+			if want, _ := pm.MatchesOrParentMatches(rel); matched != want {
+				t.Errorf("%s: %q: expected %v, got %v", name, rel, want, matched)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchesWithParentResultsOptions(t *testing.T) {
+	for name, pm := range optionMatchers(t) {
+		var results ParentResults
+		err := fs.WalkDir(walkFS, "ctx", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || p == "ctx" {
+				return err
+			}
+			rel := relPath("ctx", p)
+			matched, err := pm.MatchesWithParentResults(rel, d.IsDir(), &results)
+			if err != nil {
+				return err
+			}
+			if want, _ := pm.MatchesOrParentMatches(rel); matched != want {
+				t.Errorf("%s: %q: expected %v, got %v", name, rel, want, matched)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
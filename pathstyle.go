@@ -0,0 +1,219 @@
+package patternmatcher
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PathStyle is the flavor of paths patterns and matched paths follow: their
+// separator, whether backslashes escape characters in patterns, and how
+// they are cleaned.
+type PathStyle int
+
+const (
+	// HostPaths follows the OS the program runs on. This is the default.
+	HostPaths PathStyle = iota
+	// UnixPaths separates paths with slashes, and backslashes escape the
+	// next character of patterns.
+	UnixPaths
+	// WindowsPaths separates paths with backslashes, slashes being
+	// accepted as well, and backslashes of patterns are separators rather
+	// than escapes. Paths may start with a volume name, such as "C:".
+	WindowsPaths
+)
+
+// String returns the name of the style, such as "WindowsPaths".
+func (s PathStyle) String() string {
+	switch s {
+	case HostPaths:
+		return "HostPaths"
+	case UnixPaths:
+		return "UnixPaths"
+	case WindowsPaths:
+		return "WindowsPaths"
+	}
+	return "PathStyle(" + strconv.Itoa(int(s)) + ")"
+}
+
+// WithPathStyle compiles patterns and matches paths with the semantics of
+// style rather than those of the host, so that the Windows behavior of a
+// matcher can be tested on any OS, and the other way around. Paths are
+// still given slash-separated, or with the separator of style.
+//
+// The style applies to the patterns and to every method of the
+// PatternMatcher taking paths, such as Rebase, Prefix and EnterDir, and to
+// the paths they return. The walkers match the slash-separated paths of
+// fs.FS.
+func WithPathStyle(style PathStyle) Option {
+	return func(o *options) {
+		o.style = style
+	}
+}
+
+// PathStyle returns the path style of pm, as set with WithPathStyle,
+// HostPaths being resolved to UnixPaths or WindowsPaths, so that code
+// writing patterns for pm can escape them accordingly.
+func (pm *PatternMatcher) PathStyle() PathStyle {
+	return pm.opts.style.resolve()
+}
+
+// resolve returns UnixPaths or WindowsPaths, the style HostPaths stands for
+// being the one of the host.
+func (s PathStyle) resolve() PathStyle {
+	if s != HostPaths {
+		return s
+	}
+	if os.PathSeparator == '\\' {
+		return WindowsPaths
+	}
+	return UnixPaths
+}
+
+// native reports whether s is the style of the host, whose operations are
+// those of package filepath.
+func (s PathStyle) native() bool {
+	return s.resolve() == HostPaths.resolve()
+}
+
+// sep returns the separator of s.
+func (s PathStyle) sep() byte {
+	if s.resolve() == WindowsPaths {
+		return '\\'
+	}
+	return '/'
+}
+
+// isSep reports whether c separates the elements of paths of style s.
+func (s PathStyle) isSep(c byte) bool {
+	return c == '/' || c == s.sep()
+}
+
+// isSepRune is isSep for runes.
+func (s PathStyle) isSepRune(r rune) bool {
+	return r < utf8.RuneSelf && s.isSep(byte(r))
+}
+
+// escapes reports whether backslashes escape characters in patterns.
+func (s PathStyle) escapes() bool {
+	return s.resolve() == UnixPaths
+}
+
+// fromSlash returns p with its slashes replaced by the separator of s.
+func (s PathStyle) fromSlash(p string) string {
+	if s.sep() == '/' {
+		return p
+	}
+	return strings.ReplaceAll(p, "/", `\`)
+}
+
+// toSlash returns p with the separators of s replaced by slashes.
+func (s PathStyle) toSlash(p string) string {
+	if s.sep() == '/' {
+		return p
+	}
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// clean is filepath.Clean for paths of style s.
+func (s PathStyle) clean(p string) string {
+	switch {
+	case s.native():
+		return filepath.Clean(p)
+	case s.resolve() == UnixPaths:
+		return path.Clean(p)
+	}
+	vol := s.volumeName(p)
+	rest := strings.ReplaceAll(p[len(vol):], `\`, "/")
+	vol = s.fromSlash(vol)
+	if rest == "" {
+		if len(vol) > 1 && s.isSep(vol[1]) {
+			// A UNC volume name, such as `\\host\share`.
+			return vol
+		}
+		return vol + "."
+	}
+	return vol + s.fromSlash(path.Clean(rest))
+}
+
+// dir is filepath.Dir for paths of style s.
+func (s PathStyle) dir(p string) string {
+	switch {
+	case s.native():
+		return filepath.Dir(p)
+	case s.resolve() == UnixPaths:
+		return path.Dir(p)
+	}
+	vol := s.volumeName(p)
+	i := len(p) - 1
+	for i >= len(vol) && !s.isSep(p[i]) {
+		i--
+	}
+	dir := s.clean(p[len(vol) : i+1])
+	if dir == "." && len(vol) > 2 {
+		return vol
+	}
+	return vol + dir
+}
+
+// isAbs is filepath.IsAbs for paths of style s.
+func (s PathStyle) isAbs(p string) bool {
+	switch {
+	case s.native():
+		return filepath.IsAbs(p)
+	case s.resolve() == UnixPaths:
+		return strings.HasPrefix(p, "/")
+	}
+	vol := s.volumeName(p)
+	if vol == "" {
+		return false
+	}
+	p = p[len(vol):]
+	return p != "" && s.isSep(p[0])
+}
+
+// volumeName is filepath.VolumeName for paths of style s.
+func (s PathStyle) volumeName(p string) string {
+	switch {
+	case s.native():
+		return filepath.VolumeName(p)
+	case s.resolve() == UnixPaths:
+		return ""
+	}
+	if len(p) >= 2 && p[1] == ':' && 'a' <= p[0]|0x20 && p[0]|0x20 <= 'z' {
+		return p[:2]
+	}
+	// Is it a UNC volume name, such as `\\host\share`?
+	if l := len(p); l >= 5 && s.isSep(p[0]) && s.isSep(p[1]) && !s.isSep(p[2]) && p[2] != '.' {
+		for n := 3; n < l-1; n++ {
+			if s.isSep(p[n]) {
+				n++
+				if s.isSep(p[n]) || p[n] == '.' {
+					break
+				}
+				for ; n < l && !s.isSep(p[n]); n++ {
+				}
+				return p[:n]
+			}
+		}
+	}
+	return ""
+}
+
+// match is filepath.Match for patterns and names of style s, with the
+// classes of pattern negated with "[!" supported. It is only used on path
+// elements, and to check the syntax of patterns.
+func (s PathStyle) match(pattern, name string) (bool, error) {
+	pattern = shellClasses(pattern, s.escapes())
+	switch {
+	case s.native():
+		return filepath.Match(pattern, name)
+	case s.escapes():
+		return path.Match(pattern, name)
+	}
+	// Backslashes match themselves.
+	return path.Match(strings.ReplaceAll(pattern, `\`, `\\`), name)
+}
@@ -0,0 +1,309 @@
+package patternmatcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPathStyleCompile(t *testing.T) {
+	for _, style := range []PathStyle{UnixPaths, WindowsPaths} {
+		for _, tt := range compileTests {
+			patterns, err := NewPatterns([]string{tt.pattern}, WithPathStyle(style))
+			if err != nil {
+				t.Fatalf("%v: %q: %v", style, tt.pattern, err)
+			}
+			p := patterns[0]
+			if p.MatchType != tt.matchType {
+				t.Errorf("%v: %q: matchType = %v, want %v", style, tt.pattern, p.MatchType, tt.matchType)
+				continue
+			}
+			want := tt.compiledRegexp
+			if style == WindowsPaths {
+				want = tt.windowsCompiledRegexp
+			}
+			if want != "" && p.Regexp.String() != want {
+				t.Errorf("%v: %q: regexp = %s, want %s", style, tt.pattern, p.Regexp, want)
+			}
+		}
+	}
+}
+
+func TestPathStyleOfMatcher(t *testing.T) {
+	for _, style := range []PathStyle{UnixPaths, WindowsPaths} {
+		if got := MustNew(nil, WithPathStyle(style)).PathStyle(); got != style {
+			t.Errorf("expected %v, got %v", style, got)
+		}
+	}
+	if got := MustNew(nil).PathStyle(); got != HostPaths.resolve() {
+		t.Errorf("expected the host style to be resolved, got %v", got)
+	}
+}
+
+func TestPathStyleWindows(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{`docs\*.md`, "docs/README.md", true},
+		{`docs\*.md`, `docs\README.md`, true},
+		{"docs/*.md", `docs\sub\README.md`, false},
+		{"docs/*.md", `docs\README.md`, true},
+		{"build", `build\out\a.o`, true},
+		{`**\*.go`, `src\pkg\main.go`, true},
+		{"*.go", `src\main.go`, false},
+		{"a[b-d]e", `x\ace`, false},
+		{"a[b-d]e", `ace`, true},
+		// Backslashes are separators, not escapes.
+		{`a\*`, `a\b`, true},
+		{`a\*`, `a*`, false},
+		{`a\b\..\c`, `a\c`, true},
+	}
+	for _, tt := range tests {
+		pm, err := New([]string{tt.pattern}, WithPathStyle(WindowsPaths))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.pattern, err)
+		}
+		matched, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.pattern, err)
+		}
+		if matched != tt.expected {
+			t.Errorf("%q against %q: expected %v, got %v", tt.pattern, tt.path, tt.expected, matched)
+		}
+	}
+}
+
+func TestPathStyleUnix(t *testing.T) {
+	pm := MustNew([]string{`a\*`, `docs/*.md`}, WithPathStyle(UnixPaths))
+	for path, expected := range map[string]bool{
+		"a*":               true,
+		"a/b":              false,
+		"docs/README.md":   true,
+		`docs\README.md`:   false,
+		"docs/x/README.md": false,
+	} {
+		matched, err := pm.MatchesOrParentMatches(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%q: expected %v, got %v", path, expected, matched)
+		}
+	}
+}
+
+func TestPathStyleClean(t *testing.T) {
+	tests := []struct {
+		path, clean, dir string
+		abs              bool
+	}{
+		{"a/b", `a\b`, "a", false},
+		{`a\.\b\..\c\`, `a\c`, `a\c`, false},
+		{"", ".", ".", false},
+		{`C:\a\..\b`, `C:\b`, `C:\`, true},
+		{"C:", "C:.", "C:.", false},
+		{"C:a", "C:a", "C:.", false},
+		{`\\host\share\a`, `\\host\share\a`, `\\host\share\`, true},
+		{`\\host\share`, `\\host\share`, `\\host\share`, false},
+		{`\a`, `\a`, `\`, false},
+	}
+	for _, tt := range tests {
+		if clean := WindowsPaths.clean(tt.path); clean != tt.clean {
+			t.Errorf("clean(%q) = %q, want %q", tt.path, clean, tt.clean)
+		}
+		if dir := WindowsPaths.dir(tt.path); dir != tt.dir {
+			t.Errorf("dir(%q) = %q, want %q", tt.path, dir, tt.dir)
+		}
+		if abs := WindowsPaths.isAbs(tt.path); abs != tt.abs {
+			t.Errorf("isAbs(%q) = %v, want %v", tt.path, abs, tt.abs)
+		}
+	}
+}
+
+func TestPathStyleStrictPaths(t *testing.T) {
+	pm := MustNew([]string{"*.go"}, WithPathStyle(WindowsPaths), WithStrictPaths())
+	for _, path := range []string{`C:\src\main.go`, `\\host\share\main.go`, "/main.go"} {
+		if _, err := pm.MatchesWithOpts(path, MatchOpts{}); !errors.Is(err, ErrAbsolutePath) {
+			t.Errorf("%q: expected ErrAbsolutePath, got %v", path, err)
+		}
+	}
+	if _, err := pm.MatchesWithOpts(`src\..\..\main.go`, MatchOpts{}); !errors.Is(err, ErrPathEscapesRoot) {
+		t.Errorf("expected ErrPathEscapesRoot, got %v", err)
+	}
+}
+
+func TestPathStyleRebase(t *testing.T) {
+	for _, tt := range []struct {
+		style    PathStyle
+		dir      string
+		path     string
+		prefixed string
+	}{
+		{UnixPaths, "src", "main_test.go", "src/main_test.go"},
+		{WindowsPaths, `src`, "main_test.go", `src\main_test.go`},
+		{WindowsPaths, "src/pkg", `lib_test.go`, `src\pkg\lib_test.go`},
+	} {
+		pm := MustNew([]string{"**/*_test.go", `src/pkg/*.go`}, WithPathStyle(tt.style))
+		rebased, err := pm.Rebase(tt.dir)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.style, err)
+		}
+		if matched, _ := rebased.MatchesOrParentMatches(tt.path); !matched {
+			t.Errorf("%v: expected the matcher rebased on %q to match %q", tt.style, tt.dir, tt.path)
+		}
+		prefixed, err := pm.Prefix(tt.dir)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.style, err)
+		}
+		if matched, _ := prefixed.MatchesOrParentMatches(tt.prefixed); !matched {
+			t.Errorf("%v: expected the matcher prefixed with %q to match %q", tt.style, tt.dir, tt.prefixed)
+		}
+	}
+	for _, dir := range []string{`C:\src`, `\\host\share\src`, `..\src`} {
+		if _, err := MustNew([]string{"*.go"}, WithPathStyle(WindowsPaths)).Rebase(dir); !errors.Is(err, ErrCannotRebase) {
+			t.Errorf("%q: expected ErrCannotRebase, got %v", dir, err)
+		}
+	}
+	// Backslashes escape the characters of literal directories with Unix
+	// paths, and are separators with Windows paths.
+	if prefixed, err := MustNew([]string{"*.go"}, WithPathStyle(UnixPaths)).Prefix("a*"); err != nil {
+		t.Error(err)
+	} else if matched, _ := prefixed.MatchesOrParentMatches("ab/main.go"); matched {
+		t.Error("expected the prefix to be matched literally")
+	}
+	if _, err := MustNew([]string{"*.go"}, WithPathStyle(WindowsPaths)).Prefix("a*"); !errors.Is(err, ErrCannotRebase) {
+		t.Errorf("expected ErrCannotRebase, got %v", err)
+	}
+}
+
+func TestPathStyleDirCursor(t *testing.T) {
+	for _, tt := range []struct {
+		style PathStyle
+		path  string
+		elem  string
+	}{
+		{UnixPaths, "src/pkg", "a/b"},
+		{WindowsPaths, `src\pkg`, `a\b`},
+	} {
+		pm := MustNew([]string{"src/pkg/*.go"}, WithPathStyle(tt.style))
+		src, err := pm.EnterDir(nil, "src")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := pm.EnterDir(src, "pkg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg.Path() != tt.path {
+			t.Errorf("%v: expected path %q, got %q", tt.style, tt.path, pkg.Path())
+		}
+		if matched, _ := pkg.Matches("main.go"); !matched {
+			t.Errorf("%v: expected main.go to be matched", tt.style)
+		}
+		if _, err := pkg.Matches(tt.elem); !errors.Is(err, ErrNotPathElement) {
+			t.Errorf("%v: expected ErrNotPathElement for %q, got %v", tt.style, tt.elem, err)
+		}
+	}
+	// Backslashes are part of the names of Unix paths.
+	pm := MustNew([]string{`a\\b`}, WithPathStyle(UnixPaths))
+	if matched, err := pm.EnterDir(nil, `a\b`); err != nil || !matched.Matched() {
+		t.Errorf("expected a\\b to be a matched directory, got %v", err)
+	}
+}
+
+func TestPathStyleMultiRoot(t *testing.T) {
+	m, err := NewMultiRoot(map[string]*PatternMatcher{
+		`C:\repo`:     MustNew([]string{"*.log"}, WithPathStyle(WindowsPaths)),
+		`C:\repo\web`: MustNew([]string{"dist"}, WithPathStyle(WindowsPaths)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _, rel, err := m.Root(`C:\repo\web\dist\app.js`)
+	if err != nil || root != `C:\repo\web` || rel != `dist\app.js` {
+		t.Errorf("unexpected root %q and path %q, %v", root, rel, err)
+	}
+	if matched, err := m.MatchesOrParentMatches("C:/repo/debug.log"); err != nil || !matched {
+		t.Errorf("expected debug.log to be matched, got %v", err)
+	}
+	if _, _, _, err := m.Root("/repo/web"); !errors.Is(err, ErrNotAbsolute) {
+		t.Errorf("expected ErrNotAbsolute, got %v", err)
+	}
+
+	unix, err := NewMultiRoot(map[string]*PatternMatcher{"/repo": MustNew([]string{"*.log"}, WithPathStyle(UnixPaths))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, rel, err := unix.Root("/repo/a/b.log"); err != nil || rel != "a/b.log" {
+		t.Errorf("unexpected path %q, %v", rel, err)
+	}
+
+	if _, err := NewMultiRoot(map[string]*PatternMatcher{
+		"/a": MustNew(nil, WithPathStyle(UnixPaths)),
+		"/b": MustNew(nil, WithPathStyle(WindowsPaths)),
+	}); err == nil {
+		t.Error("expected an error for matchers of different styles")
+	}
+}
+
+func TestPathStyleParseAST(t *testing.T) {
+	for _, tt := range []struct {
+		style    PathStyle
+		pattern  string
+		segments int
+		str      string
+	}{
+		{UnixPaths, `a\*b/c`, 2, "a[*]b/c"},
+		{UnixPaths, `a\b`, 1, `ab`},
+		{WindowsPaths, `a\*b/c`, 3, "a/*b/c"},
+		{WindowsPaths, `[\]`, 0, ""},
+	} {
+		ast, err := ParseAST(tt.pattern, WithPathStyle(tt.style))
+		if tt.segments == 0 {
+			if err == nil {
+				t.Errorf("%v: %q: expected an error", tt.style, tt.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %q: %v", tt.style, tt.pattern, err)
+		}
+		if len(ast.Segments) != tt.segments || ast.String() != tt.str {
+			t.Errorf("%v: %q: expected %d segments and %q, got %d and %q", tt.style, tt.pattern, tt.segments, tt.str, len(ast.Segments), ast.String())
+		}
+	}
+	ast, err := ParseAST(`[\]]`, WithPathStyle(UnixPaths))
+	if err != nil || ast.String() != `[\]]` {
+		t.Errorf("expected the class to be escaped, got %v", err)
+	}
+}
+
+func TestPathStyleOutput(t *testing.T) {
+	pm := MustNew([]string{"build/"}, WithPathStyle(WindowsPaths))
+	kept, err := pm.FilterPaths([]string{`src\.\main.go`, `build\`, `docs\`}, OutputOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 2 || kept[0] != "src/main.go" || kept[1] != "docs/" {
+		t.Errorf("unexpected paths %q", kept)
+	}
+
+	paths, err := Enumerate(`docs\[ab].md`, EnumerateOpts{Style: WindowsPaths})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "docs/a.md" || paths[1] != "docs/b.md" {
+		t.Errorf("unexpected paths %q", paths)
+	}
+	if paths, err := Enumerate(`a\[b]`, EnumerateOpts{Style: UnixPaths}); err != nil || len(paths) != 1 || paths[0] != "a[b]" {
+		t.Errorf("unexpected paths %q, %v", paths, err)
+	}
+
+	for _, style := range []PathStyle{UnixPaths, WindowsPaths} {
+		pm := MustNew([]string{"src/*"}, WithPathStyle(style))
+		if s := suggestRewrite(pm.patterns[0]); s == "" {
+			t.Errorf("%v: expected a suggestion", style)
+		}
+	}
+}
@@ -2,12 +2,17 @@ package patternmatcher
 
 import (
 	"errors"
-	"os"
-	"path/filepath"
+	"fmt"
+	"io/fs"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/scanner"
+	"time"
+	"unicode"
 	"unicode/utf8"
+
+	"github.com/moby/patternmatcher/internal/glob"
 )
 
 // escapeBytes is a bitmap used to check whether a character should be escaped when creating the regex.
@@ -42,12 +47,23 @@ func init() {
 //
 // The "file" argument should be a slash-delimited path.
 func MatchesUsingParentResults(patterns []*Pattern, file string, parentMatched []bool) (bool, []bool, error) {
+	if err := checkPatterns(patterns); err != nil {
+		return false, nil, err
+	}
+	return matchesUsingParentResults(patterns, file, parentMatched, defaultOptions)
+}
+
+func matchesUsingParentResults(patterns []*Pattern, file string, parentMatched []bool, o *options) (bool, []bool, error) {
 	if len(parentMatched) != 0 && len(parentMatched) != len(patterns) {
 		return false, nil, errors.New("wrong number of values in parentMatched")
 	}
 
-	file = filepath.FromSlash(file)
-	matched := false
+	st := o.style
+	file = st.fromSlash(file)
+	matched := o.defaultMatch
+	if o.noParents {
+		parentMatched = nil
+	}
 
 	matchInfo := make([]bool, len(patterns))
 	for i, pattern := range patterns {
@@ -57,7 +73,7 @@ func MatchesUsingParentResults(patterns []*Pattern, file string, parentMatched [
 			match = parentMatched[i]
 		}
 
-		if !match {
+		if !match && len(pattern.predicates) == 0 {
 			// Skip evaluation if this is an inclusion and the filename
 			// already matched the pattern, or it's an exclusion and it has
 			// not matched the pattern yet.
@@ -70,12 +86,12 @@ func MatchesUsingParentResults(patterns []*Pattern, file string, parentMatched [
 			// If the zero value of MatchInfo was passed in, we don't have
 			// any information about the parent dir's match results, and we
 			// apply the same logic as MatchesOrParentMatches.
-			if !match && len(parentMatched) == 0 {
-				if parentPath := filepath.Dir(file); parentPath != "." {
-					parentPathDirs := strings.Split(parentPath, string(os.PathSeparator))
+			if !match && len(parentMatched) == 0 && !o.noParents {
+				if parentPath := st.dir(file); parentPath != "." {
+					parentPathDirs := strings.Split(parentPath, string(st.sep()))
 					// Check to see if the pattern matches one of our parent dirs.
 					for i := range parentPathDirs {
-						match = pattern.Match(strings.Join(parentPathDirs[:i+1], string(os.PathSeparator)))
+						match = pattern.Match(strings.Join(parentPathDirs[:i+1], string(st.sep())))
 						if match {
 							break
 						}
@@ -97,75 +113,470 @@ func MatchesUsingParentResults(patterns []*Pattern, file string, parentMatched [
 //
 // The "file" argument should be a slash-delimited path.
 func MatchesOrParentMatches(patterns []*Pattern, file string) (bool, error) {
-	file = filepath.Clean(file)
+	if err := checkPatterns(patterns); err != nil {
+		return false, err
+	}
+	pm := PatternMatcher{patterns: patterns, opts: defaultOptions}
+	matched, _ := pm.matchesOrParentMatches(file, &evaluation{})
+	return matched, nil
+}
 
-	if file == "." {
+// matchesOrParentMatches returns true if file matches the patterns of pm,
+// and the pattern deciding it if e requests it.
+func (pm *PatternMatcher) matchesOrParentMatches(file string, e *evaluation) (bool, *Pattern) {
+	o := pm.opts
+	file = o.style.clean(file)
+
+	if file == "." && o.root == RootNotMatched {
 		// Don't let them exclude everything, kind of silly.
 		return false, nil
 	}
 
-	matched := false
-	file = filepath.FromSlash(file)
-	parentPath := filepath.Dir(file)
-	parentPathDirs := strings.Split(parentPath, string(os.PathSeparator))
+	file = o.style.fromSlash(file)
+	patterns := pm.patterns
+	if e.opts != nil && e.opts.FoldCase {
+		// Fold the path once, rather than for each pattern.
+		file = foldString(file)
+	} else if e.trace == nil {
+		patterns = pm.index.lookup(file, pm.patterns)
+	}
+	var parentPathDirs []string
+	if parentPath := o.style.dir(file); parentPath != "." && !o.noParents {
+		parentPathDirs = strings.Split(parentPath, string(o.style.sep()))
+	}
+
+	e.pm = pm
+	e.file = file
+	isDir := e.opts != nil && (e.opts.IsDir || e.opts.Info != nil && e.opts.Info.IsDir())
+	if o.gitReinclusion {
+		// A path can't be re-included if one of its parent dirs is
+		// excluded, so the first parent dir matched by a pattern decides,
+		// or not matched once inverted. A parent dir only matched by
+		// default doesn't.
+		for i := range parentPathDirs {
+			if matched, pattern := e.decide(patterns, strings.Join(parentPathDirs[:i+1], string(o.style.sep())), true, nil); pattern != nil && matched != o.inverted {
+				return matched, pattern
+			}
+		}
+		return e.decide(patterns, file, isDir, nil)
+	}
 
+	return e.decide(patterns, file, isDir, parentPathDirs)
+}
+
+// evaluation holds the state of a single call matching a path.
+type evaluation struct {
+	pm *PatternMatcher
+	// opts are the options of the call, or nil if it has none, in which
+	// case whether paths are directories isn't known.
+	opts *MatchOpts
+	// needPattern requests the pattern deciding the result, which may
+	// require evaluating more patterns.
+	needPattern bool
+	// file is the path being matched, once cleaned, as opposed to its
+	// parent dirs.
+	file string
+	// parentInfos holds the attributes of the parent dirs of file, if
+	// known, such as when walking, the top-level one first.
+	parentInfos []fs.FileInfo
+	// trace, if set, receives a step for each pattern considered.
+	trace *Explanation
+	// steps counts the work done against the budget of the matcher, and
+	// budgetExceeded reports that it was exhausted, which makes the
+	// result invalid.
+	steps          int
+	budgetExceeded bool
+}
+
+// decide returns true if the patterns matching file, or one of its
+// parentPathDirs, resolve to a match. It also returns the pattern deciding
+// it, or nil if the default decides. Unless e.needPattern is set, the
+// pattern returned is the last one evaluated which matches, rather than
+// the last one matching, as patterns which can't change the result are
+// skipped.
+func (e *evaluation) decide(patterns []*Pattern, file string, isDir bool, parentPathDirs []string) (bool, *Pattern) {
+	o := e.pm.opts
+	if o.resolution == MostSpecificWins {
+		best := -1
+		for i, pattern := range patterns {
+			matched := e.matchesOrParent(pattern, file, isDir, parentPathDirs)
+			if matched {
+				if best == -1 || !patterns[best].outranks(pattern) {
+					best = i
+				}
+			}
+			if e.trace != nil {
+				decision := o.defaultMatch
+				if best != -1 {
+					decision = !patterns[best].Exclusion
+				}
+				e.traceStep(pattern, file, isDir, false, matched, decision)
+			}
+		}
+		if best == -1 {
+			return o.defaultMatch, nil
+		}
+		return !patterns[best].Exclusion, patterns[best]
+	}
+
+	if e.needPattern {
+		// The last matching pattern decides.
+		for i := len(patterns) - 1; i >= 0; i-- {
+			if e.matchesOrParent(patterns[i], file, isDir, parentPathDirs) {
+				return !patterns[i].Exclusion, patterns[i]
+			}
+		}
+		return o.defaultMatch, nil
+	}
+
+	matched := o.defaultMatch
+	var decider *Pattern
 	for _, pattern := range patterns {
 		// Skip evaluation if this is an inclusion and the filename
 		// already matched the pattern, or it's an exclusion and it has
-		// not matched the pattern yet.
-		if pattern.Exclusion != matched {
+		// not matched the pattern yet. With git re-inclusion rules,
+		// whether a pattern matches a parent dir matters even if it
+		// doesn't change the result, until one does.
+		if pattern.Exclusion != matched && (decider != nil || !o.gitReinclusion) {
+			if e.trace != nil {
+				e.traceStep(pattern, file, isDir, true, false, matched)
+			}
 			continue
 		}
 
-		match := pattern.Match(file)
-		if !match && parentPath != "." {
-			// Check to see if the pattern matches one of our parent dirs.
-			for i := range parentPathDirs {
-				match = pattern.Match(strings.Join(parentPathDirs[:i+1], string(os.PathSeparator)))
-				if match {
-					break
-				}
+		m := e.matchesOrParent(pattern, file, isDir, parentPathDirs)
+		if m {
+			matched = !pattern.Exclusion
+			decider = pattern
+		}
+		if e.trace != nil {
+			e.traceStep(pattern, file, isDir, false, m, matched)
+		}
+	}
+	return matched, decider
+}
+
+// matchesOrParent returns true if pattern matches file or one of its
+// parentPathDirs.
+func (e *evaluation) matchesOrParent(pattern *Pattern, file string, isDir bool, parentPathDirs []string) bool {
+	if instr := e.pm.opts.instrumentation; instr != nil {
+		start := time.Now()
+		matched := e.evalPattern(pattern, file, isDir, parentPathDirs)
+		instr.PatternEvaluated(pattern, time.Since(start))
+		return matched
+	}
+	return e.evalPattern(pattern, file, isDir, parentPathDirs)
+}
+
+// evalPattern is matchesOrParent without instrumentation.
+func (e *evaluation) evalPattern(pattern *Pattern, file string, isDir bool, parentPathDirs []string) bool {
+	if len(pattern.predicates) != 0 {
+		// Only the paths whose attributes are known can satisfy the
+		// predicates.
+		if info := e.info(file); info != nil && e.match(pattern, file, isDir) && pattern.satisfiedBy(info) {
+			return true
+		}
+		for i := 0; i < len(parentPathDirs) && i < len(e.parentInfos); i++ {
+			if info := e.parentInfos[i]; info != nil && e.match(pattern, strings.Join(parentPathDirs[:i+1], string(e.pm.opts.style.sep())), true) && pattern.satisfiedBy(info) {
+				return true
 			}
 		}
+		return false
+	}
+	if e.match(pattern, file, isDir) {
+		return true
+	}
+	if len(parentPathDirs) == 0 {
+		return false
+	}
 
-		if match {
-			matched = !pattern.Exclusion
+	sep := e.pm.opts.style.sep()
+	if e.pm.opts.legacyParents {
+		// Only check the parent dir with as many path elements as the
+		// pattern.
+		if len(pattern.Dirs) <= len(parentPathDirs) {
+			return e.match(pattern, strings.Join(parentPathDirs[:len(pattern.Dirs)], string(sep)), true)
 		}
+		return false
 	}
 
-	return matched, nil
+	// Check to see if the pattern matches one of our parent dirs. They are
+	// the prefixes of file ending before a separator, which avoids joining
+	// their elements again for each of them.
+	for i := 0; ; i++ {
+		n := strings.IndexByte(file[i:], sep)
+		if n == -1 {
+			return false
+		}
+		i += n
+		if e.match(pattern, file[:i], true) {
+			return true
+		}
+	}
+}
+
+// info returns the attributes of file, which is e.file or one of its parent
+// dirs, or nil if they aren't known.
+func (e *evaluation) info(file string) fs.FileInfo {
+	if file == e.file {
+		if e.opts == nil {
+			return nil
+		}
+		return e.opts.Info
+	}
+	if n := strings.Count(file, string(e.pm.opts.style.sep())); n < len(e.parentInfos) {
+		return e.parentInfos[n]
+	}
+	return nil
+}
+
+// match returns true if pattern matches path, applying the options of the
+// call.
+func (e *evaluation) match(pattern *Pattern, path string, isDir bool) bool {
+	if e.pm.opts.budget > 0 && !e.spend(pattern, path) {
+		return false
+	}
+	if e.opts == nil {
+		return pattern.Match(path)
+	}
+	if pattern.DirOnly && !isDir {
+		return false
+	}
+	if e.opts.FoldCase {
+		return pattern.matchFold(path, e.pm.foldedLiteral(pattern), e.pm.foldRegexp(pattern)) && pattern.satisfiesPath(path)
+	}
+	return pattern.Match(path)
 }
 
 // NewPatterns creates patterns that match against paths.
-func NewPatterns(patterns []string) ([]*Pattern, error) {
-	matchPatters := make([]*Pattern, 0, len(patterns))
-	for _, p := range patterns {
+func NewPatterns(patterns []string, opts ...Option) ([]*Pattern, error) {
+	return newPatterns(patterns, newOptions(opts))
+}
+
+// MustCompilePatterns is like NewPatterns with no options, but panics if
+// the patterns can't be compiled. It simplifies the initialization of
+// package-level variables and tests.
+func MustCompilePatterns(lines ...string) []*Pattern {
+	patterns, err := NewPatterns(lines)
+	if err != nil {
+		panic("patternmatcher: MustCompilePatterns: " + err.Error())
+	}
+	return patterns
+}
+
+func newPatterns(patterns []string, o *options) ([]*Pattern, error) {
+	type cleanedPattern struct {
+		text    string
+		index   int
+		dirOnly bool
+	}
+	if c := o.badSeparator; c != 0 {
+		return nil, fmt.Errorf("unsupported separator %q", c)
+	}
+	if c := o.escapeChar; c != 0 && (c == '/' || c == rune(o.style.sep()) || strings.ContainsRune("*?[]!", c)) {
+		return nil, fmt.Errorf("invalid escape character %q", c)
+	}
+	if d := o.globstarDepth; d < 0 || d > maxGlobstarDepth {
+		return nil, fmt.Errorf("invalid max globstar depth %d", d)
+	}
+	cleaned := make([]cleanedPattern, 0, len(patterns))
+	var size, dirs int
+	for i, p := range patterns {
 		// Eliminate leading and trailing whitespace.
 		p = strings.TrimSpace(p)
 		if p == "" {
 			continue
 		}
-		p = filepath.Clean(p)
-
-		// Do some syntax checking on the pattern.
-		// filepath's Match() has some really weird rules that are inconsistent
-		// so instead of trying to dup their logic, just call Match() for its
-		// error state and if there is an error in the pattern return it.
-		// If this becomes an issue we can remove this since its really only
-		// needed in the error (syntax) case - which isn't really critical.
-		if _, err := filepath.Match(p, "."); err != nil {
+		if err := o.limits.checkPattern(p); err != nil {
 			return nil, err
 		}
+		if o.controlChars == ControlCharReject && hasControlChar(p) {
+			return nil, &PatternError{Pattern: p, Err: ErrControlCharacter}
+		}
+		if o.gitignore {
+			p = o.anchorGitignore(p)
+		}
+		if o.literalBrackets {
+			p = literalBrackets(p, o.escapeChar, o.style.escapes())
+		}
+		if o.escapeChar != 0 {
+			p = escapeWildcards(p, o.escapeChar)
+		}
+		expanded := []string{p}
+		if o.braces {
+			max := o.limits.MaxBraceExpansion
+			if max == 0 {
+				max = DefaultMaxBraceExpansion
+			}
+			var ok bool
+			expanded, ok = glob.Expand(p, o.style.escapes(), max)
+			if !ok {
+				return nil, &PatternError{Pattern: p, Err: &LimitError{Limit: "MaxBraceExpansion", Max: max}}
+			}
+		}
+		for _, p := range expanded {
+			trailingSep := len(p) > 1 && o.style.isSep(p[len(p)-1])
+			if err := o.checkClean(p); err != nil {
+				return nil, err
+			}
+			p = o.style.clean(p)
+			if o.globstar == GlobstarDirAndContents {
+				// Parent dirs being matched, "dir" matches the contents
+				// of dir as "dir/**" does, and dir itself as well.
+				if trimmed := strings.TrimSuffix(p, string(o.style.sep())+"**"); trimmed != p && trimmed != "" && trimmed != "!" {
+					p = trimmed
+				}
+			}
+
+			var err error
+			p, err = o.applyParentRefPolicy(p)
+			if err != nil {
+				return nil, err
+			}
+
+			// Do some syntax checking on the pattern.
+			// filepath's Match() has some really weird rules that are inconsistent
+			// so instead of trying to dup their logic, just call Match() for its
+			// error state and if there is an error in the pattern return it.
+			// If this becomes an issue we can remove this since its really only
+			// needed in the error (syntax) case - which isn't really critical.
+			if _, err := o.style.match(p, "."); err != nil {
+				return nil, err
+			}
+
+			cleaned = append(cleaned, cleanedPattern{text: p, index: i, dirOnly: trailingSep})
+			size += len(p)
+			dirs += strings.Count(p, string(o.style.sep())) + 1
+		}
+		if max := o.limits.MaxPatterns; max > 0 && len(cleaned) > max {
+			return nil, &LimitError{Limit: "MaxPatterns", Max: max}
+		}
+	}
+
+	// Allocate the patterns, their text and their dirs from contiguous slabs
+	// rather than one by one, which reduces the number of objects to track
+	// for the garbage collector when there are many patterns.
+	var sb strings.Builder
+	sb.Grow(size)
+	for _, c := range cleaned {
+		sb.WriteString(c.text)
+	}
+	text := sb.String()
+	patternSlab := make([]Pattern, len(cleaned))
+	dirSlab := make([]string, 0, dirs)
 
-		newp, err := NewPattern(p)
-		if err != nil {
+	matchPatters := make([]*Pattern, len(cleaned))
+	for i, c := range cleaned {
+		var p string
+		p, text = text[:len(c.text)], text[len(c.text):]
+		newp := &patternSlab[i]
+		newp.style = o.style
+		if err := newp.init(p, &dirSlab); err != nil {
+			return nil, err
+		}
+		newp.escapeControl = o.controlChars == ControlCharEscape
+		newp.DirOnly = c.dirOnly
+		newp.index = c.index
+		if o.wildcards == ByteWildcards {
+			if err := newp.useBytes(); err != nil {
+				return nil, err
+			}
+		}
+		newp.capGlobstar(o.globstarDepth)
+		if o.foldCase {
+			newp.setFoldCase()
+		}
+		if err := o.limits.checkCompiled(newp); err != nil {
 			return nil, err
 		}
-		matchPatters = append(matchPatters, newp)
+		matchPatters[i] = newp
 	}
 	return matchPatters, nil
 }
 
+// anchorGitignore rewrites the gitignore pattern p as a pattern anchored to
+// the root: a leading separator is removed, and a pattern without any other
+// separator than a trailing one is prefixed with "**/".
+func (o *options) anchorGitignore(p string) string {
+	var prefix string
+	body := p
+	if body[0] == '!' {
+		prefix, body = "!", body[1:]
+	}
+	if body == "" {
+		return p
+	}
+	if o.style.isSep(body[0]) {
+		return prefix + body[1:]
+	}
+	end := len(body)
+	for end > 0 && o.style.isSep(body[end-1]) {
+		end--
+	}
+	for i := 0; i < end; i++ {
+		if o.style.isSep(body[i]) {
+			return p
+		}
+	}
+	return prefix + "**/" + body
+}
+
+// checkClean applies the CleanPolicy to the pattern p, which is about to be
+// cleaned.
+func (o *options) checkClean(p string) error {
+	if o.cleanPolicy == CleanAllow {
+		return nil
+	}
+	cleaned := o.style.clean(p)
+	altered := cleaned != o.style.fromSlash(p)
+	if body := strings.TrimPrefix(p, "!"); !altered && body != p {
+		// Clean takes "!." for a name, so "!./foo" is left as is.
+		altered = o.style.clean(body) != o.style.fromSlash(body)
+	}
+	if !altered {
+		return nil
+	}
+	err := &PatternError{Pattern: p, Err: fmt.Errorf("%w into %q", ErrPatternCleaned, cleaned)}
+	if o.cleanPolicy == CleanReject {
+		return err
+	}
+	if o.warn != nil {
+		o.warn(err)
+	}
+	return nil
+}
+
+// applyParentRefPolicy checks whether the cleaned pattern p refers to a
+// location above the root and rejects or anchors it according to the
+// configured policy.
+func (o *options) applyParentRefPolicy(p string) (string, error) {
+	if o.parentRefs == ParentRefAllow {
+		return p, nil
+	}
+
+	var prefix string
+	body := p
+	if body[0] == '!' {
+		prefix, body = "!", body[1:]
+	}
+
+	parentDir := ".." + string(o.style.sep())
+	if body != ".." && !strings.HasPrefix(body, parentDir) {
+		return p, nil
+	}
+	if o.parentRefs == ParentRefReject {
+		return "", &PatternError{Pattern: p, Err: ErrPatternEscapesRoot}
+	}
+
+	for strings.HasPrefix(body, parentDir) {
+		body = body[len(parentDir):]
+	}
+	if body == ".." {
+		body = "."
+	}
+	return prefix + body, nil
+}
+
 type MatchType int
 
 const (
@@ -174,9 +585,39 @@ const (
 	PrefixMatch
 	SuffixMatch
 	RegexpMatch
+	// ClassMatch is used for patterns made of a single path element with
+	// character classes, which are matched without using Regexp.
+	ClassMatch
+	// ExtensionMatch is used for patterns made of "*" followed by a literal
+	// name suffix, such as "*.go", which are matched without using Regexp.
+	ExtensionMatch
 )
 
+// String returns the name of the match type, such as "ExactMatch".
+func (t MatchType) String() string {
+	switch t {
+	case UnknownMatch:
+		return "UnknownMatch"
+	case ExactMatch:
+		return "ExactMatch"
+	case PrefixMatch:
+		return "PrefixMatch"
+	case SuffixMatch:
+		return "SuffixMatch"
+	case RegexpMatch:
+		return "RegexpMatch"
+	case ClassMatch:
+		return "ClassMatch"
+	case ExtensionMatch:
+		return "ExtensionMatch"
+	}
+	return "MatchType(" + strconv.Itoa(int(t)) + ")"
+}
+
 // Pattern defines a single regexp used to filter file paths.
+//
+// A Pattern must not be modified once compiled, which makes it safe to use
+// from multiple goroutines. Use Clone to obtain a copy that can be modified.
 type Pattern struct {
 	MatchType      MatchType
 	CleanedPattern string
@@ -184,58 +625,299 @@ type Pattern struct {
 	Regexp         *regexp.Regexp
 	// Exclusion returns true if this pattern defines Exclusion
 	Exclusion bool
+	// DirOnly reports that the pattern ended with a path separator, as
+	// "build/" does, so that it only matches directories. It is only
+	// honored when whether paths are directories is known, as with
+	// MatchPath and MatchOpts.IsDir.
+	DirOnly bool
+
+	escapeControl bool
+	literalPrefix int
+	segment       *segmentMatcher
+	foldCase      bool
+	// bytewise reports that Regexp matches paths byte by byte, as
+	// converted by bytesAsRunes.
+	bytewise bool
+	// globstarDepth is the number of directory levels a "**" may span, or
+	// 0 if unlimited.
+	globstarDepth int
+	// style is the flavor of paths the pattern was compiled for.
+	style PathStyle
+	// index is the position of the pattern in the list it was compiled
+	// from, which is shared by the patterns a brace expands into.
+	index      int
+	precedence Precedence
+	source     string
+	group      string
+	labels     map[string]string
+	// predicates restrict the paths matched to those whose attributes
+	// satisfy them, as set by a Rule, and pathPredicates to those
+	// satisfying conditions on the paths themselves.
+	predicates     []Predicate
+	pathPredicates []PathPredicate
 }
 
 func NewPattern(pattern string) (*Pattern, error) {
+	p := &Pattern{}
+	if err := p.init(pattern, new([]string)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// init compiles pattern into p, appending its dirs to dirSlab. The style
+// of p is kept.
+func (p *Pattern) init(pattern string, dirSlab *[]string) error {
+	style := p.style
 	var exclusion bool
 	if pattern[0] == '!' {
 		if len(pattern) == 1 {
-			return nil, errors.New("illegal exclusion pattern: \"!\"")
+			return errors.New("illegal exclusion pattern: \"!\"")
 		}
 		exclusion = true
 		pattern = pattern[1:]
 	}
 
-	matchType, regexp, err := Compile(pattern)
+	matchType, regexp, err := compile(pattern, style)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	p := &Pattern{
+	var segment *segmentMatcher
+	if matchType == RegexpMatch {
+		if isExtensionPattern(pattern, style.sep()) {
+			matchType = ExtensionMatch
+		} else if segment = compileSegment(pattern, style.sep()); segment != nil {
+			matchType = ClassMatch
+		}
+	}
+	literalPrefix := strings.IndexAny(pattern, `*?[\`)
+	if literalPrefix == -1 {
+		literalPrefix = len(pattern)
+	}
+
+	start := len(*dirSlab)
+	rest := pattern
+	for {
+		i := strings.IndexByte(rest, style.sep())
+		if i == -1 {
+			*dirSlab = append(*dirSlab, rest)
+			break
+		}
+		*dirSlab = append(*dirSlab, rest[:i])
+		rest = rest[i+1:]
+	}
+
+	*p = Pattern{
 		MatchType:      matchType,
 		CleanedPattern: pattern,
-		Dirs:           strings.Split(pattern, string(os.PathSeparator)),
+		Dirs:           (*dirSlab)[start:len(*dirSlab):len(*dirSlab)],
 		Regexp:         regexp,
 		Exclusion:      exclusion,
+		literalPrefix:  literalPrefix,
+		segment:        segment,
+		style:          style,
 	}
+	return nil
+}
 
-	return p, nil
+// Clone returns a copy of p that doesn't share any mutable state with it.
+//
+// The copy can be modified without affecting p, but its MatchType,
+// CleanedPattern, Dirs and Regexp must stay consistent with each other:
+// compile a new pattern with NewPattern to match something else.
+func (p *Pattern) Clone() *Pattern {
+	clone := *p
+	clone.Dirs = append([]string(nil), p.Dirs...)
+	return &clone
+}
+
+// Labels returns the labels attached to the pattern through its Source. The
+// returned map must not be modified.
+func (p *Pattern) Labels() map[string]string {
+	return p.labels
+}
+
+// Source returns the name of the Source the pattern comes from, or "" if it
+// wasn't created from one.
+func (p *Pattern) Source() string {
+	return p.source
 }
 
+// Index returns the position of the pattern in the list it was compiled
+// from, such as the patterns of its Source, starting at 0. The patterns a
+// brace expands into share the position of the brace.
+func (p *Pattern) Index() int {
+	return p.index
+}
+
+// Group returns the name of the group the pattern belongs to, or "".
+func (p *Pattern) Group() string {
+	return p.group
+}
+
+// Precedence returns the precedence of the Source of the pattern.
+func (p *Pattern) Precedence() Precedence {
+	return p.precedence
+}
+
+// String returns the pattern as it was compiled, including the leading "!"
+// of exclusions. Control characters are escaped if the pattern was created
+// with the ControlCharEscape policy.
+func (p *Pattern) String() string {
+	s := p.CleanedPattern
+	if p.Exclusion {
+		s = "!" + s
+	}
+	if !p.escapeControl || !hasControlChar(s) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			q := strconv.QuoteRune(r)
+			sb.WriteString(q[1 : len(q)-1])
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// outranks reports whether p takes precedence over q with the
+// MostSpecificWins resolution: whether it comes from a source with a higher
+// precedence, or is more specific, that is, has more path elements, or as
+// many and a longer literal prefix.
+func (p *Pattern) outranks(q *Pattern) bool {
+	if p.precedence != q.precedence {
+		return p.precedence > q.precedence
+	}
+	if len(p.Dirs) != len(q.Dirs) {
+		return len(p.Dirs) > len(q.Dirs)
+	}
+	return p.literalPrefix > q.literalPrefix
+}
+
+// Match returns true if path matches the pattern. It returns false if the
+// pattern wasn't compiled, such as a zero-value Pattern; use MatchE to get
+// an error in that case.
 func (p *Pattern) Match(path string) bool {
+	return p.compiled() && p.match(path)
+}
+
+// MatchE returns true if path matches the pattern. It returns an error
+// wrapping ErrPatternNotCompiled if the pattern wasn't compiled, such as a
+// zero-value Pattern.
+func (p *Pattern) MatchE(path string) (bool, error) {
+	if !p.compiled() {
+		return false, p.notCompiledError()
+	}
+	return p.match(path), nil
+}
+
+// MatchPath is like Match, but honors DirOnly, isDir reporting whether path
+// is a directory: a pattern ending with a path separator doesn't match a
+// file.
+func (p *Pattern) MatchPath(path string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	return p.Match(path)
+}
+
+// compiled reports whether p holds everything needed to evaluate its
+// MatchType.
+func (p *Pattern) compiled() bool {
+	if p == nil {
+		return false
+	}
+	switch p.MatchType {
+	case ExactMatch:
+		return true
+	case PrefixMatch, SuffixMatch, ExtensionMatch:
+		return len(p.CleanedPattern) >= 2
+	case RegexpMatch:
+		return p.Regexp != nil
+	case ClassMatch:
+		return p.segment != nil || p.Regexp != nil
+	}
+	return false
+}
+
+func (p *Pattern) notCompiledError() error {
+	if p == nil {
+		return &PatternError{Err: ErrPatternNotCompiled}
+	}
+	return &PatternError{Pattern: p.String(), Err: ErrPatternNotCompiled}
+}
+
+// checkPatterns returns an error if one of the patterns wasn't compiled.
+func checkPatterns(patterns []*Pattern) error {
+	for _, pattern := range patterns {
+		if !pattern.compiled() {
+			return pattern.notCompiledError()
+		}
+	}
+	return nil
+}
+
+func (p *Pattern) match(path string) bool {
+	return p.matchGlob(path) && p.satisfiesPath(path)
+}
+
+// matchGlob is match without the path predicates of p.
+func (p *Pattern) matchGlob(path string) bool {
 	switch p.MatchType {
 	case ExactMatch:
-		return path == p.CleanedPattern
+		return equalLiteral(path, p.CleanedPattern)
 	case PrefixMatch:
 		// strip trailing **
-		return strings.HasPrefix(path, p.CleanedPattern[:len(p.CleanedPattern)-2])
+		return hasPrefixLiteral(path, p.CleanedPattern[:len(p.CleanedPattern)-2])
 	case SuffixMatch:
 		// strip leading **
 		suffix := p.CleanedPattern[2:]
-		if strings.HasSuffix(path, suffix) {
+		if hasSuffixLiteral(path, suffix) {
 			return true
 		}
 		// **/foo matches "foo"
-		return suffix[0] == os.PathSeparator && path == suffix[1:]
+		return suffix[0] == p.style.sep() && equalLiteral(path, suffix[1:])
 	case RegexpMatch:
-		return p.Regexp.MatchString(path)
+		return p.matchRegexp(p.Regexp, path)
+	case ClassMatch:
+		if p.segment == nil {
+			return p.matchRegexp(p.Regexp, path)
+		}
+		return p.segment.match(path)
+	case ExtensionMatch:
+		// strip leading *
+		return strings.HasSuffix(path, p.CleanedPattern[1:]) && strings.IndexByte(path, p.style.sep()) == -1
 	}
 
 	return false
 }
 
+// isExtensionPattern reports whether pattern is made of "*" followed by a
+// literal suffix of a name, such as "*.go", sep being the separator.
+func isExtensionPattern(pattern string, sep byte) bool {
+	return len(pattern) > 1 && pattern[0] == '*' &&
+		!strings.ContainsAny(pattern[1:], `*?[\`) &&
+		strings.IndexByte(pattern, sep) == -1
+}
+
 func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
-	pathSeparator := string(os.PathSeparator)
-	regStr := "^"
+	return compile(pattern, HostPaths)
+}
+
+// compile is Compile for a pattern of style.
+func compile(pattern string, style PathStyle) (MatchType, *regexp.Regexp, error) {
+	if !strings.ContainsAny(pattern, `*?[]\`) {
+		// Literal patterns, such as those of machine-generated allowlists,
+		// don't need to be scanned.
+		return ExactMatch, nil, nil
+	}
+	pathSeparator := string(style.sep())
+	var regStr strings.Builder
+	regStr.Grow(2 * len(pattern))
+	regStr.WriteByte('^')
 	// Go through the pattern and convert it to a regexp.
 	// We use a scanner so we can support utf-8 chars.
 	var scan scanner.Scanner
@@ -247,9 +929,17 @@ func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
 	}
 
 	matchType := ExactMatch
+	inClass := false
 	for i := 0; scan.Peek() != scanner.EOF; i++ {
 		ch := scan.Next()
 
+		if inClass && (ch == '*' || ch == '?') {
+			// Wildcards match themselves in classes, as with
+			// filepath.Match.
+			regStr.WriteRune(ch)
+			continue
+		}
+
 		if ch == '*' {
 			if scan.Peek() == '*' {
 				// is some flavor of "**"
@@ -265,14 +955,14 @@ func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
 					if matchType == ExactMatch {
 						matchType = PrefixMatch
 					} else {
-						regStr += ".*"
+						regStr.WriteString(".*")
 						matchType = RegexpMatch
 					}
 				} else {
 					// is "**"
 					// Note that this allows for any # of /'s (even 0) because
 					// the .* will eat everything, even /'s
-					regStr += "(.*" + escapedPathSeparator + ")?"
+					regStr.WriteString("(.*" + escapedPathSeparator + ")?")
 					matchType = RegexpMatch
 				}
 
@@ -281,17 +971,18 @@ func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
 				}
 			} else {
 				// is "*" so map it to anything but "/"
-				regStr += "[^" + escapedPathSeparator + "]*"
+				regStr.WriteString("[^" + escapedPathSeparator + "]*")
 				matchType = RegexpMatch
 			}
 		} else if ch == '?' {
 			// "?" is any char except "/"
-			regStr += "[^" + escapedPathSeparator + "]"
+			regStr.WriteString("[^" + escapedPathSeparator + "]")
 			matchType = RegexpMatch
 		} else if shouldEscape(ch) {
 			// Escape some regexp special chars that have no meaning
 			// in golang's filepath.Match
-			regStr += `\` + string(ch)
+			regStr.WriteByte('\\')
+			regStr.WriteRune(ch)
 		} else if ch == '\\' {
 			// escape next char. Note that a trailing \ in the pattern
 			// will be left alone (but need to escape it)
@@ -299,20 +990,28 @@ func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
 				// On windows map "\" to "\\", meaning an escaped backslash,
 				// and then just continue because filepath.Match on
 				// Windows doesn't allow escaping at all
-				regStr += escapedPathSeparator
+				regStr.WriteString(escapedPathSeparator)
 				continue
 			}
 			if scan.Peek() != scanner.EOF {
-				regStr += `\` + string(scan.Next())
+				regStr.WriteByte('\\')
+				regStr.WriteRune(scan.Next())
 				matchType = RegexpMatch
 			} else {
-				regStr += `\`
+				regStr.WriteByte('\\')
 			}
+		} else if ch == '[' && scan.Peek() == '!' {
+			// Like in shells, "[!" negates the class as "[^" does.
+			scan.Next()
+			regStr.WriteString("[^")
+			matchType = RegexpMatch
+			inClass = true
 		} else if ch == '[' || ch == ']' {
-			regStr += string(ch)
+			regStr.WriteRune(ch)
 			matchType = RegexpMatch
+			inClass = ch == '['
 		} else {
-			regStr += string(ch)
+			regStr.WriteRune(ch)
 		}
 	}
 
@@ -320,12 +1019,92 @@ func Compile(pattern string) (MatchType, *regexp.Regexp, error) {
 		return matchType, nil, nil
 	}
 
-	regStr += "$"
+	regStr.WriteByte('$')
 
-	re, err := regexp.Compile(regStr)
+	re, err := regexp.Compile(regStr.String())
 	if err != nil {
 		return UnknownMatch, nil, err
 	}
 
 	return matchType, re, nil
 }
+
+// shellClasses converts the classes of pattern negated with "[!" to the
+// "[^" syntax of filepath.Match, escapes reporting whether backslashes
+// escape the next character.
+func shellClasses(pattern string, escapes bool) string {
+	if !strings.Contains(pattern, "[!") {
+		return pattern
+	}
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && escapes && i+1 < len(pattern):
+			sb.WriteString(pattern[i : i+2])
+			i++
+		case c == '[' && i+1 < len(pattern) && pattern[i+1] == '!':
+			sb.WriteString("[^")
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// literalBrackets rewrites the opening brackets of pattern as a class
+// matching an opening bracket, so that it has no classes left. Closing
+// brackets outside of classes already match themselves. Characters escaped
+// with a backslash if escapes is set, or with esc if it isn't 0, are left
+// alone.
+func literalBrackets(pattern string, esc rune, escapes bool) string {
+	if !strings.Contains(pattern, "[") {
+		return pattern
+	}
+	var sb strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			sb.WriteRune(r)
+		case r == '\\' && escapes, esc != 0 && r == esc:
+			escaped = true
+			sb.WriteRune(r)
+		case r == '[':
+			sb.WriteString("[[]")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// escapeWildcards rewrites the wildcards of pattern escaped with esc as
+// classes matching them, which works on every OS.
+func escapeWildcards(pattern string, esc rune) string {
+	if !strings.ContainsRune(pattern, esc) {
+		return pattern
+	}
+	var sb strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			if r == '*' || r == '?' || r == '[' {
+				sb.WriteByte('[')
+				sb.WriteRune(r)
+				sb.WriteByte(']')
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == esc:
+			escaped = true
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -1,6 +1,7 @@
 package patternmatcher
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -152,6 +153,11 @@ func TestMatches(t *testing.T) {
 		{"**/**/*.txt2", "dir/dir/file.txt", false},
 		{"**/*.txt", "file.txt", true},
 		{"**/**/*.txt", "file.txt", true},
+		{"*.txt", "file.txt", true},
+		{"*.txt", ".txt", true},
+		{"*.txt", "filetxt", false},
+		{"*.txt", "dir/file.txt", false},
+		{"*.txt", "file.txt/dir", true},
 		{"a**/*.txt", "a/file.txt", true},
 		{"a**/*.txt", "a/dir/file.txt", true},
 		{"a**/*.txt", "a/dir/dir/file.txt", true},
@@ -422,6 +428,14 @@ var matchTests = []matchTest{
 	{"a[", "a", false, filepath.ErrBadPattern}, // was nil but IMO its wrong
 	{"a[", "ab", false, filepath.ErrBadPattern},
 	{"*x", "xxx", true, nil},
+	{"[!abc]", "d", true, nil},
+	{"[!abc]", "b", false, nil},
+	{"[!abc]", "!", true, nil},
+	{"a[!0-9]", "ax", true, nil},
+	{"a[!0-9]", "a5", false, nil},
+	{"a[!0-9]/b", "ax/b", true, nil},
+	{"[!", "a", false, filepath.ErrBadPattern},
+	{"[!]", "a", false, filepath.ErrBadPattern},
 }
 
 func errp(e error) string {
@@ -461,7 +475,8 @@ type compileTestCase struct {
 var compileTests = []compileTestCase{
 	{"*", RegexpMatch, `^[^/]*$`, `^[^\\]*$`},
 	{"file*", RegexpMatch, `^file[^/]*$`, `^file[^\\]*$`},
-	{"*file", RegexpMatch, `^[^/]*file$`, `^[^\\]*file$`},
+	{"*file", ExtensionMatch, "", ""},
+	{"*.go", ExtensionMatch, "", ""},
 	{"a*/b", RegexpMatch, `^a[^/]*/b$`, `^a[^\\]*\\b$`},
 	{"**", SuffixMatch, "", ""},
 	{"**/**", RegexpMatch, `^(.*/)?.*$`, `^(.*\\)?.*$`},
@@ -472,7 +487,8 @@ var compileTests = []compileTestCase{
 	{"**file", SuffixMatch, "", ""},
 	{"**/file*txt", RegexpMatch, `^(.*/)?file[^/]*txt$`, `^(.*\\)?file[^\\]*txt$`},
 	{"**/**/*.txt", RegexpMatch, `^(.*/)?(.*/)?[^/]*\.txt$`, `^(.*\\)?(.*\\)?[^\\]*\.txt$`},
-	{"a[b-d]e", RegexpMatch, `^a[b-d]e$`, `^a[b-d]e$`},
+	{"a[b-d]e", ClassMatch, `^a[b-d]e$`, `^a[b-d]e$`},
+	{"a[*?]e", ClassMatch, `^a[*?]e$`, `^a[*?]e$`},
 	{".*", RegexpMatch, `^\.[^/]*$`, `^\.[^\\]*$`},
 	{"abc.def", ExactMatch, "", ""},
 	{"abc?def", RegexpMatch, `^abc[^/]def$`, `^abc[^\\]def$`},
@@ -528,3 +544,251 @@ func matches(file string, patterns []string) (bool, error) {
 
 	return matched, nil
 }
+
+func TestParentRefPolicy(t *testing.T) {
+	if _, err := NewPatterns([]string{"../secrets/**"}); err != nil {
+		t.Fatalf("expected parent references to be allowed by default, got %v", err)
+	}
+
+	for _, p := range []string{"../secrets/**", "!../secrets", "a/../../b", ".."} {
+		_, err := NewPatterns([]string{p}, WithParentRefPolicy(ParentRefReject))
+		if !errors.Is(err, ErrPatternEscapesRoot) {
+			t.Errorf("pattern %q: expected ErrPatternEscapesRoot, got %v", p, err)
+		}
+	}
+	if _, err := NewPatterns([]string{"a/../b"}, WithParentRefPolicy(ParentRefReject)); err != nil {
+		t.Errorf("expected pattern not escaping the root to be accepted, got %v", err)
+	}
+
+	anchorTests := []struct {
+		pattern, cleaned string
+		exclusion        bool
+	}{
+		{"../secrets/**", "secrets/**", false},
+		{"../../a/b", "a/b", false},
+		{"!../secrets", "secrets", true},
+		{"..", ".", false},
+	}
+	for _, tt := range anchorTests {
+		patterns, err := NewPatterns([]string{tt.pattern}, WithParentRefPolicy(ParentRefAnchor))
+		if err != nil {
+			t.Fatalf("pattern %q: %v", tt.pattern, err)
+		}
+		want := filepath.FromSlash(tt.cleaned)
+		if patterns[0].CleanedPattern != want || patterns[0].Exclusion != tt.exclusion {
+			t.Errorf("pattern %q: got (%q, %v), want (%q, %v)", tt.pattern, patterns[0].CleanedPattern, patterns[0].Exclusion, want, tt.exclusion)
+		}
+	}
+}
+
+func TestCleanPolicy(t *testing.T) {
+	altered := []string{"./foo", "a//b", "dir/", "a/../b", "!./foo", "!a//b"}
+	unaltered := []string{"foo", "a/b/*.go", "!foo", "**", "../x"}
+
+	var warnings []error
+	warn := WithWarnings(func(err error) { warnings = append(warnings, err) })
+	if _, err := NewPatterns(append(altered, unaltered...), WithCleanPolicy(CleanWarn), warn); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != len(altered) {
+		t.Fatalf("expected %d warnings, got %q", len(altered), warnings)
+	}
+	for i, err := range warnings {
+		var perr *PatternError
+		if !errors.As(err, &perr) || perr.Pattern != altered[i] || !errors.Is(err, ErrPatternCleaned) {
+			t.Errorf("pattern %q: unexpected warning %v", altered[i], err)
+		}
+	}
+
+	for _, p := range altered {
+		if _, err := NewPatterns([]string{p}, WithCleanPolicy(CleanReject)); !errors.Is(err, ErrPatternCleaned) {
+			t.Errorf("pattern %q: expected ErrPatternCleaned, got %v", p, err)
+		}
+	}
+	if _, err := NewPatterns(unaltered, WithCleanPolicy(CleanReject)); err != nil {
+		t.Errorf("expected clean patterns to be accepted, got %v", err)
+	}
+	if _, err := NewPatterns(altered); err != nil {
+		t.Errorf("expected patterns to be cleaned silently by default, got %v", err)
+	}
+}
+
+func TestControlCharPolicy(t *testing.T) {
+	_, err := NewPatterns([]string{"foo\x00bar"}, WithControlCharPolicy(ControlCharReject))
+	if !errors.Is(err, ErrControlCharacter) {
+		t.Errorf("expected ErrControlCharacter, got %v", err)
+	}
+
+	patterns, err := NewPatterns([]string{"!foo\nbar"}, WithControlCharPolicy(ControlCharEscape))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := patterns[0].String(); s != `!foo\nbar` {
+		t.Errorf("expected control characters to be escaped, got %q", s)
+	}
+
+	patterns, err = NewPatterns([]string{"foo\nbar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := patterns[0].String(); s != "foo\nbar" {
+		t.Errorf("expected control characters to be passed through, got %q", s)
+	}
+	if match, _ := MatchesOrParentMatches(patterns, "foo\nbar"); !match {
+		t.Errorf("expected pattern with control characters to match")
+	}
+}
+
+func TestNewPatternsSlabs(t *testing.T) {
+	lines := []string{"docs", "  !docs/README.md", "", "a/b/c/*.go", "**/vendor"}
+	patterns, err := NewPatterns(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range patterns {
+		want, err := NewPattern(p.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.CleanedPattern != want.CleanedPattern || p.Exclusion != want.Exclusion || strings.Join(p.Dirs, "|") != strings.Join(want.Dirs, "|") {
+			t.Errorf("pattern %q: got %+v, want %+v", p, p, want)
+		}
+	}
+
+	// Appending to the dirs of a pattern must not overwrite those of the
+	// next one.
+	_ = append(patterns[0].Dirs, "x")
+	if patterns[1].Dirs[0] != "docs" {
+		t.Errorf("dirs of patterns share storage: got %q", patterns[1].Dirs)
+	}
+}
+
+func BenchmarkNewPatterns(b *testing.B) {
+	lines := make([]string, 10000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("dir%d/sub%d/*.txt", i%100, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewPatterns(lines); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMatchE(t *testing.T) {
+	invalid := []*Pattern{
+		nil,
+		{},
+		{MatchType: RegexpMatch, CleanedPattern: "*.go"},
+		{MatchType: PrefixMatch, CleanedPattern: "*"},
+		{MatchType: ClassMatch, CleanedPattern: "[ab]"},
+	}
+	for _, p := range invalid {
+		if p.Match("main.go") {
+			t.Errorf("pattern %#v: expected no match", p)
+		}
+		if _, err := p.MatchE("main.go"); !errors.Is(err, ErrPatternNotCompiled) {
+			t.Errorf("pattern %#v: expected ErrPatternNotCompiled, got %v", p, err)
+		}
+
+		patterns, err := NewPatterns([]string{"docs"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		patterns = append(patterns, p)
+		if _, err := MatchesOrParentMatches(patterns, "main.go"); !errors.Is(err, ErrPatternNotCompiled) {
+			t.Errorf("pattern %#v: expected ErrPatternNotCompiled from MatchesOrParentMatches, got %v", p, err)
+		}
+		if _, _, err := MatchesUsingParentResults(patterns, "main.go", nil); !errors.Is(err, ErrPatternNotCompiled) {
+			t.Errorf("pattern %#v: expected ErrPatternNotCompiled from MatchesUsingParentResults, got %v", p, err)
+		}
+	}
+
+	p, err := NewPattern("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := p.MatchE("main.go"); err != nil || !match {
+		t.Errorf("expected match, got %v, %v", match, err)
+	}
+}
+
+func TestPatternClone(t *testing.T) {
+	p, err := NewPattern(filepath.FromSlash("docs/*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := p.Clone()
+	if clone == p || clone.String() != p.String() || clone.Regexp != p.Regexp {
+		t.Fatalf("expected an equivalent copy, got %+v", clone)
+	}
+
+	clone.Exclusion = true
+	clone.Dirs[0] = "other"
+	if p.Exclusion || p.Dirs[0] != "docs" {
+		t.Errorf("modifying the clone modified the original pattern: %+v", p)
+	}
+	if !clone.Match(filepath.FromSlash("docs/README.md")) {
+		t.Errorf("expected clone to match")
+	}
+}
+
+func BenchmarkExtensionMatch(b *testing.B) {
+	p, err := NewPattern("*.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("extension", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Match("patternmatcher.go")
+		}
+	})
+	b.Run("regexp", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Regexp.MatchString("patternmatcher.go")
+		}
+	})
+}
+
+func TestMustCompilePatterns(t *testing.T) {
+	patterns := MustCompilePatterns("docs", "!docs/README.md")
+	if len(patterns) != 2 || !patterns[1].Exclusion {
+		t.Fatalf("unexpected patterns %q", patterns)
+	}
+	if matched, _ := MustNew([]string{"docs"}).MatchesOrParentMatches("docs/a.md"); !matched {
+		t.Error("expected docs/a.md to match")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected MustCompilePatterns to panic")
+		}
+	}()
+	MustCompilePatterns("!")
+}
+
+func TestMatchPath(t *testing.T) {
+	patterns, err := NewPatterns([]string{"build/", "*.o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirPattern, filePattern := patterns[0], patterns[1]
+	if !dirPattern.DirOnly || filePattern.DirOnly {
+		t.Fatalf("unexpected DirOnly: %v, %v", dirPattern.DirOnly, filePattern.DirOnly)
+	}
+	if !dirPattern.MatchPath("build", true) {
+		t.Error("expected the build directory to match")
+	}
+	if dirPattern.MatchPath("build", false) {
+		t.Error("expected a file named build not to match")
+	}
+	if !filePattern.MatchPath("main.o", false) || !filePattern.MatchPath("main.o", true) {
+		t.Error("expected main.o to match")
+	}
+	// Match doesn't know whether paths are directories.
+	if !dirPattern.Match("build") {
+		t.Error("expected Match to ignore DirOnly")
+	}
+}
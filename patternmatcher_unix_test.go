@@ -24,10 +24,21 @@ func TestCompile(t *testing.T) {
 			t.Errorf("pattern %q: matchType = %v, want %v", pattern, newp.MatchType, tt.matchType)
 			continue
 		}
-		if tt.matchType == RegexpMatch {
+		if tt.matchType == RegexpMatch || tt.matchType == ClassMatch {
 			if newp.Regexp.String() != tt.compiledRegexp {
 				t.Errorf("pattern %q: regexp = %s, want %s", pattern, newp.Regexp, tt.compiledRegexp)
 			}
 		}
 	}
 }
+
+func TestParseASTEscapes(t *testing.T) {
+	ast, err := ParseAST(`a\*b[\]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := ast.Segments[0].Nodes
+	if len(nodes) != 2 || nodes[0].Value != "a*b" || nodes[0].End != 4 || nodes[1].Ranges[0] != (ClassRange{']', ']'}) {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+}
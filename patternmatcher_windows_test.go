@@ -25,7 +25,7 @@ func TestWindowsCompile(t *testing.T) {
 			t.Errorf("pattern %q: matchType = %v, want %v", pattern, newp.MatchType, tt.matchType)
 			continue
 		}
-		if tt.matchType == RegexpMatch {
+		if tt.matchType == RegexpMatch || tt.matchType == ClassMatch {
 			if pathSeparator == `\` {
 				if newp.Regexp.String() != tt.windowsCompiledRegexp {
 					t.Errorf("pattern %q: regexp = %s, want %s", pattern, newp.Regexp, tt.windowsCompiledRegexp)
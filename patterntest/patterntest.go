@@ -0,0 +1,225 @@
+// Package patterntest generates random file trees and pattern lists, and
+// checks implementations of the patterns of this module, or of dialects
+// derived from them, against a slow reference matcher.
+package patterntest
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+)
+
+// Case is a list of patterns along with paths to match against them.
+type Case struct {
+	Patterns []string
+	// Paths are the slash-separated paths of the files of a tree.
+	Paths []string
+}
+
+// FS returns a file system made of the files of c.
+func (c Case) FS() fstest.MapFS {
+	fsys := make(fstest.MapFS, len(c.Paths))
+	for _, p := range c.Paths {
+		fsys[p] = &fstest.MapFile{}
+	}
+	return fsys
+}
+
+// Config controls the generation of cases. Zero fields take a default.
+type Config struct {
+	// Files is the maximum number of files of a tree.
+	Files int
+	// Depth is the maximum number of elements of a path.
+	Depth int
+	// Patterns is the maximum number of patterns of a case.
+	Patterns int
+	// Names are the names of the files and directories of trees. Small
+	// sets of names make patterns match more often.
+	Names []string
+}
+
+var defaultNames = []string{"a", "b", "ab", "ba", "x.go", "y.md", ".git", "a.b"}
+
+func (c *Config) withDefaults() Config {
+	cfg := *c
+	if cfg.Files <= 0 {
+		cfg.Files = 20
+	}
+	if cfg.Depth <= 0 {
+		cfg.Depth = 4
+	}
+	if cfg.Patterns <= 0 {
+		cfg.Patterns = 6
+	}
+	if len(cfg.Names) == 0 {
+		cfg.Names = defaultNames
+	}
+	return cfg
+}
+
+// RandomCase generates a random tree, and patterns derived from its paths so
+// that they match some of them.
+func RandomCase(r *rand.Rand, cfg Config) Case {
+	cfg = cfg.withDefaults()
+	seen := make(map[string]bool)
+	var c Case
+	for i := 1 + r.Intn(cfg.Files); i > 0; i-- {
+		elems := make([]string, 1+r.Intn(cfg.Depth))
+		for j := range elems {
+			elems[j] = cfg.Names[r.Intn(len(cfg.Names))]
+		}
+		p := strings.Join(elems, "/")
+		if seen[p] || conflicts(seen, p) {
+			continue
+		}
+		seen[p] = true
+		c.Paths = append(c.Paths, p)
+	}
+	for i := 1 + r.Intn(cfg.Patterns); i > 0; i-- {
+		c.Patterns = append(c.Patterns, randomPattern(r, c.Paths[r.Intn(len(c.Paths))]))
+	}
+	return c
+}
+
+// conflicts reports whether p is a parent of a path of seen, or one of them
+// is a parent of p, which would make the tree impossible.
+func conflicts(seen map[string]bool, p string) bool {
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		if seen[dir] {
+			return true
+		}
+	}
+	for q := range seen {
+		if strings.HasPrefix(q, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// randomPattern derives a pattern from a prefix of the path p, replacing
+// some of its elements with wildcards.
+func randomPattern(r *rand.Rand, p string) string {
+	elems := strings.Split(p, "/")
+	elems = elems[:1+r.Intn(len(elems))]
+	var out []string
+	for _, elem := range elems {
+		switch r.Intn(8) {
+		case 0:
+			out = append(out, "*")
+		case 1:
+			out = append(out, "**", elem)
+		case 2:
+			i := r.Intn(len(elem))
+			out = append(out, elem[:i]+"?"+elem[i+1:])
+		case 3:
+			out = append(out, elem[:r.Intn(len(elem))]+"*")
+		case 4:
+			i := r.Intn(len(elem))
+			out = append(out, elem[:i]+"["+elem[i:i+1]+"xy]"+elem[i+1:])
+		default:
+			out = append(out, elem)
+		}
+	}
+	pattern := strings.Join(out, "/")
+	if r.Intn(3) == 0 {
+		pattern = "!" + pattern
+	}
+	return pattern
+}
+
+// Reference reports whether path, or one of its parent directories, matches
+// patterns, where the last matching pattern decides and patterns starting
+// with "!" are exclusions. It evaluates patterns with a backtracking matcher
+// which is slow but simple, for comparison with optimized implementations.
+//
+// The syntax supported is that of the patterns generated by RandomCase:
+// "*", "?", character classes, and "**" as a whole path element.
+func Reference(patterns []string, p string) (bool, error) {
+	p = path.Clean(p)
+	if p == "." {
+		return false, nil
+	}
+	elems := strings.Split(p, "/")
+	matched := false
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		exclusion := strings.HasPrefix(pattern, "!")
+		if exclusion {
+			pattern = pattern[1:]
+		}
+		pattern = filepath.ToSlash(filepath.Clean(filepath.FromSlash(pattern)))
+		for i := len(elems); i > 0; i-- {
+			ok, err := matchElems(strings.Split(pattern, "/"), elems[:i])
+			if err != nil {
+				return false, fmt.Errorf("%q: %w", pattern, err)
+			}
+			if ok {
+				matched = !exclusion
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// matchElems matches the elements of a path against those of a pattern.
+func matchElems(pattern, elems []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(elems) == 0, nil
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return true, nil
+		}
+		for i := 0; i <= len(elems); i++ {
+			if ok, err := matchElems(pattern[1:], elems[i:]); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(elems) == 0 {
+		return false, nil
+	}
+	ok, err := path.Match(pattern[0], elems[0])
+	if !ok || err != nil {
+		return false, err
+	}
+	return matchElems(pattern[1:], elems[1:])
+}
+
+// MatchFunc compiles patterns and matches p against them, with the semantics
+// of Reference.
+type MatchFunc func(patterns []string, p string) (bool, error)
+
+// Check generates n cases with r and cfg, and compares the results of match
+// with those of Reference for every path of the tree of each case and their
+// parent directories. It returns an error describing the first difference.
+func Check(r *rand.Rand, cfg Config, n int, match MatchFunc) error {
+	for i := 0; i < n; i++ {
+		c := RandomCase(r, cfg)
+		for _, p := range c.Paths {
+			for ; p != "."; p = path.Dir(p) {
+				want, err := Reference(c.Patterns, p)
+				if err != nil {
+					return err
+				}
+				got, err := match(c.Patterns, p)
+				if err != nil {
+					return fmt.Errorf("patterns %q, path %q: %w", c.Patterns, p, err)
+				}
+				if got != want {
+					return fmt.Errorf("patterns %q, path %q: got %v, want %v", c.Patterns, p, got, want)
+				}
+			}
+		}
+	}
+	return nil
+}
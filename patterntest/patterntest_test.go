@@ -0,0 +1,68 @@
+package patterntest
+
+import (
+	"io/fs"
+	"math/rand"
+	"testing"
+
+	"github.com/moby/patternmatcher"
+)
+
+func TestReference(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		pass     bool
+	}{
+		{[]string{"a"}, "a/b/c", true},
+		{[]string{"a", "!a/b"}, "a/b/c", false},
+		{[]string{"**/c"}, "a/b/c", true},
+		{[]string{"**/c"}, "c", true},
+		{[]string{"a/**/c"}, "a/c", true},
+		{[]string{"*/b"}, "a/b/c", true},
+		{[]string{"a?"}, "ab/c", true},
+		{[]string{"[ab]"}, "b", true},
+		{[]string{"**"}, "a", true},
+		{[]string{"b"}, "a/b", false},
+	}
+	for _, tt := range tests {
+		got, err := Reference(tt.patterns, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.pass {
+			t.Errorf("patterns %q, path %q: expected %v, got %v", tt.patterns, tt.path, tt.pass, got)
+		}
+	}
+}
+
+func TestRandomCase(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		c := RandomCase(r, Config{})
+		if len(c.Paths) == 0 || len(c.Patterns) == 0 {
+			t.Fatalf("empty case %+v", c)
+		}
+		if err := fs.WalkDir(c.FS(), ".", func(string, fs.DirEntry, error) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPatternMatcher(t *testing.T) {
+	for _, opts := range [][]patternmatcher.Option{
+		nil,
+		{patternmatcher.WithSinglePassMatching()},
+	} {
+		match := func(patterns []string, p string) (bool, error) {
+			pm, err := patternmatcher.New(patterns, opts...)
+			if err != nil {
+				return false, err
+			}
+			return pm.MatchesOrParentMatches(p)
+		}
+		if err := Check(rand.New(rand.NewSource(1)), Config{}, 500, match); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package patternmatcher
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PatternProfile is the cost of a pattern over a workload of paths.
+type PatternProfile struct {
+	Pattern *Pattern
+	// Evaluations is the number of paths the pattern was evaluated
+	// against.
+	Evaluations int
+	// Elapsed is the total time spent evaluating the pattern.
+	Elapsed time.Duration
+	// Decided is the number of paths whose result the pattern decided.
+	Decided int
+	// Suggestion describes how to rewrite the pattern to make it cheaper,
+	// or is empty if there is no known rewrite.
+	Suggestion string
+}
+
+// Profile matches each of paths with pm and reports the cost of each pattern,
+// the most expensive first, so that pathological patterns of an ignore file
+// can be found and rewritten. Patterns which are never evaluated, because
+// they are skipped for every path, are reported with no cost.
+func (pm *PatternMatcher) Profile(paths []string) ([]PatternProfile, error) {
+	prof := &profiler{profiles: make(map[*Pattern]*PatternProfile)}
+	o := *pm.baseOpts
+	o.instrumentation = prof
+	profiled, err := newPatternMatcher(pm.all, &o, pm.disabled)
+	if err != nil {
+		return nil, err
+	}
+	// An inverted matcher evaluates copies of its patterns, which differ
+	// between pm and profiled, so both are mapped to the same profile.
+	for i, p := range pm.patterns {
+		profile := &PatternProfile{Pattern: p, Suggestion: suggestRewrite(p)}
+		prof.profiles[p] = profile
+		prof.profiles[profiled.patterns[i]] = profile
+	}
+	for _, path := range paths {
+		if _, err := profiled.MatchesOrParentMatches(path); err != nil {
+			return nil, err
+		}
+		e := &evaluation{needPattern: true}
+		if _, decider := pm.matchesOrParentMatches(path, e); decider != nil {
+			prof.profiles[decider].Decided++
+		}
+	}
+
+	profiles := make([]PatternProfile, len(pm.patterns))
+	for i, p := range pm.patterns {
+		profiles[i] = *prof.profiles[p]
+	}
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Elapsed > profiles[j].Elapsed
+	})
+	return profiles, nil
+}
+
+// Optimize returns a PatternMatcher with the patterns of pm reordered so
+// that those which decide the most paths according to profiles, as
+// returned by Profile for a representative workload, are evaluated first.
+// Patterns only move within runs of consecutive patterns of the same kind
+// and precedence, which leaves the results unchanged, although another
+// pattern of a run may be reported as deciding a path. Patterns missing
+// from profiles keep their place relative to each other, after the profiled
+// ones of their run.
+//
+// Evaluating patterns in a different order only saves work with the
+// LastMatchWins resolution, so pm is returned as is with MostSpecificWins.
+func (pm *PatternMatcher) Optimize(profiles []PatternProfile) (*PatternMatcher, error) {
+	if pm.opts.resolution == MostSpecificWins {
+		return pm, nil
+	}
+	// The profiles of an inverted pm report its copies of the patterns.
+	original := make(map[*Pattern]*Pattern, len(pm.patterns))
+	i := 0
+	for _, p := range pm.all {
+		if !pm.disabled[p.group] {
+			original[pm.patterns[i]] = p
+			i++
+		}
+	}
+	decided := make(map[*Pattern]int, len(profiles))
+	for _, prof := range profiles {
+		p := prof.Pattern
+		if o, ok := original[p]; ok {
+			p = o
+		}
+		decided[p] = prof.Decided + 1
+	}
+	all := append([]*Pattern(nil), pm.all...)
+	for start := 0; start < len(all); {
+		end := start + 1
+		for end < len(all) && all[end].Exclusion == all[start].Exclusion && all[end].precedence == all[start].precedence {
+			end++
+		}
+		run := all[start:end]
+		sort.SliceStable(run, func(i, j int) bool {
+			return decided[run[i]] > decided[run[j]]
+		})
+		start = end
+	}
+	return newPatternMatcher(all, pm.baseOpts, pm.disabled)
+}
+
+// profiler is the Instrumentation used by Profile.
+type profiler struct {
+	mu       sync.Mutex
+	profiles map[*Pattern]*PatternProfile
+}
+
+func (p *profiler) PatternEvaluated(pattern *Pattern, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prof := p.profiles[pattern]
+	prof.Evaluations++
+	prof.Elapsed += elapsed
+}
+
+func (p *profiler) WalkDone(WalkStats) {}
+
+// suggestRewrite returns a rewrite of the common patterns which are
+// expensive to evaluate, or "" if there is none.
+func suggestRewrite(p *Pattern) string {
+	sep := string(p.style.sep())
+	switch {
+	case !p.Exclusion && (p.CleanedPattern == "**" || p.CleanedPattern == "*" || p.CleanedPattern == "**"+sep+"*"):
+		return "matches every path; replace it with WithDefaultMatch(true)"
+	case strings.Count(p.CleanedPattern, "**") > 1:
+		return `several "**" elements backtrack on deep paths; anchor the pattern to a directory or keep a single "**"`
+	case p.MatchType == RegexpMatch && strings.HasSuffix(p.CleanedPattern, sep+"*") && !strings.ContainsAny(p.CleanedPattern[:len(p.CleanedPattern)-2], `*?[\`):
+		return "equivalent to the cheaper prefix pattern " + p.CleanedPattern + "*"
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package patternmatcher
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	pm, err := New([]string{"**", "!**/a/**/b/**/*.go", "vendor/*", "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"a/x/b/y/main.go", "vendor/lib/x.go", "docs/index.md", "README.md"}
+	profiles, err := pm.Profile(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 4 {
+		t.Fatalf("expected 4 profiles, got %d", len(profiles))
+	}
+	for i := 1; i < len(profiles); i++ {
+		if profiles[i].Elapsed > profiles[i-1].Elapsed {
+			t.Errorf("expected profiles sorted by decreasing cost")
+		}
+	}
+	suggested := make(map[string]bool)
+	for _, prof := range profiles {
+		if prof.Evaluations == 0 && prof.Elapsed != 0 {
+			t.Errorf("%s: unexpected cost without evaluations", prof.Pattern)
+		}
+		suggested[filepath.ToSlash(prof.Pattern.String())] = prof.Suggestion != ""
+	}
+	want := map[string]bool{"**": true, "!**/a/**/b/**/*.go": true, "vendor/*": true, "docs": false}
+	for pattern, s := range want {
+		if suggested[pattern] != s {
+			t.Errorf("%s: expected a suggestion %v, got %v", pattern, s, suggested[pattern])
+		}
+	}
+}
+
+func TestProfileInverted(t *testing.T) {
+	pm := MustNew([]string{"a", "!a/b"}).Invert()
+	profiles, err := pm.Profile([]string{"a/x", "a/b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decided := make(map[string]int)
+	for _, prof := range profiles {
+		if prof.Evaluations == 0 {
+			t.Errorf("%s: expected evaluations", prof.Pattern)
+		}
+		decided[prof.Pattern.String()] = prof.Decided
+	}
+	if want := map[string]int{"!a": 1, "a/b": 1}; !reflect.DeepEqual(decided, want) {
+		t.Errorf("expected decisions %v, got %v", want, decided)
+	}
+
+	optimized, err := pm.Optimize(profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"a/x", "a/b", "c"} {
+		want, _ := pm.MatchesOrParentMatches(path)
+		if got, err := optimized.MatchesOrParentMatches(path); err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v, %v", path, want, got, err)
+		}
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	pm, err := New([]string{"docs", "*.log", "node_modules", "!node_modules/keep.js", "build", "dist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"node_modules/a.js", "node_modules/b.js", "a.log", "dist/app.js", "dist/app.css", "dist/app.map", "main.go", "node_modules/keep.js"}
+	profiles, err := pm.Profile(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimized, err := pm.Optimize(profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, p := range optimized.Patterns() {
+		order = append(order, filepath.ToSlash(p.String()))
+	}
+	want := []string{"node_modules", "*.log", "docs", "!node_modules/keep.js", "dist", "build"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected %q, got %q", want, order)
+	}
+	for _, path := range append(paths, "docs/x", "build/y", "node_modules") {
+		want, _ := pm.MatchesOrParentMatches(path)
+		if got, _ := optimized.MatchesOrParentMatches(path); got != want {
+			t.Errorf("%q: expected %v, got %v", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package patternmatcher
+
+import (
+	"strings"
+)
+
+// Rebase returns a PatternMatcher whose patterns apply to the paths of the
+// subdirectory dir, relative to it, such as those of fs.Sub(fsys, dir): the
+// returned matcher matches "x" if pm matches dir/x. Patterns matching dir or
+// one of its parents match every path of the subdirectory, and those which
+// can't match beneath dir are dropped.
+//
+// Patterns with "**" in the middle of a path element, such as "a**", and
+// rules with path predicates can't be rebased, and make Rebase fail with
+// ErrCannotRebase. The "dir" argument should be a slash-delimited path.
+func (pm *PatternMatcher) Rebase(dir string) (*PatternMatcher, error) {
+	st := pm.opts.style
+	dir, err := pm.opts.subdir(dir)
+	if err != nil || dir == "." {
+		return pm, err
+	}
+	dirElems := strings.Split(dir, string(st.sep()))
+	return pm.rewrite(func(p *Pattern) ([]string, error) {
+		if p.CleanedPattern == "." {
+			// Only matches the root.
+			return nil, nil
+		}
+		rebased, err := rebaseElems(p.Dirs, dirElems, p.foldCase, st)
+		if err != nil {
+			return nil, &PatternError{Pattern: p.String(), Err: err}
+		}
+		return rebased, nil
+	})
+}
+
+// Prefix returns a PatternMatcher whose patterns apply to the subdirectory
+// dir of a larger tree, the converse of Rebase: the returned matcher matches
+// dir/x if pm matches "x", and doesn't match the paths outside of dir, such
+// as when applying the ignore file of a subdirectory to a whole repository.
+// Rules with path predicates make it fail with ErrCannotRebase. The "dir"
+// argument should be a slash-delimited path.
+func (pm *PatternMatcher) Prefix(dir string) (*PatternMatcher, error) {
+	st := pm.opts.style
+	dir, err := pm.opts.subdir(dir)
+	if err != nil || dir == "." {
+		return pm, err
+	}
+	escaped, ok := escapeLiteral(dir, st)
+	if !ok {
+		return nil, &PathError{Path: dir, Err: ErrCannotRebase}
+	}
+	return pm.rewrite(func(p *Pattern) ([]string, error) {
+		return []string{st.clean(escaped + string(st.sep()) + p.CleanedPattern)}, nil
+	})
+}
+
+// subdir returns the subdirectory dir of Rebase and Prefix, cleaned and
+// using the separator of the style of o, or an error if it isn't beneath
+// the root.
+func (o *options) subdir(dir string) (string, error) {
+	st := o.style
+	dir = st.clean(st.fromSlash(dir))
+	if st.isAbs(dir) || st.volumeName(dir) != "" || strings.HasPrefix(dir, string(st.sep())) || dir == ".." || strings.HasPrefix(dir, ".."+string(st.sep())) {
+		return "", &PathError{Path: dir, Err: ErrCannotRebase}
+	}
+	return dir, nil
+}
+
+// rewrite returns a PatternMatcher made of the patterns rewriting those of
+// pm with fn, in the same position, with the same attributes.
+func (pm *PatternMatcher) rewrite(fn func(p *Pattern) ([]string, error)) (*PatternMatcher, error) {
+	// The patterns are already expanded and cleaned.
+	o := *pm.baseOpts
+	o.braces = false
+	o.globstar = GlobstarContents
+	var all []*Pattern
+	for _, p := range pm.all {
+		if len(p.pathPredicates) != 0 {
+			// The paths passed to the predicates would change.
+			return nil, &PatternError{Pattern: p.String(), Err: ErrCannotRebase}
+		}
+		texts, err := fn(p)
+		if err != nil {
+			return nil, err
+		}
+		for i, text := range texts {
+			if p.Exclusion {
+				text = "!" + text
+			}
+			if p.DirOnly && text != "**" && text != "!**" {
+				text += string(o.style.sep())
+			}
+			texts[i] = text
+		}
+		rewritten, err := newPatterns(texts, &o)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range rewritten {
+			q.index = p.index
+			q.precedence = p.precedence
+			q.source = p.source
+			q.group = p.group
+			q.labels = p.labels
+			if p.foldCase {
+				q.setFoldCase()
+			}
+		}
+		all = append(all, rewritten...)
+	}
+	return newPatternMatcher(all, pm.baseOpts, pm.disabled)
+}
+
+// rebaseElems returns the patterns matching the paths x beneath dirElems,
+// relative to it, for which the pattern made of elems matches dirElems/x or
+// one of its parents. Consecutive patterns of the same kind being a union,
+// the result can replace the pattern in its list.
+func rebaseElems(elems, dirElems []string, foldCase bool, st PathStyle) ([]string, error) {
+	if len(elems) == 0 {
+		// The pattern matches dirElems or one of its parents.
+		return []string{"**"}, nil
+	}
+	if len(dirElems) == 0 {
+		return []string{strings.Join(elems, string(st.sep()))}, nil
+	}
+	elem := elems[0]
+	if elem == "**" {
+		// "**" spans none of dirElems, or at least its first one.
+		none, err := rebaseElems(elems[1:], dirElems, foldCase, st)
+		if err != nil {
+			return nil, err
+		}
+		more, err := rebaseElems(elems, dirElems[1:], foldCase, st)
+		if err != nil {
+			return nil, err
+		}
+		return unionPatterns(none, more), nil
+	}
+	if strings.Contains(elem, "**") {
+		return nil, ErrCannotRebase
+	}
+	name := dirElems[0]
+	if foldCase {
+		elem, name = foldString(elem), foldString(name)
+	}
+	if ok, err := st.match(elem, name); !ok || err != nil {
+		return nil, nil
+	}
+	return rebaseElems(elems[1:], dirElems[1:], foldCase, st)
+}
+
+// unionPatterns returns the patterns of a and b without duplicates, or only
+// "**" if one of them is.
+func unionPatterns(a, b []string) []string {
+	union := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, p := range append(a, b...) {
+		if p == "**" {
+			return []string{"**"}
+		}
+		if !seen[p] {
+			seen[p] = true
+			union = append(union, p)
+		}
+	}
+	return union
+}
+
+// escapeLiteral returns path with the characters special to patterns of
+// style st escaped, so that it matches itself. It returns false if they
+// can't be escaped, as with backslashes being separators on Windows.
+func escapeLiteral(path string, st PathStyle) (string, bool) {
+	if !strings.ContainsAny(path, `*?[\`) || !st.escapes() && !strings.ContainsAny(path, `*?[`) {
+		return path, true
+	}
+	if !st.escapes() {
+		return "", false
+	}
+	var sb strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), true
+}
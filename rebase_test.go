@@ -0,0 +1,80 @@
+package patternmatcher
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"testing"
+)
+
+func TestRebase(t *testing.T) {
+	patterns := []string{
+		"ctx/node_modules",
+		"!ctx/node_modules/keep.js",
+		"**/build/",
+		"**/src/**/*_test.go",
+		"*/docs",
+		"other/**",
+		"ctx/src/pkg",
+		"!**/pkg/lib.go",
+	}
+	pm, err := New(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebased, err := pm.Rebase("ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := fs.Sub(walkFS, "ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.WalkDir(sub, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == "." {
+			return err
+		}
+		opts := MatchOpts{IsDir: d.IsDir()}
+		want, err := pm.MatchesWithOpts(path.Join("ctx", p), opts)
+		if err != nil {
+			return err
+		}
+		got, err := rebased.MatchesWithOpts(p, opts)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			t.Errorf("%q: expected %v, got %v", p, want, got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Prefix is the converse of Rebase.
+	prefixed, err := rebased.Prefix("ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"ctx/node_modules/a", "ctx/node_modules/keep.js", "ctx/docs/x", "ctx/src/pkg/lib.go", "ctx/src/pkg/x", "other/a", "ctx"} {
+		want, _ := pm.MatchesOrParentMatches(p)
+		if p == "other/a" {
+			want = false
+		}
+		if got, _ := prefixed.MatchesOrParentMatches(p); got != want {
+			t.Errorf("prefixed %q: expected %v, got %v", p, want, got)
+		}
+	}
+
+	if _, err := pm.Rebase("../x"); !errors.Is(err, ErrCannotRebase) {
+		t.Errorf("expected ErrCannotRebase rebasing outside the root, got %v", err)
+	}
+	bad, err := New([]string{"a**/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bad.Rebase("a"); !errors.Is(err, ErrCannotRebase) {
+		t.Errorf("expected ErrCannotRebase for a** pattern, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package patternmatcher
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+)
+
+// WalkRecord is a line of the report written by WriteWalkReport.
+type WalkRecord struct {
+	// Path is the slash-separated path relative to the root.
+	Path    string `json:"path"`
+	IsDir   bool   `json:"isDir"`
+	Matched bool   `json:"matched"`
+	// Pattern is the pattern deciding Matched, if any.
+	Pattern string `json:"decidingPattern,omitempty"`
+	// Source is the name of the Source of Pattern, if it came from one.
+	Source string `json:"source,omitempty"`
+	// Index is the position of Pattern in the list of patterns of its
+	// source, starting at 0, or -1 if no pattern decides. Blank lines and
+	// comments of ignore files aren't counted.
+	Index int `json:"index"`
+	// Pruned reports that the contents of a matched directory weren't
+	// walked, because all of them are matched as well.
+	Pruned bool `json:"pruned,omitempty"`
+}
+
+// WriteWalkReport walks the file tree rooted at root in fsys like
+// WalkAndMatch, and writes a WalkRecord for every path walked to w as
+// newline-delimited JSON, so that the decisions of pm can be processed and
+// checked by other tools.
+func (pm *PatternMatcher) WriteWalkReport(w io.Writer, fsys fs.FS, root string) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return pm.walkDecisions(fsys, root, func(rel string, d fs.DirEntry, matched bool, pattern *Pattern, pruned bool) error {
+		rec := WalkRecord{
+			Path:    rel,
+			IsDir:   d.IsDir(),
+			Matched: matched,
+			Index:   -1,
+			Pruned:  pruned,
+		}
+		if pattern != nil {
+			rec.Pattern = pattern.style.toSlash(pattern.String())
+			rec.Source = pattern.source
+			rec.Index = pattern.index
+		}
+		return enc.Encode(&rec)
+	})
+}
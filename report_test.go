@@ -0,0 +1,37 @@
+package patternmatcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteWalkReport(t *testing.T) {
+	pm, err := NewFromSources([]Source{
+		{Name: ".dockerignore", Patterns: []string{"node_modules", "!node_modules/keep.js", "build"}},
+		{Name: "flags", Patterns: []string{"**/*_test.go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := pm.WriteWalkReport(&sb, walkFS, "ctx"); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"path":".","isDir":true,"matched":false,"index":-1}
+{"path":"Dockerfile","isDir":false,"matched":false,"index":-1}
+{"path":"build","isDir":true,"matched":true,"decidingPattern":"build","source":".dockerignore","index":2,"pruned":true}
+{"path":"docs","isDir":true,"matched":false,"index":-1}
+{"path":"docs/build","isDir":false,"matched":false,"index":-1}
+{"path":"node_modules","isDir":true,"matched":true,"decidingPattern":"node_modules","source":".dockerignore","index":0}
+{"path":"node_modules/a","isDir":true,"matched":true,"decidingPattern":"node_modules","source":".dockerignore","index":0,"pruned":true}
+{"path":"node_modules/keep.js","isDir":false,"matched":false,"decidingPattern":"!node_modules/keep.js","source":".dockerignore","index":1}
+{"path":"src","isDir":true,"matched":false,"index":-1}
+{"path":"src/main.go","isDir":false,"matched":false,"index":-1}
+{"path":"src/pkg","isDir":true,"matched":false,"index":-1}
+{"path":"src/pkg/lib.go","isDir":false,"matched":false,"index":-1}
+{"path":"src/pkg/lib_test.go","isDir":false,"matched":true,"decidingPattern":"**/*_test.go","source":"flags","index":0}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,138 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// Predicate is a condition on the attributes of a file, which restricts the
+// paths matched by the pattern of a Rule.
+type Predicate func(info fs.FileInfo) bool
+
+// IsDirectory returns a Predicate holding for directories.
+func IsDirectory() Predicate {
+	return func(info fs.FileInfo) bool {
+		return info.IsDir()
+	}
+}
+
+// LargerThan returns a Predicate holding for files whose size is over n
+// bytes.
+func LargerThan(n int64) Predicate {
+	return func(info fs.FileInfo) bool {
+		return info.Size() > n
+	}
+}
+
+// HasType returns a Predicate holding for files of type t, which is one of
+// the type bits of fs.FileMode, such as fs.ModeSymlink, or 0 for regular
+// files.
+func HasType(t fs.FileMode) Predicate {
+	return func(info fs.FileInfo) bool {
+		return info.Mode().Type() == t
+	}
+}
+
+// PathPredicate is a condition on a slash-separated path, for the rules
+// which can't be expressed as a pattern.
+type PathPredicate func(path string) bool
+
+// DeeperThan returns a PathPredicate holding for paths of more than n
+// elements.
+func DeeperThan(n int) PathPredicate {
+	return func(path string) bool {
+		return strings.Count(path, "/") >= n
+	}
+}
+
+// Rule is a pattern whose matches are restricted to the files satisfying
+// all of its predicates, such as the "*.log" files over 100MB:
+//
+//	Rule{Pattern: "*.log", Predicates: []Predicate{LargerThan(100 << 20)}}
+//
+// Its path predicates restrict them further to the paths satisfying them,
+// the pattern "**" leaving them alone, such as to exclude the paths deeper
+// than 6 levels:
+//
+//	Rule{Pattern: "**", PathPredicates: []PathPredicate{DeeperThan(6)}}
+type Rule struct {
+	Pattern        string
+	Predicates     []Predicate
+	PathPredicates []PathPredicate
+}
+
+// NewFromRules creates a PatternMatcher from an ordered list of rules,
+// which are evaluated as the patterns passed to New, so that rules with and
+// without predicates can override each other.
+//
+// The predicates of a rule are evaluated with the attributes of the path
+// passed in MatchOpts.Info, as the walkers do. A rule with predicates
+// doesn't match paths whose attributes aren't known. It only matches the
+// paths beneath the directories it matches in the walkers, which know the
+// attributes of the directories they descend into, such as when a later
+// rule may re-include some of their contents.
+//
+// The path predicates of a rule are evaluated with the paths the pattern
+// matches, the parent directories of the matched path included, so that a
+// rule matching a directory matches the paths beneath it as patterns do.
+func NewFromRules(rules []Rule, opts ...Option) (*PatternMatcher, error) {
+	o := newOptions(opts)
+	lines := make([]string, len(rules))
+	for i, r := range rules {
+		lines[i] = r.Pattern
+	}
+	patterns, err := newPatterns(lines, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range patterns {
+		p.predicates = rules[p.index].Predicates
+		p.pathPredicates = rules[p.index].PathPredicates
+	}
+	return newPatternMatcher(patterns, o, nil)
+}
+
+// satisfiedBy reports whether info satisfies all the predicates of p.
+func (p *Pattern) satisfiedBy(info fs.FileInfo) bool {
+	for _, pred := range p.predicates {
+		if !pred(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPredicates reports whether one of patterns has predicates.
+func hasPredicates(patterns []*Pattern) bool {
+	for _, p := range patterns {
+		if len(p.predicates) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiesPath reports whether path, with the separator of p, satisfies
+// all the path predicates of p.
+func (p *Pattern) satisfiesPath(path string) bool {
+	if len(p.pathPredicates) == 0 {
+		return true
+	}
+	path = p.style.toSlash(path)
+	for _, pred := range p.pathPredicates {
+		if !pred(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPathPredicates reports whether one of patterns has path predicates.
+func hasPathPredicates(patterns []*Pattern) bool {
+	for _, p := range patterns {
+		if len(p.pathPredicates) != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package patternmatcher
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewFromRules(t *testing.T) {
+	pm, err := NewFromRules([]Rule{
+		{Pattern: "*.log", Predicates: []Predicate{LargerThan(100)}},
+		{Pattern: "tmp", Predicates: []Predicate{IsDirectory()}},
+		{Pattern: "link", Predicates: []Predicate{HasType(fs.ModeSymlink)}},
+		{Pattern: "!keep.log"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"big.log":      {Data: make([]byte, 200)},
+		"small.log":    {Data: make([]byte, 10)},
+		"keep.log":     {Data: make([]byte, 200)},
+		"tmp/a":        {},
+		"src/tmp":      {Data: []byte("a file")},
+		"link":         {Mode: fs.ModeSymlink},
+		"src/main.go":  {},
+		"src/huge.log": {Data: make([]byte, 200)},
+	}
+
+	var included []string
+	err = pm.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		included = append(included, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{".", "keep.log", "small.log", "src", "src/huge.log", "src/main.go", "src/tmp"}
+	if !reflect.DeepEqual(included, expected) {
+		t.Errorf("expected %q, got %q", expected, included)
+	}
+
+	// Without attributes, rules with predicates don't match.
+	if matched, err := pm.MatchesOrParentMatches("big.log"); err != nil || matched {
+		t.Errorf("expected big.log not to be matched without attributes, got %v, %v", matched, err)
+	}
+	// Nor do they match the paths beneath those they match.
+	info, err := fs.Stat(fsys, "tmp/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched, err := pm.MatchesWithOpts("tmp/a", MatchOpts{Info: info}); err != nil || matched {
+		t.Errorf("expected tmp/a not to be matched, got %v, %v", matched, err)
+	}
+}
+
+func TestRulesReinclusionBeneathPredicate(t *testing.T) {
+	pm, err := NewFromRules([]Rule{
+		{Pattern: "vendor", Predicates: []Predicate{IsDirectory()}},
+		{Pattern: "!vendor/keep.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"main.go":         {},
+		"vendor/keep.go":  {},
+		"vendor/other.go": {},
+		"vendor/sub/a.go": {},
+	}
+
+	// The walk descends into vendor for keep.go, and leaves out the rest
+	// of its contents.
+	var included []string
+	err = pm.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		included = append(included, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{".", "main.go", "vendor/keep.go"}
+	if !reflect.DeepEqual(included, expected) {
+		t.Errorf("expected %q, got %q", expected, included)
+	}
+
+	all, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, excluded, err := DiffTree(fsys, ".", all, pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"vendor/other.go", "vendor/sub/a.go"}; !reflect.DeepEqual(excluded, expected) {
+		t.Errorf("expected DiffTree to exclude %q, got %q", expected, excluded)
+	}
+
+	dir := t.TempDir()
+	for name := range fsys {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	included = nil
+	err = filepath.Walk(dir, pm.WalkFunc(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		included = append(included, filepath.ToSlash(rel))
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(included, expected) {
+		t.Errorf("expected WalkFunc to pass %q, got %q", expected, included)
+	}
+}
+
+func TestPathPredicates(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSinglePassMatching()}, {WithPathStyle(WindowsPaths)}} {
+		pm, err := NewFromRules([]Rule{
+			{Pattern: "**", PathPredicates: []PathPredicate{DeeperThan(3)}},
+			{Pattern: "!vendor/**"},
+			{Pattern: "*.go", PathPredicates: []PathPredicate{func(path string) bool { return path != "main.go" }}},
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, expected := range map[string]bool{
+			"a/b/c":         false,
+			"a/b/c/d":       true,
+			"a/b/c/d/e":     true,
+			"vendor/b/c/d":  false,
+			"main.go":       false,
+			"lib.go":        true,
+			"src/lib.go":    false,
+			"a/b/c/d/x.txt": true,
+		} {
+			matched, err := pm.MatchesOrParentMatches(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != expected {
+				t.Errorf("%q: expected %v, got %v", path, expected, matched)
+			}
+		}
+		if _, err := pm.Rebase("a"); !errors.Is(err, ErrCannotRebase) {
+			t.Errorf("expected ErrCannotRebase, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// allowlist returns a machine-generated allowlist of n files: everything is
+// excluded, except the listed files.
+func allowlist(n int) []string {
+	patterns := make([]string, 0, n+1)
+	patterns = append(patterns, "**")
+	for i := 0; i < n; i++ {
+		patterns = append(patterns, fmt.Sprintf("!pkg/mod%d/dir%d/file%d.go", i%100, i%1000, i))
+	}
+	return patterns
+}
+
+func TestLargePatternSet(t *testing.T) {
+	patterns := append(allowlist(10000), "**/*_test.go", "!pkg/mod3/dir3/file3_test.go")
+	pm, err := New(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.index == nil {
+		t.Fatal("expected the patterns to be indexed")
+	}
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"pkg/mod7/dir507/file507.go", false},
+		{"pkg/mod7/dir507/file9507.go", false},
+		{"pkg/mod7/dir507/file9508.go", true},
+		{"pkg/mod7/dir507/other.go", true},
+		{"pkg/mod7/dir507/file507.go/x", false},
+		{"pkg/mod7/dir507/file507_test.go", true},
+		{"pkg/mod3/dir3/file3_test.go", false},
+		{"pkg/mod7", true},
+		{"pkg/mod7/dir8/file8.go", true},
+		{"README.md", true},
+	}
+	for _, tt := range tests {
+		path := filepath.FromSlash(tt.path)
+		matched, err := pm.MatchesOrParentMatches(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.path, tt.expected, matched)
+		}
+		// Folding the case, which doesn't change these lowercase paths,
+		// evaluates every pattern rather than the indexed ones.
+		if want, _ := pm.MatchesWithOpts(path, MatchOpts{FoldCase: true}); matched != want {
+			t.Errorf("%q: expected the index not to change the result %v", tt.path, want)
+		}
+	}
+}
+
+func BenchmarkLargePatternSet(b *testing.B) {
+	patterns := allowlist(100000)
+	b.Run("New", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := New(patterns); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	pm, err := New(patterns)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Listed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matched, _ := pm.MatchesOrParentMatches("pkg/mod7/dir507/file50507.go"); matched {
+				b.Fatal("expected the listed file to be included")
+			}
+		}
+	})
+	b.Run("Unlisted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matched, _ := pm.MatchesOrParentMatches("pkg/mod7/dir507/other.go"); !matched {
+				b.Fatal("expected an unlisted file to be excluded")
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package patternmatcher
+
+import (
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSegmentTokens is the maximum number of tokens of a segmentMatcher, so
+// that its states fit in a uint64.
+const maxSegmentTokens = 63
+
+type segmentTokenKind uint8
+
+const (
+	literalToken segmentTokenKind = iota
+	anyToken
+	starToken
+	classToken
+)
+
+type segmentToken struct {
+	kind   segmentTokenKind
+	r      rune
+	class  *runeClass
+	negate bool
+}
+
+// runeClass is the set of runes of a character class. ASCII runes are kept
+// in a bitmap, others as ranges.
+type runeClass struct {
+	ascii  [2]uint64
+	ranges [][2]rune
+}
+
+func (c *runeClass) add(lo, hi rune) {
+	for ; lo <= hi && lo < utf8.RuneSelf; lo++ {
+		c.ascii[lo/64] |= 1 << (lo % 64)
+	}
+	if lo <= hi {
+		c.ranges = append(c.ranges, [2]rune{lo, hi})
+	}
+}
+
+func (c *runeClass) contains(r rune) bool {
+	if r < utf8.RuneSelf {
+		return c.ascii[r/64]&(1<<(r%64)) != 0
+	}
+	for _, rg := range c.ranges {
+		if rg[0] <= r && r <= rg[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentMatcher matches paths against a pattern made of literal runes,
+// character classes, "?" and "*", without the overhead of regexp. It
+// simulates the equivalent regexp with the set of its states kept as a bit
+// mask, bit i meaning that the first i tokens matched the input consumed.
+type segmentMatcher struct {
+	tokens []segmentToken
+	// stars has bit i set if token i is "*".
+	stars uint64
+	// sep is the separator "?" and "*" don't match.
+	sep rune
+}
+
+// compileSegment returns a segmentMatcher for pattern if it is a single path
+// element containing character classes, without escapes or "**", sep being
+// the separator. It returns nil otherwise, or if it can't represent the
+// pattern.
+func compileSegment(pattern string, sep byte) *segmentMatcher {
+	if !strings.Contains(pattern, "[") || strings.ContainsAny(pattern, string(sep)+`\`) || strings.Contains(pattern, "**") {
+		return nil
+	}
+
+	m := &segmentMatcher{sep: rune(sep)}
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch r {
+		case '*':
+			m.stars |= 1 << len(m.tokens)
+			m.tokens = append(m.tokens, segmentToken{kind: starToken})
+		case '?':
+			m.tokens = append(m.tokens, segmentToken{kind: anyToken})
+		case '[':
+			tok, n := parseClass(pattern[i:])
+			if n == 0 {
+				return nil
+			}
+			i += n
+			m.tokens = append(m.tokens, tok)
+		case ']':
+			return nil
+		default:
+			m.tokens = append(m.tokens, segmentToken{kind: literalToken, r: r})
+		}
+	}
+	if len(m.tokens) > maxSegmentTokens {
+		return nil
+	}
+	return m
+}
+
+// parseClass parses a character class following its opening "[". It
+// returns the number of bytes consumed, including the closing "]", or 0 if
+// the class isn't supported.
+func parseClass(s string) (segmentToken, int) {
+	tok := segmentToken{kind: classToken, class: &runeClass{}}
+	i := 0
+	if strings.HasPrefix(s, "^") || strings.HasPrefix(s, "!") {
+		tok.negate = true
+		i++
+	}
+	start := i
+	for i < len(s) {
+		lo, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case lo == ']' && i-size > start:
+			return tok, i
+		case lo == ']' || lo == '-' || lo == '[':
+			return segmentToken{}, 0
+		}
+		hi := lo
+		if strings.HasPrefix(s[i:], "-") {
+			var size int
+			hi, size = utf8.DecodeRuneInString(s[i+1:])
+			if hi == ']' || hi == '-' || hi == '[' || hi < lo || size == 0 {
+				return segmentToken{}, 0
+			}
+			i += 1 + size
+		}
+		tok.class.add(lo, hi)
+	}
+	return segmentToken{}, 0
+}
+
+// closure adds to states those reached by matching "*" with nothing.
+func (m *segmentMatcher) closure(states uint64) uint64 {
+	for {
+		next := states | (states&m.stars)<<1
+		if next == states {
+			return states
+		}
+		states = next
+	}
+}
+
+func (m *segmentMatcher) match(path string) bool {
+	states := m.closure(1)
+	for _, r := range path {
+		var next uint64
+		for pending := states; pending != 0; pending &= pending - 1 {
+			i := bits.TrailingZeros64(pending)
+			if i == len(m.tokens) {
+				break
+			}
+			tok := &m.tokens[i]
+			switch tok.kind {
+			case literalToken:
+				if r == tok.r {
+					next |= 1 << (i + 1)
+				}
+			case anyToken:
+				if r != m.sep {
+					next |= 1 << (i + 1)
+				}
+			case starToken:
+				if r != m.sep {
+					next |= 1 << i
+				}
+			case classToken:
+				if tok.class.contains(r) != tok.negate {
+					next |= 1 << (i + 1)
+				}
+			}
+		}
+		states = m.closure(next)
+		if states == 0 {
+			return false
+		}
+	}
+	return states&(1<<len(m.tokens)) != 0
+}
@@ -0,0 +1,76 @@
+package patternmatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileSegment(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"[abc].txt", true},
+		{"[0-9]*", true},
+		{"*.[ch]", true},
+		{"[^a-z]?", true},
+		{"[!a-z]?", true},
+		{"[a-ζ]*", true},
+		{"*.go", false},
+		{"dir/[abc]", false},
+		{"[a\\]]", false},
+		{"**[abc]", false},
+		{"[-a]", false},
+		{"[a-]", false},
+		{"[]", false},
+		{"[^]", false},
+		{"[!]", false},
+		{"[z-a]", false},
+		{"[abc", false},
+	}
+	for _, tt := range tests {
+		if ok := compileSegment(filepath.FromSlash(tt.pattern), os.PathSeparator) != nil; ok != tt.ok {
+			t.Errorf("pattern %q: expected %v, got %v", tt.pattern, tt.ok, ok)
+		}
+	}
+}
+
+// TestSegmentMatch checks that segment matchers give the same results as the
+// regexps compiled for the same patterns.
+func TestSegmentMatch(t *testing.T) {
+	patterns := []string{"[abc].txt", "[0-9]*", "*.[ch]", "[^a-z]?", "a[^b]c", "*[xy]*[xy]*", "[a-ζ]*", "*[a-ζ]", "ab[^e-g]", "a[^a][^a][^a]b", "[!a-z]?", "a[!b]c"}
+	paths := []string{"a.txt", "d.txt", "b.txt.bak", "1", "123", "x1", "main.c", "main.h", "main.go", "A", "Ab", "ab", "abc", "a/c", "axc", "xy", "zxzyz", "zxz", "α", "A", "abd", "abe", "a☺b", "a/b/c"}
+	for _, pattern := range patterns {
+		p, err := NewPattern(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.MatchType != ClassMatch {
+			t.Fatalf("pattern %q: expected ClassMatch, got %v", pattern, p.MatchType)
+		}
+		for _, path := range paths {
+			path = filepath.FromSlash(path)
+			if got, want := p.Match(path), p.Regexp.MatchString(path); got != want {
+				t.Errorf("pattern %q, path %q: expected %v, got %v", pattern, path, want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkClassMatch(b *testing.B) {
+	p, err := NewPattern("[0-9][0-9]*.log")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("segment", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Match("2023-10-01.log")
+		}
+	})
+	b.Run("regexp", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Regexp.MatchString("2023-10-01.log")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Precedence orders the sources of patterns. Patterns from a source with a
+// higher precedence override those from a source with a lower one,
+// regardless of the order in which the sources are listed.
+type Precedence int
+
+// Precedences of the usual sources of patterns.
+const (
+	// PrecedenceDefaults is for patterns built into a tool.
+	PrecedenceDefaults Precedence = 0
+	// PrecedenceFile is for patterns read from an ignore file.
+	PrecedenceFile Precedence = 100
+	// PrecedenceFlags is for patterns passed on the command line.
+	PrecedenceFlags Precedence = 200
+)
+
+// Source is a list of patterns coming from the same place, such as an ignore
+// file or command line flags.
+type Source struct {
+	// Name identifies the source in errors, such as the path of a file.
+	Name       string
+	Precedence Precedence
+	// Group is the name of the group the patterns belong to, which allows
+	// disabling them with PatternMatcher.DisableGroups.
+	Group string
+	// Labels are attached to each of the patterns, and reported by
+	// PatternMatcher.MatchesWithLabels when one of them decides a result.
+	Labels   map[string]string
+	Patterns []string
+}
+
+// NewFromSources creates a PatternMatcher from the patterns of several
+// sources. Patterns are evaluated by increasing precedence of their source,
+// and in their order within sources of the same precedence, so the last
+// matching pattern of the source with the highest precedence decides.
+func NewFromSources(sources []Source, opts ...Option) (*PatternMatcher, error) {
+	o := newOptions(opts)
+	var patterns []*Pattern
+	for _, src := range sources {
+		ps, err := newPatterns(src.Patterns, o)
+		if err != nil {
+			if src.Name != "" {
+				return nil, fmt.Errorf("%s: %w", src.Name, err)
+			}
+			return nil, err
+		}
+		for _, p := range ps {
+			p.precedence = src.Precedence
+			p.source = src.Name
+			p.group = src.Group
+			p.labels = src.Labels
+		}
+		patterns = append(patterns, ps...)
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patterns[i].precedence < patterns[j].precedence
+	})
+	return newPatternMatcher(patterns, o, nil)
+}
@@ -0,0 +1,148 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewFromSources(t *testing.T) {
+	sources := []Source{
+		{Name: "flags", Precedence: PrecedenceFlags, Patterns: []string{"!docs/README.md"}},
+		{Name: ".dockerignore", Precedence: PrecedenceFile, Patterns: []string{"docs/README.md", "!vendor"}},
+		{Name: "defaults", Precedence: PrecedenceDefaults, Patterns: []string{"docs", "vendor", "*.tmp"}},
+	}
+	tests := []struct {
+		path string
+		pass bool
+	}{
+		{"docs/README.md", false},
+		{"docs/index.md", true},
+		{"vendor/lib.go", false},
+		{"a.tmp", true},
+	}
+	for _, resolution := range []Resolution{LastMatchWins, MostSpecificWins} {
+		pm, err := NewFromSources(sources, WithResolution(resolution))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			match, err := pm.MatchesOrParentMatches(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.pass {
+				t.Errorf("resolution %v, path %q: expected %v, got %v", resolution, tt.path, tt.pass, match)
+			}
+		}
+	}
+
+	// A less specific pattern from a source with a higher precedence wins.
+	pm, err := NewFromSources([]Source{
+		{Precedence: PrecedenceFlags, Patterns: []string{"!docs"}},
+		{Precedence: PrecedenceFile, Patterns: []string{"docs/*.md"}},
+	}, WithResolution(MostSpecificWins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesOrParentMatches("docs/README.md"); match {
+		t.Errorf("expected pattern with higher precedence to win")
+	}
+	derived, err := pm.With("docs/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := derived.MatchesOrParentMatches("docs/README.md"); !match {
+		t.Errorf("expected pattern added with With to win")
+	}
+
+	_, err = NewFromSources([]Source{{Name: ".dockerignore", Patterns: []string{"["}}})
+	if !errors.Is(err, filepath.ErrBadPattern) || !strings.HasPrefix(err.Error(), ".dockerignore: ") {
+		t.Errorf("expected error naming the source, got %v", err)
+	}
+}
+
+func TestMatchesWithLabels(t *testing.T) {
+	sources := []Source{
+		{Labels: map[string]string{"class": "secret"}, Patterns: []string{"**/*.pem", "**/.env"}},
+		{Labels: map[string]string{"class": "build"}, Patterns: []string{"build", "*.o"}},
+		{Patterns: []string{"!build/keep.pem"}},
+	}
+	tests := []struct {
+		path    string
+		matched bool
+		class   string
+	}{
+		{"certs/server.pem", true, "secret"},
+		{"build/out/main.o", true, "build"},
+		{"build/key.pem", true, "build"},
+		{"build/keep.pem", false, ""},
+		{"README.md", false, ""},
+	}
+	for _, opts := range [][]Option{nil, {WithSinglePassMatching()}} {
+		pm, err := NewFromSources(sources, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			matched, labels, err := pm.MatchesWithLabels(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != tt.matched || labels["class"] != tt.class {
+				t.Errorf("path %q: expected (%v, %q), got (%v, %q)", tt.path, tt.matched, tt.class, matched, labels["class"])
+			}
+		}
+	}
+	pm, err := NewFromSources(sources, WithGitReinclusionRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched, labels, _ := pm.MatchesWithLabels("build/keep.pem"); !matched || labels["class"] != "build" {
+		t.Errorf("expected excluded parent dir to decide, got (%v, %q)", matched, labels["class"])
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	pm, err := NewFromSources([]Source{
+		{Name: "flags", Precedence: PrecedenceFlags, Patterns: []string{"!keep.log"}},
+		{Name: ".dockerignore", Precedence: PrecedenceFile, Group: "logs", Patterns: []string{"# comment", "./logs/", "*.log"}},
+		{Name: "defaults", Patterns: []string{".git"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type rule struct {
+		pattern    string
+		matchType  MatchType
+		exclusion  bool
+		source     string
+		index      int
+		group      string
+		precedence Precedence
+	}
+	var got []rule
+	for _, p := range pm.Patterns() {
+		got = append(got, rule{p.String(), p.MatchType, p.Exclusion, p.Source(), p.Index(), p.Group(), p.Precedence()})
+	}
+	want := []rule{
+		{".git", ExactMatch, false, "defaults", 0, "", PrecedenceDefaults},
+		{"# comment", ExactMatch, false, ".dockerignore", 0, "logs", PrecedenceFile},
+		{"logs", ExactMatch, false, ".dockerignore", 1, "logs", PrecedenceFile},
+		{"*.log", ExtensionMatch, false, ".dockerignore", 2, "logs", PrecedenceFile},
+		{"!keep.log", ExactMatch, true, "flags", 0, "", PrecedenceFlags},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected\n%v\ngot\n%v", want, got)
+	}
+
+	if n := len(pm.DisableGroups("logs").Patterns()); n != 2 {
+		t.Errorf("expected 2 patterns with logs disabled, got %d", n)
+	}
+	pm.Patterns()[0].Exclusion = true
+	if pm.Patterns()[0].Exclusion {
+		t.Error("expected Patterns to return copies")
+	}
+}
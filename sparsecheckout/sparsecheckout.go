@@ -0,0 +1,161 @@
+// Package sparsecheckout evaluates the cone mode of git sparse-checkout with
+// a PatternMatcher.
+//
+// In cone mode, a sparse-checkout is a list of directories whose whole tree
+// is checked out. The files directly in the root and in the parent
+// directories of the listed ones are checked out as well, but not their
+// other subdirectories.
+package sparsecheckout
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+)
+
+// ErrNotCone is returned for sparse-checkout patterns which don't follow the
+// cone mode format.
+var ErrNotCone = errors.New("not a cone mode pattern")
+
+// Patterns returns patterns matching the paths checked out by a cone mode
+// sparse-checkout of dirs, such as the arguments of
+// "git sparse-checkout set". dirs are slash-separated paths relative to the
+// root of the repository. The patterns are escaped for the path style of
+// the host; New escapes them for the style of its options.
+func Patterns(dirs []string) []string {
+	return conePatterns(dirs, patternmatcher.HostPaths)
+}
+
+// conePatterns is Patterns, escaping the patterns for style.
+func conePatterns(dirs []string, style patternmatcher.PathStyle) []string {
+	recursive := outermost(dirs)
+	parents := make(map[string]bool)
+	for _, dir := range recursive {
+		for p := path.Dir(dir); p != "."; p = path.Dir(p) {
+			parents[p] = true
+		}
+	}
+	sortedParents := make([]string, 0, len(parents))
+	for p := range parents {
+		sortedParents = append(sortedParents, p)
+	}
+	// Patterns for a parent must precede those of its subdirectories.
+	sort.Slice(sortedParents, func(i, j int) bool {
+		di, dj := strings.Count(sortedParents[i], "/"), strings.Count(sortedParents[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return sortedParents[i] < sortedParents[j]
+	})
+
+	patterns := []string{"*", "!*/*"}
+	for _, p := range sortedParents {
+		p = escape(p, style)
+		patterns = append(patterns, p+"/*", "!"+p+"/*/*")
+	}
+	for _, dir := range recursive {
+		patterns = append(patterns, escape(dir, style))
+	}
+	return patterns
+}
+
+// New returns a PatternMatcher matching the paths checked out by a cone
+// mode sparse-checkout of dirs.
+func New(dirs []string, opts ...patternmatcher.Option) (*patternmatcher.PatternMatcher, error) {
+	// The path style the patterns are escaped for is only known once opts
+	// are applied.
+	pm, err := patternmatcher.New(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return pm.With(conePatterns(dirs, pm.PathStyle())...)
+}
+
+// Read reads a sparse-checkout file in cone mode, such as
+// .git/info/sparse-checkout, and returns the directories whose whole tree is
+// checked out. Patterns which aren't in the cone mode format are an error
+// wrapping ErrNotCone.
+func Read(r io.Reader) ([]string, error) {
+	var added []string
+	parents := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case line == "/*" || line == "!/*/":
+		case strings.HasPrefix(line, "!/") && strings.HasSuffix(line, "/*/") && len(line) > len("!//*/"):
+			parents[unescape(line[2:len(line)-3])] = true
+		case strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") && len(line) > len("//"):
+			added = append(added, unescape(line[1:len(line)-1]))
+		default:
+			return nil, fmt.Errorf("%q: %w", line, ErrNotCone)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, dir := range added {
+		if !parents[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// outermost cleans dirs, sorts them and removes those beneath another one.
+func outermost(dirs []string) []string {
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir = path.Clean("/" + dir)[1:]; dir != "" {
+			cleaned = append(cleaned, dir)
+		}
+	}
+	sort.Strings(cleaned)
+	var result []string
+	for _, dir := range cleaned {
+		if n := len(result); n != 0 && (result[n-1] == dir || strings.HasPrefix(dir, result[n-1]+"/")) {
+			continue
+		}
+		result = append(result, dir)
+	}
+	return result
+}
+
+// escape escapes the characters of dir which have a special meaning in
+// patterns of style. Patterns can't be escaped with WindowsPaths, where
+// "\" is the path separator.
+func escape(dir string, style patternmatcher.PathStyle) string {
+	if style == patternmatcher.WindowsPaths || style == patternmatcher.HostPaths && os.PathSeparator == '\\' {
+		return dir
+	}
+	var sb strings.Builder
+	for _, r := range dir {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// unescape removes the backslashes git uses to escape special characters in
+// sparse-checkout files.
+func unescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
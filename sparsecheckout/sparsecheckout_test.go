@@ -0,0 +1,91 @@
+package sparsecheckout
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moby/patternmatcher"
+)
+
+func TestPatterns(t *testing.T) {
+	got := Patterns([]string{"a/b/", "a/b/c", "/d", "a/e"})
+	want := []string{"*", "!*/*", "a/*", "!a/*/*", "a/b", "a/e", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	pm, err := New([]string{"a/b/c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		pass bool
+	}{
+		{"README.md", true},
+		{"a/x.txt", true},
+		{"a/b/x.txt", true},
+		{"a/b/c/x/y.txt", true},
+		{"a/b/other/x.txt", false},
+		{"a/other/x.txt", false},
+		{"d/x/y/z.txt", true},
+		{"e/x.txt", false},
+	}
+	for _, tt := range tests {
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("path %q: expected %v, got %v", tt.path, tt.pass, match)
+		}
+	}
+}
+
+func TestNewPathStyle(t *testing.T) {
+	if got := conePatterns([]string{"a*"}, patternmatcher.UnixPaths); got[len(got)-1] != `a\*` {
+		t.Errorf("expected a*, escaped for UnixPaths, got %q", got)
+	}
+	if got := conePatterns([]string{"a*"}, patternmatcher.WindowsPaths); got[len(got)-1] != "a*" {
+		t.Errorf("expected a*, unescaped for WindowsPaths, got %q", got)
+	}
+
+	pm, err := New([]string{"a*b"}, patternmatcher.WithPathStyle(patternmatcher.UnixPaths))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{"a*b/x": true, "axb/x": false} {
+		if match, err := pm.MatchesOrParentMatches(path); err != nil || match != want {
+			t.Errorf("UnixPaths, %q: expected %v, got %v, %v", path, want, match, err)
+		}
+	}
+
+	pm, err = New([]string{"a/b"}, patternmatcher.WithPathStyle(patternmatcher.WindowsPaths))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{`a\b\c\x`: true, `a\x`: true, `a\c\x`: false} {
+		if match, err := pm.MatchesOrParentMatches(path); err != nil || match != want {
+			t.Errorf("WindowsPaths, %q: expected %v, got %v, %v", path, want, match, err)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	const file = "/*\n!/*/\n/a/\n!/a/*/\n/a/b/\n/d\\*/\n"
+	dirs, err := Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a/b", "d*"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("expected %q, got %q", want, dirs)
+	}
+
+	if _, err := Read(strings.NewReader("*.go\n")); !errors.Is(err, ErrNotCone) {
+		t.Errorf("expected ErrNotCone, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package patternmatcher
+
+// Stats describes the compiled patterns of a PatternMatcher, to tell
+// whether an ignore file would benefit from being simplified.
+type Stats struct {
+	// Patterns is the number of patterns in effect, counted once braces
+	// are expanded.
+	Patterns int
+	// ByMatchType counts the patterns of each MatchType.
+	ByMatchType map[MatchType]int
+	// Exclusions is the number of patterns starting with "!".
+	Exclusions int
+	// Regexps is the number of patterns evaluated with a regular
+	// expression.
+	Regexps int
+	// ProgramSize is the total number of instructions of the compiled
+	// regular expressions, as bounded per pattern by
+	// Limits.MaxProgramSize.
+	ProgramSize int
+}
+
+// Stats returns statistics on the patterns of pm in effect, leaving out
+// those of disabled groups.
+func (pm *PatternMatcher) Stats() Stats {
+	stats := Stats{
+		Patterns:    len(pm.patterns),
+		ByMatchType: make(map[MatchType]int),
+	}
+	for _, p := range pm.patterns {
+		stats.ByMatchType[p.MatchType]++
+		if p.Exclusion {
+			stats.Exclusions++
+		}
+		// Class patterns of a single element are matched without their
+		// regexp.
+		if p.MatchType == RegexpMatch || p.MatchType == ClassMatch && p.segment == nil {
+			stats.Regexps++
+			stats.ProgramSize += programSize(p.Regexp)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,31 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	pm, err := New([]string{"docs", "!docs/README.md", "*.go", "build/**", "**/tmp", "src/*/gen", "a[bc]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := pm.Stats()
+	expected := map[MatchType]int{
+		ExactMatch:     2,
+		ExtensionMatch: 1,
+		PrefixMatch:    1,
+		SuffixMatch:    1,
+		RegexpMatch:    1,
+		ClassMatch:     1,
+	}
+	if !reflect.DeepEqual(stats.ByMatchType, expected) {
+		t.Errorf("expected %v, got %v", expected, stats.ByMatchType)
+	}
+	if stats.Patterns != 7 || stats.Exclusions != 1 || stats.Regexps != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.ProgramSize != programSize(pm.patterns[5].Regexp) {
+		t.Errorf("expected the program size of %q, got %d", pm.patterns[5], stats.ProgramSize)
+	}
+}
@@ -0,0 +1,206 @@
+// Package stignore reads Syncthing ignore files (.stignore) and matches
+// paths against them, following
+// https://docs.syncthing.net/users/ignoring.html.
+package stignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher/internal/glob"
+)
+
+// ErrIncludeLoop is returned when a file includes itself, directly or not.
+var ErrIncludeLoop = errors.New("include loop")
+
+// Pattern is a line of an ignore file.
+type Pattern struct {
+	// Pattern is the glob, without its prefixes.
+	Pattern string
+	// Include is set by the "!" prefix: matching paths aren't ignored.
+	Include bool
+	// FoldCase is set by the "(?i)" prefix.
+	FoldCase bool
+	// Deletable is set by the "(?d)" prefix: matching paths may be deleted
+	// if they prevent the removal of a directory.
+	Deletable bool
+
+	compiled *glob.Glob
+}
+
+// Result is the outcome of matching a path.
+type Result struct {
+	// Ignored is true if the first pattern matching the path ignores it.
+	Ignored bool
+	// Deletable is true if the path is ignored by a pattern with the
+	// "(?d)" prefix.
+	Deletable bool
+}
+
+// Matcher matches paths against the patterns of an ignore file, where the
+// first matching pattern decides.
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// OpenFunc opens the file named by an "#include" line. As Syncthing does,
+// the name is resolved against the directory of the file containing the
+// line, so that it is a slash-separated path relative to the directory of
+// the top-level ignore file, unless it is absolute.
+type OpenFunc func(name string) (io.ReadCloser, error)
+
+// Parse reads an ignore file. Included files are opened with open, or are an
+// error if open is nil.
+func Parse(r io.Reader, open OpenFunc) (*Matcher, error) {
+	m := &Matcher{}
+	if err := m.parse(r, open, ".", map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load reads the ignore file at path, opening included files relative to the
+// directory of the file including them.
+func Load(path string) (*Matcher, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir := filepath.Dir(path)
+	open := func(name string) (io.ReadCloser, error) {
+		if filepath.IsAbs(name) {
+			return os.Open(name)
+		}
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	m := &Matcher{}
+	if err := m.parse(f, open, ".", map[string]bool{filepath.Base(path): true}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parse reads the patterns of r, the file whose included files are resolved
+// against dir. including holds the files being parsed, to detect loops.
+func (m *Matcher) parse(r io.Reader, open OpenFunc, dir string, including map[string]bool) error {
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if first {
+			line = bytes.TrimPrefix(line, []byte{0xEF, 0xBB, 0xBF})
+			first = false
+		}
+		text := strings.TrimSpace(string(line))
+		switch {
+		case text == "", strings.HasPrefix(text, "//"):
+			continue
+		case strings.HasPrefix(text, "#include "):
+			if err := m.include(strings.TrimSpace(text[len("#include "):]), open, dir, including); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(text, "#"):
+			// Other directives, such as "#escape", don't affect matching.
+			continue
+		}
+		p, err := parsePattern(text)
+		if err != nil {
+			return err
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return scanner.Err()
+}
+
+func (m *Matcher) include(name string, open OpenFunc, dir string, including map[string]bool) error {
+	if open == nil {
+		return fmt.Errorf("#include %s: no way to open included files", name)
+	}
+	resolved := name
+	if !path.IsAbs(name) && !filepath.IsAbs(name) {
+		resolved = path.Join(dir, name)
+	}
+	if including[resolved] {
+		return fmt.Errorf("#include %s: %w", name, ErrIncludeLoop)
+	}
+	f, err := open(resolved)
+	if err != nil {
+		return fmt.Errorf("#include %s: %w", name, err)
+	}
+	defer f.Close()
+	including[resolved] = true
+	defer delete(including, resolved)
+	if err := m.parse(f, open, path.Dir(resolved), including); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// parsePattern parses a pattern line, whose prefixes may come in any order.
+func parsePattern(text string) (*Pattern, error) {
+	p := &Pattern{}
+	for {
+		switch {
+		case strings.HasPrefix(text, "!") && !p.Include:
+			p.Include = true
+			text = text[1:]
+			continue
+		case strings.HasPrefix(text, "(?i)") && !p.FoldCase:
+			p.FoldCase = true
+			text = text[4:]
+			continue
+		case strings.HasPrefix(text, "(?d)") && !p.Deletable:
+			p.Deletable = true
+			text = text[4:]
+			continue
+		}
+		break
+	}
+	p.Pattern = text
+
+	flags := glob.MatchContents
+	if p.FoldCase {
+		flags |= glob.FoldCase
+	}
+	// Patterns starting with "/" only match in the top directory, others
+	// match at any level.
+	if strings.HasPrefix(text, "/") {
+		text = text[1:]
+	} else if !strings.HasPrefix(text, "**/") {
+		text = "**/" + text
+	}
+	compiled, err := glob.Compile(text, flags)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", p.Pattern, err)
+	}
+	p.compiled = compiled
+	return p, nil
+}
+
+// Patterns returns the patterns of the ignore file, including those of the
+// included files in place of the "#include" lines.
+func (m *Matcher) Patterns() []*Pattern {
+	return m.patterns
+}
+
+// Match matches path, a slash-separated path relative to the directory of
+// the ignore file. The first pattern matching path or one of its parent
+// directories decides the result.
+func (m *Matcher) Match(path string) Result {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	for _, p := range m.patterns {
+		if p.compiled.Match(path) {
+			return Result{Ignored: !p.Include, Deletable: !p.Include && p.Deletable}
+		}
+	}
+	return Result{}
+}
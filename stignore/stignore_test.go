@@ -0,0 +1,156 @@
+package stignore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	const file = "// comment\n" +
+		"!/keep.tmp\n" +
+		"*.tmp\n" +
+		"(?d).DS_Store\n" +
+		"(?i)Thumbs.db\n" +
+		"/build\n" +
+		"docs/*.pdf\n" +
+		"**/cache\n" +
+		"*.{o,a}\n"
+	m, err := Parse(strings.NewReader(file), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want Result
+	}{
+		{"a.tmp", Result{Ignored: true}},
+		{"sub/a.tmp", Result{Ignored: true}},
+		{"keep.tmp", Result{}},
+		{"sub/keep.tmp", Result{Ignored: true}},
+		{"sub/.DS_Store", Result{Ignored: true, Deletable: true}},
+		{"THUMBS.DB", Result{Ignored: true}},
+		{"build", Result{Ignored: true}},
+		{"build/out/x", Result{Ignored: true}},
+		{"src/build", Result{}},
+		{"docs/a.pdf", Result{Ignored: true}},
+		{"x/docs/a.pdf", Result{Ignored: true}},
+		{"docs/sub/a.pdf", Result{}},
+		{"cache/x", Result{Ignored: true}},
+		{"a/b/cache", Result{Ignored: true}},
+		{"lib.a", Result{Ignored: true}},
+		{"main.go", Result{}},
+	}
+	for _, test := range tests {
+		if got := m.Match(test.path); got != test.want {
+			t.Errorf("Match(%q) = %+v, want %+v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	m, err := Parse(strings.NewReader("(?d)!(?i)a\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := m.Patterns()[0]
+	if p.Pattern != "a" || !p.Include || !p.FoldCase || !p.Deletable {
+		t.Errorf("unexpected pattern %+v", p)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	files := map[string]string{
+		"common": "*.log\n#include more\n",
+		"more":   "*.bak\n",
+		"loop":   "#include loop\n",
+		"sub/a":  "#include b\n",
+		"sub/b":  "*.tmp\n#include ../more\n",
+		"b":      "*.iso\n",
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		content, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+
+	m, err := Parse(strings.NewReader("!keep.log\n#include common\n"), open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ignored := range map[string]bool{"a.log": true, "a.bak": true, "keep.log": false} {
+		if got := m.Match(path).Ignored; got != ignored {
+			t.Errorf("Match(%q).Ignored = %v, want %v", path, got, ignored)
+		}
+	}
+
+	// Includes are resolved against the directory of the including file.
+	m, err = Parse(strings.NewReader("#include sub/a\n"), open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ignored := range map[string]bool{"a.tmp": true, "a.bak": true, "a.iso": false} {
+		if got := m.Match(path).Ignored; got != ignored {
+			t.Errorf("Match(%q).Ignored = %v, want %v", path, got, ignored)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("#include loop\n"), open); !errors.Is(err, ErrIncludeLoop) {
+		t.Errorf("expected ErrIncludeLoop, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader("#include missing\n"), open); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader("#include common\n"), nil); err == nil {
+		t.Error("expected an error without an open function")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".stignore"), []byte("#include .stglobal\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".stglobal"), []byte("*.iso\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(filepath.Join(dir, ".stignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("images/a.iso").Ignored {
+		t.Error("expected images/a.iso to be ignored")
+	}
+}
+
+func TestLoadNestedInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		".stignore":  "#include sub/first\n",
+		"sub/first":  "#include second\n",
+		"sub/second": "*.tmp\n",
+		"second":     "*.iso\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := Load(filepath.Join(dir, ".stignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("a.tmp").Ignored {
+		t.Error("expected a.tmp to be ignored")
+	}
+	if m.Match("a.iso").Ignored {
+		t.Error("expected a.iso not to be ignored")
+	}
+}
@@ -0,0 +1,88 @@
+package patternmatcher
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DefaultStrictLimits are the limits NewStrictPatterns enforces unless
+// overridden with WithLimits.
+var DefaultStrictLimits = Limits{
+	MaxPatterns:       10000,
+	MaxPatternLength:  4096,
+	MaxProgramSize:    10000,
+	MaxBraceExpansion: 256,
+}
+
+// NewStrictPatterns is like NewPatterns, for services compiling patterns
+// supplied by their users, such as ignore files of tenants. On top of opts,
+// it:
+//
+//   - rejects the constructs whose meaning depends on the OS, such as
+//     backslashes, which are escapes on Unix but separators on Windows,
+//     and patterns starting with a drive letter, with ErrOSDependent;
+//   - rejects trailing backslashes with ErrTrailingBackslash, and empty
+//     character classes with ErrEmptyClass;
+//   - enforces DefaultStrictLimits, ControlCharReject and ParentRefReject,
+//     unless overridden by opts.
+//
+// The errors about a pattern are *PatternError, whose wrapped error
+// doesn't depend on the OS, and which is filepath.ErrBadPattern for the
+// other syntax errors. Those about the whole list, such as a *LimitError
+// for more patterns than MaxPatterns, or about opts, aren't.
+func NewStrictPatterns(patterns []string, opts ...Option) ([]*Pattern, error) {
+	for _, p := range patterns {
+		if err := checkStrictSyntax(strings.TrimSpace(p)); err != nil {
+			return nil, err
+		}
+	}
+	strict := []Option{
+		WithLimits(DefaultStrictLimits),
+		WithControlCharPolicy(ControlCharReject),
+		WithParentRefPolicy(ParentRefReject),
+	}
+	return newPatterns(patterns, newOptions(append(strict, opts...)))
+}
+
+// checkStrictSyntax checks the syntax of p in a way which doesn't depend on
+// the OS.
+func checkStrictSyntax(p string) error {
+	if p == "" {
+		return nil
+	}
+	body := strings.TrimPrefix(p, "!")
+	if body == "" {
+		return &PatternError{Pattern: p, Err: filepath.ErrBadPattern}
+	}
+	if len(body) >= 2 && body[1] == ':' && ('a' <= body[0] && body[0] <= 'z' || 'A' <= body[0] && body[0] <= 'Z') {
+		return &PatternError{Pattern: p, Err: ErrOSDependent}
+	}
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			if i == len(body)-1 {
+				return &PatternError{Pattern: p, Err: ErrTrailingBackslash}
+			}
+			return &PatternError{Pattern: p, Err: ErrOSDependent}
+		case '[':
+			j := i + 1
+			if j < len(body) && (body[j] == '^' || body[j] == '!') {
+				j++
+			}
+			if j < len(body) && body[j] == ']' {
+				return &PatternError{Pattern: p, Err: ErrEmptyClass}
+			}
+			end := strings.IndexByte(body[j:], ']')
+			if end == -1 {
+				return &PatternError{Pattern: p, Err: filepath.ErrBadPattern}
+			}
+			i = j + end
+		}
+	}
+	// The syntax of filepath.Match is the same on every OS in the absence
+	// of backslashes.
+	if _, err := HostPaths.match(body, ""); err != nil {
+		return &PatternError{Pattern: p, Err: filepath.ErrBadPattern}
+	}
+	return nil
+}
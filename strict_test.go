@@ -0,0 +1,57 @@
+package patternmatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewStrictPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     error
+	}{
+		{"*.go", nil},
+		{"!docs/[a-z]*.md", nil},
+		{"[^a]", nil},
+		{"  ", nil},
+		{`a\`, ErrTrailingBackslash},
+		{`a\*`, ErrOSDependent},
+		{`dir\file`, ErrOSDependent},
+		{"C:/secrets", ErrOSDependent},
+		{"!c:x", ErrOSDependent},
+		{"a[]b", ErrEmptyClass},
+		{"a[^]b", ErrEmptyClass},
+		{"a[b", filepath.ErrBadPattern},
+		{"[a-]", filepath.ErrBadPattern},
+		{"!", filepath.ErrBadPattern},
+		{"../secrets", ErrPatternEscapesRoot},
+		{"a\x00b", ErrControlCharacter},
+		{strings.Repeat("a", 5000), ErrLimitExceeded},
+	}
+	for _, tt := range tests {
+		_, err := NewStrictPatterns([]string{tt.pattern})
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.pattern, err)
+			}
+			continue
+		}
+		var patternErr *PatternError
+		if !errors.As(err, &patternErr) || !errors.Is(err, tt.err) {
+			t.Errorf("%q: expected a *PatternError wrapping %v, got %v", tt.pattern, tt.err, err)
+		}
+	}
+
+	// Too many patterns isn't an error about one of them.
+	_, err := NewStrictPatterns([]string{"a", "b"}, WithLimits(Limits{MaxPatterns: 1}))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxPatterns" {
+		t.Errorf("expected a *LimitError for MaxPatterns, got %v", err)
+	}
+
+	if _, err := NewStrictPatterns([]string{"../a"}, WithParentRefPolicy(ParentRefAnchor)); err != nil {
+		t.Errorf("expected options to override the strict defaults, got %v", err)
+	}
+}
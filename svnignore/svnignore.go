@@ -0,0 +1,52 @@
+// Package svnignore converts the ignore properties of Subversion into
+// patterns, so that tools migrating from Subversion can evaluate them with
+// a PatternMatcher.
+//
+// Subversion matches ignore patterns against the basenames of unversioned
+// items, and doesn't descend into ignored directories, which the parent
+// matching of PatternMatcher reproduces. Its wildcards match bytes rather
+// than characters, which the patternmatcher.ByteWildcards unit reproduces.
+package svnignore
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultGlobalIgnores is the value of the global-ignores runtime option
+// when it isn't configured.
+const DefaultGlobalIgnores = "*.o *.lo *.la *.al .libs *.so *.so.[0-9]* *.a *.pyc *.pyo __pycache__ " +
+	"*.rej *~ #*# .#* .*.swp .DS_Store [Tt]humbs.db"
+
+// GlobalIgnores converts the whitespace-separated value of the
+// global-ignores runtime option, or of an svn:global-ignores property set on
+// the root of the working copy, into patterns matching basenames at any
+// depth.
+func GlobalIgnores(value string) []string {
+	fields := strings.Fields(value)
+	patterns := make([]string, len(fields))
+	for i, glob := range fields {
+		patterns[i] = "**/" + glob
+	}
+	return patterns
+}
+
+// Ignore converts the newline-separated value of the svn:ignore property of
+// dir into patterns matching the immediate children of dir. dir is a
+// slash-separated path relative to the root of the working copy, which is
+// "" or "." for the root itself.
+func Ignore(dir, value string) []string {
+	dir = path.Clean("/" + dir)[1:]
+	var patterns []string
+	for _, line := range strings.Split(value, "\n") {
+		glob := strings.TrimSpace(line)
+		if glob == "" {
+			continue
+		}
+		if dir != "" {
+			glob = dir + "/" + glob
+		}
+		patterns = append(patterns, glob)
+	}
+	return patterns
+}
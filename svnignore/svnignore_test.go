@@ -0,0 +1,59 @@
+package svnignore
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moby/patternmatcher"
+)
+
+func TestGlobalIgnores(t *testing.T) {
+	got := GlobalIgnores(" *.o\t*.pyc\n.DS_Store ")
+	want := []string{"**/*.o", "**/*.pyc", "**/.DS_Store"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GlobalIgnores() = %q, want %q", got, want)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	for _, dir := range []string{"", ".", "/"} {
+		if got, want := Ignore(dir, "build\r\n\n*.tmp\n"), []string{"build", "*.tmp"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Ignore(%q) = %q, want %q", dir, got, want)
+		}
+	}
+	if got, want := Ignore("src/lib/", "*.gen"), []string{"src/lib/*.gen"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ignore() = %q, want %q", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	patterns := GlobalIgnores(DefaultGlobalIgnores)
+	patterns = append(patterns, Ignore("src", "generated\n*.tmp")...)
+	pm, err := patternmatcher.New(patterns, patternmatcher.WithWildcardUnit(patternmatcher.ByteWildcards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		pass bool
+	}{
+		{"main.o", true},
+		{"src/lib/x.so.1", true},
+		{"docs/Thumbs.db", true},
+		{"a/__pycache__/x.py", true},
+		{"src/generated/x.go", true},
+		{"src/a.tmp", true},
+		{"src/sub/a.tmp", false},
+		{"a.tmp", false},
+		{"src/main.c", false},
+	}
+	for _, tt := range tests {
+		match, err := pm.MatchesOrParentMatches(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != tt.pass {
+			t.Errorf("path %q: expected %v, got %v", tt.path, tt.pass, match)
+		}
+	}
+}
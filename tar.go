@@ -0,0 +1,53 @@
+package patternmatcher
+
+import (
+	"archive/tar"
+	"strings"
+)
+
+// TarReader wraps a tar.Reader to skip the entries matched by a
+// PatternMatcher, such as those excluded by an ignore file, so that they are
+// never extracted.
+type TarReader struct {
+	tr *tar.Reader
+	pm *PatternMatcher
+}
+
+// NewTarReader returns a TarReader reading the entries of tr which pm
+// doesn't match.
+func NewTarReader(tr *tar.Reader, pm *PatternMatcher) *TarReader {
+	return &TarReader{tr: tr, pm: pm}
+}
+
+// Next advances to the next entry which isn't matched, skipping the matched
+// entries along with their content. Entry names are matched relative to the
+// root of the archive, and directory entries honor patterns ending with a
+// path separator. It returns io.EOF at the end of the archive, and the error
+// of the PatternMatcher for names it rejects.
+func (r *TarReader) Next() (*tar.Header, error) {
+	for {
+		hdr, err := r.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			return hdr, nil
+		}
+		name := strings.TrimLeft(hdr.Name, "/")
+		if name == "" {
+			name = "."
+		}
+		matched, err := r.pm.MatchesWithOpts(name, MatchOpts{IsDir: hdr.Typeflag == tar.TypeDir})
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return hdr, nil
+		}
+	}
+}
+
+// Read reads from the current entry.
+func (r *TarReader) Read(b []byte) (int, error) {
+	return r.tr.Read(b)
+}
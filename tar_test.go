@@ -0,0 +1,89 @@
+package patternmatcher
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestTarReader(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		name    string
+		typ     byte
+		content string
+	}{
+		{"./", tar.TypeDir, ""},
+		{"./src/", tar.TypeDir, ""},
+		{"./src/main.go", tar.TypeReg, "package main"},
+		{"./node_modules/", tar.TypeDir, ""},
+		{"./node_modules/lib.js", tar.TypeReg, "module.exports = {}"},
+		{"./build", tar.TypeReg, "not a dir"},
+		{"./debug.log", tar.TypeReg, "log"},
+		{"./keep.log", tar.TypeReg, "kept"},
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typ, Mode: 0o644, Size: int64(len(e.content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := New([]string{"node_modules", "build/", "*.log", "!keep.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewTarReader(tar.NewReader(&buf), pm)
+	var names []string
+	contents := make(map[string]string)
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[hdr.Name] = string(b)
+	}
+	want := []string{"./", "./src/", "./src/main.go", "./build", "./keep.log"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected entries %q, got %q", want, names)
+	}
+	if contents["./keep.log"] != "kept" || contents["./build"] != "not a dir" {
+		t.Errorf("unexpected contents %q", contents)
+	}
+}
+
+func TestTarReaderStrictPaths(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	pm, err := New(nil, WithStrictPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTarReader(tar.NewReader(&buf), pm).Next(); !errors.Is(err, ErrPathEscapesRoot) {
+		t.Errorf("expected ErrPathEscapesRoot, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package patternmatcher
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+)
+
+// TreeNode is a file or directory of the tree returned by WalkAndMatch.
+type TreeNode struct {
+	// Name is the base name of the node, or "." for the root.
+	Name string
+	// Path is the slash-separated path of the node relative to the root.
+	Path  string
+	IsDir bool
+	// Matched reports whether pm matches the node, such as a file excluded
+	// from a build context.
+	Matched bool
+	// Pattern is the pattern deciding Matched, or nil if no pattern matches
+	// the node.
+	Pattern *Pattern
+	// Pruned reports that the contents of a matched directory weren't
+	// walked, because all of them are matched as well.
+	Pruned   bool
+	Children []*TreeNode
+}
+
+// WalkAndMatch walks the file tree rooted at root in fsys, and returns it
+// with the decision of pm for every node, for tools showing what a build
+// context will contain. Children are sorted by name. As with WalkDir,
+// directories pruned with WithSkipVCSDirs or WithExcludeIfPresent are left
+// out, and matched directories are only descended into when a path beneath
+// them may not be matched.
+func (pm *PatternMatcher) WalkAndMatch(fsys fs.FS, root string) (*TreeNode, error) {
+	var tree *TreeNode
+	nodes := make(map[string]*TreeNode)
+	err := pm.walkDecisions(fsys, root, func(rel string, d fs.DirEntry, matched bool, pattern *Pattern, pruned bool) error {
+		node := &TreeNode{
+			Name:    path.Base(rel),
+			Path:    rel,
+			IsDir:   d.IsDir(),
+			Matched: matched,
+			Pattern: pattern,
+			Pruned:  pruned,
+		}
+		if rel == "." {
+			tree = node
+		} else {
+			parent := nodes[path.Dir(rel)]
+			parent.Children = append(parent.Children, node)
+		}
+		if d.IsDir() && !pruned {
+			nodes[rel] = node
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// walkDecisionFunc is called by walkDecisions for every path walked, with
+// its path relative to the root, the decision of pm and the pattern
+// deciding it. pruned reports that the contents of a directory won't be
+// walked.
+type walkDecisionFunc func(rel string, d fs.DirEntry, matched bool, pattern *Pattern, pruned bool) error
+
+// walkDecisions walks the file tree rooted at root in fsys, including the
+// matched paths, and calls fn with the decision for each of them. It prunes
+// directories as WalkDir does.
+func (pm *PatternMatcher) walkDecisions(fsys fs.FS, root string, fn walkDecisionFunc) error {
+	var infos dirInfos
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(root, p)
+		if d.IsDir() && rel != "." && pm.opts.prunesDir(fsys, p, d.Name()) {
+			return fs.SkipDir
+		}
+		e := &evaluation{opts: &MatchOpts{IsDir: d.IsDir()}, needPattern: true}
+		if pm.predicates {
+			if e.opts.Info, err = d.Info(); err != nil {
+				return err
+			}
+			e.parentInfos = infos.visit(rel, e.opts.Info)
+		}
+		matched, pattern := pm.matchesOrParentMatches(rel, e)
+		if err := e.pathError(rel); err != nil {
+			return err
+		}
+		pruned := false
+		if d.IsDir() && matched {
+			if watch, err := pm.ShouldWatch(rel); err == nil && !watch {
+				pruned = true
+			}
+		}
+		if err := fn(rel, d, matched, pattern, pruned); err != nil {
+			return err
+		}
+		if pruned {
+			return fs.SkipDir
+		}
+		return nil
+	})
+}
+
+// Render writes the tree rooted at n to w as indented text, one node per
+// line, like the tree command. Each node is annotated with the decision
+// and the pattern deciding it, and pruned directories are marked:
+//
+//	.
+//	├── build/ excluded by "build", pruned
+//	├── node_modules/ excluded by "node_modules"
+//	│   └── keep.js included by "!node_modules/keep.js"
+//	└── main.go
+func (n *TreeNode) Render(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	n.render(bw, "", "")
+	return bw.Flush()
+}
+
+func (n *TreeNode) render(bw *bufio.Writer, prefix, childPrefix string) {
+	bw.WriteString(prefix)
+	bw.WriteString(n.Name)
+	if n.IsDir && n.Path != "." {
+		bw.WriteByte('/')
+	}
+	if n.Pattern != nil {
+		if n.Matched {
+			bw.WriteString(" excluded by ")
+		} else {
+			bw.WriteString(" included by ")
+		}
+		bw.WriteString(strconv.Quote(n.Pattern.style.toSlash(n.Pattern.String())))
+	} else if n.Matched {
+		bw.WriteString(" excluded")
+	}
+	if n.Pruned {
+		bw.WriteString(", pruned")
+	}
+	bw.WriteByte('\n')
+	for i, c := range n.Children {
+		if i == len(n.Children)-1 {
+			c.render(bw, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			c.render(bw, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
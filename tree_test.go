@@ -0,0 +1,85 @@
+package patternmatcher
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalkAndMatch(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "build", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := pm.WalkAndMatch(walkFS, "ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var flatten func(n *TreeNode)
+	flatten = func(n *TreeNode) {
+		line := fmt.Sprintf("%s %v", n.Path, n.Matched)
+		if n.Pattern != nil {
+			line += " " + n.Pattern.String()
+		}
+		if n.Pruned {
+			line += " pruned"
+		}
+		got = append(got, line)
+		for _, c := range n.Children {
+			flatten(c)
+		}
+	}
+	flatten(tree)
+	want := []string{
+		". false",
+		"Dockerfile false",
+		"build true build pruned",
+		"docs false",
+		"docs/build false",
+		"node_modules true node_modules",
+		"node_modules/a true node_modules pruned",
+		"node_modules/keep.js false !node_modules/keep.js",
+		"src false",
+		"src/main.go false",
+		"src/pkg false",
+		"src/pkg/lib.go false",
+		"src/pkg/lib_test.go true **/*_test.go",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected\n%q\ngot\n%q", want, got)
+	}
+}
+
+func TestTreeNodeRender(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "build", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := pm.WalkAndMatch(walkFS, "ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tree.Render(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `.
+├── Dockerfile
+├── build/ excluded by "build", pruned
+├── docs/
+│   └── build
+├── node_modules/ excluded by "node_modules"
+│   ├── a/ excluded by "node_modules", pruned
+│   └── keep.js included by "!node_modules/keep.js"
+└── src/
+    ├── main.go
+    └── pkg/
+        ├── lib.go
+        └── lib_test.go excluded by "**/*_test.go"
+`
+	if got := sb.String(); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,267 @@
+package patternmatcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WalkDir walks the file tree rooted at root in fsys like fs.WalkDir, but
+// only calls fn for the paths pm doesn't match, such as the files of a build
+// context which aren't excluded by an ignore file. Paths are matched
+// relative to root, and directories honor patterns ending with a path
+// separator. Matched directories are only descended into when a path
+// beneath them may not be matched, as reported by ShouldWatch. The
+// predicates of rules are evaluated with the attributes of the walked paths.
+//
+// Directories skipped with WithSkipVCSDirs or WithExcludeIfPresent are
+// pruned without being passed to fn. The path passed to fn is the path in
+// fsys, as with fs.WalkDir.
+func (pm *PatternMatcher) WalkDir(fsys fs.FS, root string, fn fs.WalkDirFunc) error {
+	return pm.WalkDirWithOpts(fsys, root, fn, WalkOpts{})
+}
+
+// WalkOpts are options applying to a single call to WalkDirWithOpts, which
+// bound the walk for previews and for finding out whether any path isn't
+// matched. The zero value walks the whole tree.
+type WalkOpts struct {
+	// MaxResults stops the walk once fn was called for that many paths
+	// other than root. Zero means no limit.
+	MaxResults int
+	// MaxDepth stops the walk from descending into directories more than
+	// MaxDepth elements beneath root, which are passed to fn without
+	// their contents. Zero means no limit.
+	MaxDepth int
+	// StopAfterFirst stops the walk after the first path other than root,
+	// as a MaxResults of 1 does.
+	StopAfterFirst bool
+}
+
+// errStopWalk ends a walk once WalkOpts.MaxResults is reached.
+var errStopWalk = errors.New("stop walk")
+
+// WalkDirWithOpts is like WalkDir, but applies opts to the walk. A walk
+// stopped by MaxResults or StopAfterFirst returns nil.
+func (pm *PatternMatcher) WalkDirWithOpts(fsys fs.FS, root string, fn fs.WalkDirFunc, opts WalkOpts) error {
+	if opts.StopAfterFirst {
+		opts.MaxResults = 1
+	}
+	var err error
+	if instr := pm.opts.instrumentation; instr != nil {
+		var stats WalkStats
+		start := time.Now()
+		err = pm.walkDir(fsys, root, fn, opts, &stats)
+		stats.Elapsed = time.Since(start)
+		instr.WalkDone(stats)
+	} else {
+		err = pm.walkDir(fsys, root, fn, opts, &WalkStats{})
+	}
+	if err == errStopWalk {
+		return nil
+	}
+	return err
+}
+
+func (pm *PatternMatcher) walkDir(fsys fs.FS, root string, fn fs.WalkDirFunc, opts WalkOpts, stats *WalkStats) error {
+	results := 0
+	var infos dirInfos
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(p, d, err)
+		}
+		rel := relPath(root, p)
+		if d.IsDir() && rel != "." && pm.opts.prunesDir(fsys, p, d.Name()) {
+			return fs.SkipDir
+		}
+		stats.Visited++
+		matchOpts := MatchOpts{IsDir: d.IsDir()}
+		if pm.predicates {
+			if matchOpts.Info, err = d.Info(); err != nil {
+				return fn(p, d, err)
+			}
+		}
+		matched, err := pm.matchesWalked(rel, matchOpts, infos.visit(rel, matchOpts.Info))
+		if err != nil {
+			return fn(p, d, err)
+		}
+		tooDeep := d.IsDir() && opts.MaxDepth > 0 && rel != "." && strings.Count(rel, "/")+1 >= opts.MaxDepth
+		if !matched {
+			stats.Included++
+			if err := fn(p, d, nil); err != nil {
+				return err
+			}
+			if rel != "." {
+				results++
+				if opts.MaxResults > 0 && results >= opts.MaxResults {
+					return errStopWalk
+				}
+			}
+			if tooDeep {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if tooDeep {
+				return fs.SkipDir
+			}
+			if !pm.mayReincludeBeneath(rel) {
+				return fs.SkipDir
+			}
+		}
+		return nil
+	})
+}
+
+// WalkDirMatcher is like PatternMatcher.WalkDir, but with any Matcher, so
+// that hand-written matchers can select the walked paths. Matched
+// directories are descended into, as m can't tell whether a path beneath
+// them may not be matched, unless m is a PatternMatcher, whose WalkDir is
+// used.
+func WalkDirMatcher(m Matcher, fsys fs.FS, root string, fn fs.WalkDirFunc) error {
+	if pm, ok := m.(*PatternMatcher); ok {
+		return pm.WalkDir(fsys, root, fn)
+	}
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(p, d, err)
+		}
+		matched, err := m.Matches(relPath(root, p))
+		if err != nil {
+			return fn(p, d, err)
+		}
+		if matched {
+			return nil
+		}
+		return fn(p, d, nil)
+	})
+}
+
+// WalkFunc adapts fn, a callback for filepath.Walk, so that it is only
+// called for the paths pm doesn't match, and matched directories are pruned
+// as with WalkDir, for code which can't move to WalkDir:
+//
+//	err := filepath.Walk(root, pm.WalkFunc(root, fn))
+//
+// Paths are matched relative to root, which must be the root passed to
+// filepath.Walk. The path passed to fn is the one passed by filepath.Walk.
+func (pm *PatternMatcher) WalkFunc(root string, fn filepath.WalkFunc) filepath.WalkFunc {
+	var infos dirInfos
+	return func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fn(p, info, err)
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return fn(p, info, err)
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() && rel != "." && pm.opts.prunesDir(os.DirFS(p), ".", info.Name()) {
+			return filepath.SkipDir
+		}
+		matched, err := pm.matchesWalked(rel, MatchOpts{IsDir: info.IsDir(), Info: info}, infos.visit(rel, info))
+		if err != nil {
+			return fn(p, info, err)
+		}
+		if !matched {
+			return fn(p, info, nil)
+		}
+		if info.IsDir() {
+			if !pm.mayReincludeBeneath(rel) {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	}
+}
+
+// prunesDir reports whether the walkers prune the directory name at p in
+// fsys, because of WithSkipVCSDirs or WithExcludeIfPresent.
+func (o *options) prunesDir(fsys fs.FS, p, name string) bool {
+	if o.skipDirs[name] {
+		return true
+	}
+	for _, marker := range o.markers {
+		if _, err := fs.Stat(fsys, path.Join(p, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dirInfos holds the attributes of the directories enclosing the paths of
+// a depth-first walk, for the predicates of rules.
+type dirInfos []fs.FileInfo
+
+// visit returns the attributes of the parent dirs of the slash-separated
+// path rel, relative to the walked root, and records info as those of rel
+// if it's a directory.
+func (s *dirInfos) visit(rel string, info fs.FileInfo) []fs.FileInfo {
+	if rel == "." {
+		return nil
+	}
+	depth := strings.Count(rel, "/")
+	if depth > len(*s) {
+		// The parent dir wasn't visited, such as when walking a subtree.
+		return nil
+	}
+	parents := (*s)[:depth]
+	if info != nil && info.IsDir() {
+		*s = append(parents, info)
+	}
+	return parents
+}
+
+// relPath returns p relative to root, both being paths of an fs.FS.
+func relPath(root, p string) string {
+	if root == "." {
+		return p
+	}
+	if p == root {
+		return "."
+	}
+	return strings.TrimPrefix(p, root+"/")
+}
+
+// DirSizes walks the file tree rooted at root in fsys, and returns the total
+// size of the regular files pm doesn't match, grouped by their directory
+// relative to root truncated to depth elements. With a depth of 1, the size
+// of each top-level directory is reported, and files directly in root are
+// accounted to ".". A depth of 0 accounts every file to ".", and a negative
+// depth is an error.
+func (pm *PatternMatcher) DirSizes(fsys fs.FS, root string, depth int) (map[string]int64, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("invalid depth %d", depth)
+	}
+	sizes := make(map[string]int64)
+	err := pm.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dir := path.Dir(relPath(root, p))
+		if elems := strings.Split(dir, "/"); dir != "." && len(elems) > depth {
+			dir = strings.Join(elems[:depth], "/")
+			if dir == "" {
+				dir = "."
+			}
+		}
+		sizes[dir] += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
@@ -0,0 +1,269 @@
+package patternmatcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var walkFS = fstest.MapFS{
+	"ctx/Dockerfile":              {Data: make([]byte, 10)},
+	"ctx/src/main.go":             {Data: make([]byte, 100)},
+	"ctx/src/pkg/lib.go":          {Data: make([]byte, 50)},
+	"ctx/src/pkg/lib_test.go":     {Data: make([]byte, 25)},
+	"ctx/node_modules/a/index.js": {Data: make([]byte, 1000)},
+	"ctx/node_modules/keep.js":    {Data: make([]byte, 7)},
+	"ctx/build/out":               {Data: make([]byte, 500)},
+	"ctx/docs/build":              {Data: make([]byte, 3)},
+}
+
+func TestWalkDir(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/build/", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	err = pm.WalkDir(walkFS, "ctx", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ctx",
+		"ctx/Dockerfile",
+		"ctx/docs",
+		"ctx/docs/build",
+		"ctx/node_modules/keep.js",
+		"ctx/src",
+		"ctx/src/main.go",
+		"ctx/src/pkg",
+		"ctx/src/pkg/lib.go",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+}
+
+func TestDirSizes(t *testing.T) {
+	pm, err := New([]string{"node_modules", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		depth int
+		want  map[string]int64
+	}{
+		{0, map[string]int64{".": 10 + 100 + 50 + 500 + 3}},
+		{1, map[string]int64{".": 10, "src": 150, "build": 500, "docs": 3}},
+		{2, map[string]int64{".": 10, "src": 100, "src/pkg": 50, "build": 500, "docs": 3}},
+	}
+	for _, tt := range tests {
+		sizes, err := pm.DirSizes(walkFS, "ctx", tt.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sizes, tt.want) {
+			t.Errorf("depth %d: expected %v, got %v", tt.depth, tt.want, sizes)
+		}
+	}
+	if _, err := pm.DirSizes(walkFS, "ctx", -1); err == nil {
+		t.Error("expected an error for a negative depth")
+	}
+}
+
+func TestWalkDirSkipVCSDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":        {},
+		"src/.svn/entries": {},
+		"src/main.go":      {},
+		".hg/store/data":   {},
+		".gitignore":       {},
+	}
+	tests := []struct {
+		opts []Option
+		want []string
+	}{
+		{nil, []string{".", ".git", ".git/HEAD", ".gitignore", ".hg", ".hg/store", ".hg/store/data", "src", "src/.svn", "src/.svn/entries", "src/main.go"}},
+		{[]Option{WithSkipVCSDirs()}, []string{".", ".gitignore", "src", "src/main.go"}},
+		{[]Option{WithSkipVCSDirs(".hg")}, []string{".", ".git", ".git/HEAD", ".gitignore", "src", "src/.svn", "src/.svn/entries", "src/main.go"}},
+	}
+	for _, tt := range tests {
+		// An exclusion doesn't bring back the skipped directories.
+		pm, err := New([]string{"!.git"}, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		err = pm.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			paths = append(paths, p)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(paths, tt.want) {
+			t.Errorf("expected %q, got %q", tt.want, paths)
+		}
+	}
+}
+
+func TestWalkDirExcludeIfPresent(t *testing.T) {
+	fsys := fstest.MapFS{
+		"CACHEDIR.TAG":             {},
+		"cache/CACHEDIR.TAG":       {},
+		"cache/blob":               {},
+		"src/main.go":              {},
+		"src/tmp/.nobackup":        {},
+		"src/tmp/scratch":          {},
+		"src/vendor/lib/.nobackup": {},
+	}
+	pm, err := New(nil, WithExcludeIfPresent("CACHEDIR.TAG", ".nobackup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	err = pm.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		paths = append(paths, p)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The root isn't pruned, even with a marker.
+	want := []string{".", "CACHEDIR.TAG", "src", "src/main.go", "src/vendor"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+
+	included, _, err := DiffTree(fsys, ".", pm, MustNew(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"cache/CACHEDIR.TAG", "cache/blob", "src/tmp/.nobackup", "src/tmp/scratch", "src/vendor/lib/.nobackup"}
+	if !reflect.DeepEqual(included, want) {
+		t.Errorf("expected %q to become included, got %q", want, included)
+	}
+}
+
+func TestWalkFunc(t *testing.T) {
+	root := t.TempDir()
+	for name, f := range walkFS {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, f.Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root = filepath.Join(root, "ctx")
+
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/build/", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	err = filepath.Walk(root, pm.WalkFunc(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".",
+		"Dockerfile",
+		"docs",
+		"docs/build",
+		"node_modules/keep.js",
+		"src",
+		"src/main.go",
+		"src/pkg",
+		"src/pkg/lib.go",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+}
+
+func TestWalkDirWithOpts(t *testing.T) {
+	pm, err := New([]string{"node_modules", "!node_modules/keep.js", "**/build/", "**/*_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		opts WalkOpts
+		want []string
+	}{
+		{WalkOpts{MaxResults: 3}, []string{"ctx", "ctx/Dockerfile", "ctx/docs", "ctx/docs/build"}},
+		{WalkOpts{StopAfterFirst: true}, []string{"ctx", "ctx/Dockerfile"}},
+		{WalkOpts{MaxDepth: 1}, []string{"ctx", "ctx/Dockerfile", "ctx/docs", "ctx/src"}},
+		{WalkOpts{MaxDepth: 2, MaxResults: 5}, []string{"ctx", "ctx/Dockerfile", "ctx/docs", "ctx/docs/build", "ctx/node_modules/keep.js", "ctx/src"}},
+	}
+	for _, tt := range tests {
+		var paths []string
+		err := pm.WalkDirWithOpts(walkFS, "ctx", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			paths = append(paths, p)
+			return nil
+		}, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(paths, tt.want) {
+			t.Errorf("%+v: expected %q, got %q", tt.opts, tt.want, paths)
+		}
+	}
+}
+
+// suffixMatcher is a hand-written Matcher matching the paths ending with a
+// suffix.
+type suffixMatcher string
+
+func (m suffixMatcher) Matches(path string) (bool, error) {
+	return strings.HasSuffix(path, string(m)), nil
+}
+
+func TestWalkDirMatcher(t *testing.T) {
+	for _, tt := range []struct {
+		m        Matcher
+		expected []string
+	}{
+		{suffixMatcher(".go"), []string{".", "Dockerfile", "build", "build/out", "docs", "docs/build", "node_modules", "node_modules/a", "node_modules/a/index.js", "node_modules/keep.js", "src", "src/pkg"}},
+		{MustNew([]string{"node_modules", "src"}), []string{".", "Dockerfile", "build", "build/out", "docs", "docs/build"}},
+		{NewChain(AllMustInclude, MustNew([]string{"node_modules"}), MustNew([]string{"src", "build"})), []string{".", "Dockerfile", "docs", "docs/build"}},
+	} {
+		var paths []string
+		err := WalkDirMatcher(tt.m, walkFS, "ctx", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			paths = append(paths, relPath("ctx", p))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("unexpected paths %q", paths)
+		}
+	}
+}
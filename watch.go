@@ -0,0 +1,202 @@
+package patternmatcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// ShouldWatch reports whether a file watcher needs to watch dir, that is
+// whether a path beneath dir may not be matched by pm. It returns false for
+// directories whose whole tree is matched, such as an ignored node_modules,
+// unless an exclusion pattern may re-include paths beneath them.
+//
+// The answer is conservative: ShouldWatch may return true for a directory
+// whose contents all end up matched, but never false for one which may
+// contain a path that isn't matched.
+//
+// The "dir" argument should be a slash-delimited path.
+func (pm *PatternMatcher) ShouldWatch(dir string) (bool, error) {
+	matched, err := pm.MatchesOrParentMatches(dir)
+	if err != nil || !matched {
+		return true, err
+	}
+	return pm.mayReincludeBeneath(dir), nil
+}
+
+// mayReincludeBeneath reports whether an exclusion pattern of pm may match
+// a path beneath the slash-separated directory dir, or whether a path
+// beneath dir may not be matched at all.
+func (pm *PatternMatcher) mayReincludeBeneath(dir string) bool {
+	if pm.opts.noParents {
+		// Matching dir doesn't match the paths beneath it.
+		return true
+	}
+	var dirElems []string
+	if dir = pm.opts.style.clean(pm.opts.style.fromSlash(dir)); dir != "." {
+		dirElems = strings.Split(dir, string(pm.opts.style.sep()))
+	}
+	for _, p := range pm.patterns {
+		if p.Exclusion && p.mayMatchBeneath(dirElems) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatchBeneath reports whether p may match a path beneath the directory
+// made of dirElems. Patterns with no more elements than the directory can
+// only match it or its parents, and elements with "**" can match anything,
+// unless the depth of "**" is limited.
+func (p *Pattern) mayMatchBeneath(dirElems []string) bool {
+	return p.mayMatchElemsBeneath(p.Dirs, dirElems)
+}
+
+func (p *Pattern) mayMatchElemsBeneath(elems, dirElems []string) bool {
+	for i, elem := range elems {
+		if i == len(dirElems) {
+			return true
+		}
+		if elem == "**" && p.globstarDepth > 0 && i < len(elems)-1 {
+			// "**" spans up to globstarDepth elements of the directory,
+			// or all the remaining ones.
+			for n := 0; n <= p.globstarDepth; n++ {
+				if i+n >= len(dirElems) || p.mayMatchElemsBeneath(elems[i+1:], dirElems[i+n:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if strings.Contains(elem, "**") {
+			return true
+		}
+		name := dirElems[i]
+		if p.foldCase {
+			elem, name = strings.ToLower(elem), strings.ToLower(name)
+		}
+		if ok, err := p.style.match(elem, name); !ok && err == nil {
+			return false
+		}
+	}
+	return false
+}
+
+// WatchRoots returns the minimal set of directories a watcher must watch
+// recursively to observe every path pm may not match, taking the matched
+// paths as ignored as ShouldWatch does. It also returns the directories
+// beneath the roots which the watcher can skip, because their whole tree
+// is matched, for which ShouldWatch can return false as well.
+//
+// The roots are derived from the literal prefixes of the exclusion
+// patterns, which re-include paths, unless paths aren't matched by default,
+// in which case the root is ".". To watch the paths a matcher selects
+// rather than ignores, such as a list of source globs, call WatchRoots on
+// its Invert.
+//
+// Paths are slash-separated, and "." stands for the root. A root is a file
+// rather than a directory when an exclusion pattern is the literal path of
+// a file.
+func (pm *PatternMatcher) WatchRoots() (roots, skip []string) {
+	return pm.Invert().matchedRoots()
+}
+
+// matchedRoots returns the directories to watch to observe every path pm
+// may match, derived from the literal prefixes of the inclusion patterns,
+// and those beneath them which an exclusion pattern rules out.
+func (pm *PatternMatcher) matchedRoots() (roots, skip []string) {
+	if pm.opts.defaultMatch {
+		roots = append(roots, ".")
+	}
+	for _, p := range pm.patterns {
+		if !p.Exclusion {
+			roots = append(roots, joinDirs(p.literalDirs()))
+		}
+	}
+	roots = outermostDirs(roots)
+
+	for _, p := range pm.patterns {
+		if !p.Exclusion {
+			continue
+		}
+		dirs := p.literalDirs()
+		if len(dirs) != len(p.Dirs) {
+			continue
+		}
+		dir := joinDirs(dirs)
+		if hasAncestor(roots, dir) && pm.canSkip(dirs) {
+			skip = append(skip, dir)
+		}
+	}
+	return roots, outermostDirs(skip)
+}
+
+// canSkip reports whether no path beneath or at the directory made of
+// dirElems is matched.
+func (pm *PatternMatcher) canSkip(dirElems []string) bool {
+	dir := strings.Join(dirElems, string(pm.opts.style.sep()))
+	e := &evaluation{needPattern: true}
+	matched, decider := pm.matchesOrParentMatches(dir, e)
+	if matched || e.budgetExceeded {
+		return false
+	}
+	// Inclusion patterns preceding the exclusion deciding the directory
+	// are overridden by it for paths beneath it as well.
+	start := 0
+	if decider != nil && pm.opts.resolution == LastMatchWins && !pm.opts.gitReinclusion {
+		for i, p := range pm.patterns {
+			if p == decider {
+				start = i + 1
+			}
+		}
+	}
+	for _, p := range pm.patterns[start:] {
+		if !p.Exclusion && p.mayMatchBeneath(dirElems) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalDirs returns the leading elements of the pattern's path which are
+// literals.
+func (p *Pattern) literalDirs() []string {
+	if p.foldCase {
+		return nil
+	}
+	for i, elem := range p.Dirs {
+		if strings.ContainsAny(elem, `*?[\`) {
+			return p.Dirs[:i]
+		}
+	}
+	return p.Dirs
+}
+
+// joinDirs returns the slash-separated path made of dirs.
+func joinDirs(dirs []string) string {
+	if len(dirs) == 0 {
+		return "."
+	}
+	return strings.Join(dirs, "/")
+}
+
+// outermostDirs sorts dirs and removes those beneath another one, along
+// with duplicates.
+func outermostDirs(dirs []string) []string {
+	sort.Strings(dirs)
+	var outermost []string
+	for _, dir := range dirs {
+		if !hasAncestor(outermost, dir) {
+			outermost = append(outermost, dir)
+		}
+	}
+	return outermost
+}
+
+// hasAncestor reports whether dir is one of dirs or beneath one of them.
+func hasAncestor(dirs []string, dir string) bool {
+	for _, d := range dirs {
+		if d == "." || d == dir || strings.HasPrefix(dir, d+"/") {
+			return true
+		}
+	}
+	return false
+}
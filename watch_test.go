@@ -0,0 +1,117 @@
+package patternmatcher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShouldWatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		dir      string
+		watch    bool
+	}{
+		{[]string{"node_modules"}, "node_modules", false},
+		{[]string{"node_modules"}, "node_modules/lib", false},
+		{[]string{"node_modules"}, "src", true},
+		{[]string{"node_modules"}, ".", true},
+		{[]string{"**/node_modules"}, "web/node_modules", false},
+		{[]string{"node_modules", "!node_modules/keep"}, "node_modules", true},
+		{[]string{"node_modules", "!node_modules/keep"}, "node_modules/other", false},
+		{[]string{"node_modules", "!node_modules/*/README.md"}, "node_modules/lib", true},
+		{[]string{"node_modules", "!**/README.md"}, "node_modules/lib", true},
+		{[]string{"node_modules", "!src"}, "node_modules", false},
+		{[]string{"*", "!*/keep"}, "a", true},
+		{[]string{"*", "!b/keep"}, "a", false},
+		{[]string{"a", "!a/b", "a/b/c"}, "a/b/c", false},
+		{[]string{"a", "!a/b", "a/b/c"}, "a/b", true},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		watch, err := pm.ShouldWatch(tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if watch != tt.watch {
+			t.Errorf("patterns %q, dir %q: expected %v, got %v", tt.patterns, tt.dir, tt.watch, watch)
+		}
+	}
+}
+
+func TestWatchRoots(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		opts     []Option
+		roots    []string
+		skip     []string
+	}{
+		{[]string{"src/**/*.go", "docs/*.md", "src/cmd"}, nil, []string{"docs", "src"}, nil},
+		{[]string{"src", "*.md"}, nil, []string{"."}, nil},
+		{[]string{"src", "!src/vendor"}, nil, []string{"src"}, []string{"src/vendor"}},
+		{[]string{"src", "!src/vendor", "src/vendor/keep"}, nil, []string{"src"}, nil},
+		{[]string{"src/vendor/keep", "src", "!src/vendor"}, nil, []string{"src"}, []string{"src/vendor"}},
+		{[]string{"src", "!src/vendor", "!src/vendor/x", "!other"}, nil, []string{"src"}, []string{"src/vendor"}},
+		{[]string{"src", "!src/*/testdata"}, nil, []string{"src"}, nil},
+		{[]string{"!node_modules"}, []Option{WithDefaultMatch(true)}, []string{"."}, []string{"node_modules"}},
+		{nil, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The patterns select the paths to watch.
+		roots, skip := pm.Invert().WatchRoots()
+		if !reflect.DeepEqual(roots, tt.roots) || !reflect.DeepEqual(skip, tt.skip) {
+			t.Errorf("patterns %q: expected roots %q and skip %q, got %q and %q", tt.patterns, tt.roots, tt.skip, roots, skip)
+		}
+	}
+}
+
+func TestWatchRootsShouldWatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		opts     []Option
+		roots    []string
+		skip     []string
+	}{
+		{[]string{"node_modules", "*.log"}, nil, []string{"."}, []string{"node_modules"}},
+		{[]string{"node_modules", "!node_modules/keep"}, nil, []string{"."}, nil},
+		{[]string{"!src", "src/vendor"}, []Option{WithDefaultMatch(true)}, []string{"src"}, []string{"src/vendor"}},
+	}
+	dirs := []string{".", "docs", "node_modules", "node_modules/keep", "node_modules/x", "src", "src/vendor", "src/vendor/x"}
+	for _, tt := range tests {
+		pm, err := New(tt.patterns, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots, skip := pm.WatchRoots()
+		if !reflect.DeepEqual(roots, tt.roots) || !reflect.DeepEqual(skip, tt.skip) {
+			t.Errorf("patterns %q: expected roots %q and skip %q, got %q and %q", tt.patterns, tt.roots, tt.skip, roots, skip)
+		}
+		// The directories ShouldWatch wants watched are observed from the
+		// roots, and those skipped don't need to be.
+		for _, dir := range dirs {
+			watch, err := pm.ShouldWatch(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			observed := hasAncestor(roots, dir) && !hasAncestor(skip, dir)
+			for _, root := range roots {
+				if dir == "." || strings.HasPrefix(root, dir+"/") {
+					observed = true
+				}
+			}
+			if watch && !observed {
+				t.Errorf("patterns %q: ShouldWatch(%q) is true, but the roots don't observe it", tt.patterns, dir)
+			}
+			if hasAncestor(skip, dir) && watch {
+				t.Errorf("patterns %q: %q is skipped, but ShouldWatch is true", tt.patterns, dir)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package patternmatcher
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/moby/patternmatcher/internal/glob"
+)
+
+// WildcardUnit selects what the "?" wildcard and character classes match.
+type WildcardUnit int
+
+const (
+	// RuneWildcards makes "?" and character classes match a single
+	// character, as filepath.Match does, which is what Docker expects. This
+	// is the default.
+	RuneWildcards WildcardUnit = iota
+	// ByteWildcards makes "?" and character classes match a single byte, as
+	// Git, Subversion and the fnmatch of C libraries in the C locale do, so
+	// that "??" matches "é". Classes can't list non-ASCII characters.
+	ByteWildcards
+)
+
+// WithWildcardUnit sets what the "?" wildcard and character classes match,
+// to reproduce the tool the patterns come from. It makes no difference for
+// ASCII paths.
+func WithWildcardUnit(unit WildcardUnit) Option {
+	return func(o *options) {
+		o.wildcards = unit
+	}
+}
+
+// errClassNotASCII is returned for patterns with a non-ASCII character in a
+// class when wildcards match bytes.
+var errClassNotASCII = errors.New("character class with a non-ASCII character can't match a single byte")
+
+// useBytes makes p match paths byte by byte, if it has wildcards whose
+// meaning depends on it. Its regexp then operates on paths whose bytes are
+// mapped to the runes of the same value, as done by bytesAsRunes.
+func (p *Pattern) useBytes() error {
+	pattern := p.CleanedPattern
+	if !strings.ContainsAny(pattern, "?[") {
+		return nil
+	}
+	escapes := p.style.escapes()
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\' && escapes:
+			i++
+		case pattern[i] == '[':
+			end := glob.ClassEnd(pattern, i)
+			if end == -1 {
+				continue
+			}
+			for j := i; j < end; j++ {
+				if pattern[j] >= utf8.RuneSelf {
+					return &PatternError{Pattern: p.String(), Err: errClassNotASCII}
+				}
+			}
+			i = end
+		}
+	}
+
+	_, re, err := compile(bytesAsRunes(pattern), p.style)
+	if err != nil {
+		return err
+	}
+	p.MatchType = RegexpMatch
+	p.Regexp = re
+	p.segment = nil
+	p.bytewise = true
+	return nil
+}
+
+// foldBytesRegexp returns the version of the regexp of p matching paths
+// folded with foldString byte by byte. Unlike with (?i), the bytes of
+// non-ASCII characters must be folded the same way as those of paths.
+func (p *Pattern) foldBytesRegexp() *regexp.Regexp {
+	folded := Pattern{style: p.style}
+	if err := folded.init(foldString(p.CleanedPattern), new([]string)); err != nil {
+		return nil
+	}
+	if err := folded.useBytes(); err != nil || folded.Regexp == nil {
+		return nil
+	}
+	folded.capGlobstar(p.globstarDepth)
+	return folded.Regexp
+}
+
+// matchRegexp returns true if re, the regexp of p or its case-insensitive
+// version, matches path.
+func (p *Pattern) matchRegexp(re *regexp.Regexp, path string) bool {
+	if p.bytewise {
+		path = bytesAsRunes(path)
+	}
+	return re.MatchString(path)
+}
+
+// bytesAsRunes returns s with each of its bytes replaced by the rune of the
+// same value, so that regexps can match its bytes one by one. ASCII strings
+// are returned as is.
+func bytesAsRunes(s string) string {
+	i := 0
+	for i < len(s) && s[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) + len(s) - i)
+	sb.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		sb.WriteRune(rune(s[i]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package patternmatcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWildcardUnit(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		runes   bool
+		bytes   bool
+	}{
+		{"caf?", "café", true, false},
+		{"caf??", "café", false, true},
+		{"caf??", "cafe", false, false},
+		{"?", "é", true, false},
+		{"??", "é", false, true},
+		{"[!a]", "é", true, false},
+		{"[!a]?", "é", false, true},
+		{"d?r/*.go", "dér/main.go", true, false},
+		{"d??r/*.go", "dér/main.go", false, true},
+		{"**/?.txt", "sub/x.txt", true, true},
+		{"é?", "éx", true, true},
+	}
+	for _, tt := range tests {
+		for _, unit := range []WildcardUnit{RuneWildcards, ByteWildcards} {
+			pm, err := New([]string{tt.pattern}, WithWildcardUnit(unit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.runes
+			if unit == ByteWildcards {
+				want = tt.bytes
+			}
+			for _, opts := range []MatchOpts{{}, {FoldCase: true}} {
+				match, err := pm.MatchesWithOpts(tt.path, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if match != want {
+					t.Errorf("pattern %q, path %q, unit %v, %+v: expected %v, got %v", tt.pattern, tt.path, unit, opts, want, match)
+				}
+			}
+		}
+	}
+
+	pm, err := New([]string{"CAFÉ??"}, WithWildcardUnit(ByteWildcards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, _ := pm.MatchesWithOpts("café/é", MatchOpts{FoldCase: true}); match {
+		t.Errorf("expected CAFÉ?? not to match café/é")
+	}
+	if match, _ := pm.MatchesWithOpts("caféé", MatchOpts{FoldCase: true}); !match {
+		t.Errorf("expected CAFÉ?? to match caféé regardless of case")
+	}
+
+	if _, err := New([]string{"[é]"}, WithWildcardUnit(ByteWildcards)); !errors.Is(err, errClassNotASCII) {
+		t.Errorf("expected errClassNotASCII, got %v", err)
+	}
+}